@@ -0,0 +1,141 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the type of a federation object status condition
+type ConditionType string
+
+const (
+	// ConditionReady is True when the object is fully effected in the mesh
+	ConditionReady ConditionType = "Ready"
+	// ConditionReconciled is True when the style created all of the underlying objects
+	ConditionReconciled ConditionType = "Reconciled"
+	// ConditionEndpointsResolved is True when the ingress endpoints (exposition) or the
+	// remote endpoints (binding) are known and usable
+	ConditionEndpointsResolved ConditionType = "EndpointsResolved"
+	// ConditionDiscoveryPublished is True when the object has been handed to the discovery service
+	ConditionDiscoveryPublished ConditionType = "DiscoveryPublished"
+	// ConditionConnected is True while a discovery peer's server is connected
+	ConditionConnected ConditionType = "Connected"
+	// ConditionImportConflict is True while services of a discovery peer are not imported,
+	// or not merged, because other services map to the same ServiceBinding
+	ConditionImportConflict ConditionType = "ImportConflict"
+)
+
+// Reasons used by the controllers and styles when setting conditions
+const (
+	ReasonReconciled             = "Reconciled"
+	ReasonReconcileFailed        = "ReconcileFailed"
+	ReasonMeshFedConfigNotFound  = "MeshFedConfigNotFound"
+	ReasonUnknownMode            = "UnknownMode"
+	ReasonEndpointsResolved      = "EndpointsResolved"
+	ReasonEndpointsUnavailable   = "EndpointsUnavailable"
+	ReasonSecretNotFound         = "SecretNotFound"
+	ReasonGatewayFailed          = "GatewayFailed"
+	ReasonVirtualServiceFailed   = "VirtualServiceFailed"
+	ReasonDestinationRuleFailed  = "DestinationRuleFailed"
+	ReasonServiceEntryFailed     = "ServiceEntryFailed"
+	ReasonServiceFailed          = "ServiceFailed"
+	ReasonRouteFailed            = "RouteFailed"
+	ReasonReferenceGrantFailed   = "ReferenceGrantFailed"
+	ReasonBackendTLSPolicyFailed = "BackendTLSPolicyFailed"
+	ReasonDeploymentFailed       = "DeploymentFailed"
+	ReasonTeardownFailed         = "TeardownFailed"
+	ReasonPublished              = "Published"
+	ReasonNotPublished           = "NotPublished"
+	ReasonDependenciesNotReady   = "DependenciesNotReady"
+	ReasonAllConditionsSatisfied = "AllConditionsSatisfied"
+	ReasonConnected              = "Connected"
+	ReasonConnectFailed          = "ConnectFailed"
+	ReasonNameCollision          = "NameCollision"
+	ReasonNoCollisions           = "NoCollisions"
+)
+
+// Condition describes one aspect of the state of a federation object
+type Condition struct {
+	Type   ConditionType          `json:"type"`
+	Status corev1.ConditionStatus `json:"status"`
+	// Last time the condition transitioned from one status to another
+	LastTransitionTime metav1.Time `json:"last_transition_time,omitempty"`
+	// One-word CamelCase reason for the condition's last transition
+	Reason string `json:"reason,omitempty"`
+	// Human readable message with details about the last transition
+	Message string `json:"message,omitempty"`
+}
+
+// GeneratedObject identifies a Kubernetes or Istio object created on behalf of a federation object
+type GeneratedObject struct {
+	APIVersion string `json:"api_version,omitempty"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// SetCondition adds or updates the condition of type t.  LastTransitionTime only
+// changes when the status changes.
+func SetCondition(conditions *[]Condition, t ConditionType, status corev1.ConditionStatus, reason, message string) {
+	for i := range *conditions {
+		c := &(*conditions)[i]
+		if c.Type != t {
+			continue
+		}
+		if c.Status != status {
+			c.Status = status
+			c.LastTransitionTime = metav1.Now()
+		}
+		c.Reason = reason
+		c.Message = message
+		return
+	}
+	*conditions = append(*conditions, Condition{
+		Type:               t,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// GetCondition returns the condition of type t, or nil
+func GetCondition(conditions []Condition, t ConditionType) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == t {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue returns true if the condition of type t is present and True
+func IsConditionTrue(conditions []Condition, t ConditionType) bool {
+	c := GetCondition(conditions, t)
+	return c != nil && c.Status == corev1.ConditionTrue
+}
+
+// AddGeneratedObject records obj in objs unless it is already there
+func AddGeneratedObject(objs *[]GeneratedObject, obj GeneratedObject) {
+	for _, o := range *objs {
+		if o.Kind == obj.Kind && o.Namespace == obj.Namespace && o.Name == obj.Name {
+			return
+		}
+	}
+	*objs = append(*objs, obj)
+}
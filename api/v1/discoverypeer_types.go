@@ -0,0 +1,121 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultDiscoveryPort is the port of an exposed services discovery (ESDS) server
+const DefaultDiscoveryPort = 50051
+
+// DiscoveryPeerSpec defines the desired state of DiscoveryPeer
+type DiscoveryPeerSpec struct {
+	// REQUIRED: The host name or IP address of the remote exposed services discovery server.
+	Address string `json:"address"`
+	// OPTIONAL: The port of the remote discovery server.  Defaults to 50051.
+	Port uint32 `json:"port,omitempty"`
+	// OPTIONAL: Mutual TLS for the connection.  The Secret is in the DiscoveryPeer's namespace.
+	TLS *DiscoveryTLS `json:"tls,omitempty"`
+	// OPTIONAL: The MeshFedConfig selector of the imported ServiceBindings.  Defaults to the
+	// selector sent by the peer.
+	MeshFedConfigSelector map[string]string `json:"mesh_fed_config_selector,omitempty"`
+	// OPTIONAL: Rules mapping the namespaces of imported services to local namespaces.  The
+	// first matching rule applies, before those of the MeshFedConfig the bindings select.
+	// Unmatched services keep their namespace.
+	NamespaceMapping []NamespaceMappingRule `json:"namespace_mapping,omitempty"`
+}
+
+// NamespaceMappingRule maps the namespace of services imported from a peer.  In to, prefix
+// and suffix {peer} is replaced by the DiscoveryPeer's name and {namespace} by the remote
+// namespace.
+type NamespaceMappingRule struct {
+	// REQUIRED: The remote namespace.  * matches any namespace and a trailing * any suffix.
+	From string `json:"from"`
+	// OPTIONAL: The local namespace, such as federated-{peer}.
+	To string `json:"to,omitempty"`
+	// OPTIONAL: Added before the remote namespace if to is not set.
+	Prefix string `json:"prefix,omitempty"`
+	// OPTIONAL: Added after the remote namespace if to is not set.
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// PeerConnectionState is the state of the connection to a discovery peer
+type PeerConnectionState string
+
+const (
+	PeerConnecting   PeerConnectionState = "Connecting"
+	PeerConnected    PeerConnectionState = "Connected"
+	PeerDisconnected PeerConnectionState = "Disconnected"
+)
+
+// DiscoveryPeerStatus defines the observed state of DiscoveryPeer
+type DiscoveryPeerStatus struct {
+	// The generation most recently reconciled by the controller
+	ObservedGeneration int64 `json:"observed_generation,omitempty"`
+	// Ready, Connected
+	Conditions []Condition `json:"conditions,omitempty"`
+	// Connecting, Connected or Disconnected
+	ConnectionState PeerConnectionState `json:"connection_state,omitempty"`
+	// The last time a discovery message was received from the peer
+	LastMessageTime *metav1.Time `json:"last_message_time,omitempty"`
+	// The last connection or import error
+	LastError string `json:"last_error,omitempty"`
+	// The number of ServiceBindings imported from the peer
+	ImportedBindings int32 `json:"imported_bindings,omitempty"`
+	// Imported services not bound, or not merged, because of conflicting imports
+	Collisions []ImportCollision `json:"collisions,omitempty"`
+}
+
+// ImportCollision is an imported service in conflict over its ServiceBinding
+type ImportCollision struct {
+	// The namespace/name of the remote service
+	Service string `json:"service"`
+	// The namespace/name of the ServiceBinding it maps to
+	Binding string `json:"binding"`
+	// What the service conflicts with: the services of peers or the local binding holding the
+	// name, or under the reject policy every other service mapping to it
+	Holder string `json:"holder"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".spec.address"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.connection_state"
+// +kubebuilder:printcolumn:name="Imported",type="integer",JSONPath=".status.imported_bindings"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+
+// DiscoveryPeer is the Schema for the discoverypeers API
+type DiscoveryPeer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DiscoveryPeerSpec   `json:"spec,omitempty"`
+	Status DiscoveryPeerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DiscoveryPeerList contains a list of DiscoveryPeer
+type DiscoveryPeerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DiscoveryPeer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DiscoveryPeer{}, &DiscoveryPeerList{})
+}
@@ -33,15 +33,49 @@ type MeshFedConfigSpec struct {
 	UseIngressGateway      bool              `json:"use_ingress_gateway,omitempty"`
 	IngressGatewaySelector map[string]string `json:"ingress_gateway_selector,omitempty"`
 	IngressGatewayPort     uint32            `json:"ingress_gateway_port,omitempty"`
+	// If specified, secures the exposed services discovery channel with mutual TLS
+	DiscoveryTLS *DiscoveryTLS `json:"discovery_tls,omitempty"`
+	// The clusters or peer identities (DNS SANs or SPIFFE IDs) that may discover services exposed
+	// with this config.  A trailing * matches any suffix.  If empty every peer may discover them,
+	// including peers without an identity.  Without discovery TLS a peer's identity is the cluster
+	// it claims, which is not verified.
+	Peers []string `json:"peers,omitempty"`
+	// Rules mapping the namespaces of services imported into ServiceBindings that select this
+	// config.  They apply after those of the DiscoveryPeer.
+	NamespaceMapping []NamespaceMappingRule `json:"namespace_mapping,omitempty"`
+	// OPTIONAL: The region/zone/subzone of this mesh's ingress, published with the services
+	// exposed with this config.  Defaults to the topology labels of the ingress gateway's node.
+	Locality string `json:"locality,omitempty"`
+	// OPTIONAL: The Istio network of this mesh's ingress.  Defaults to the
+	// topology.istio.io/network label of the ingress gateway's node or pod.
+	Network string `json:"network,omitempty"`
+	// OPTIONAL: The Istio cluster ID of this mesh.  Defaults to the controller's --cluster-name.
+	ClusterID string `json:"cluster_id,omitempty"`
+}
+
+// DiscoveryTLS configures mutual TLS for the exposed services discovery (ESDS) channel
+type DiscoveryTLS struct {
+	// REQUIRED: A Secret in the same namespace holding tls.crt, tls.key and ca.crt.
+	SecretName string `json:"secret_name"`
+	// REQUIRED: The DNS SANs or SPIFFE IDs peers may present.  A trailing * matches any suffix,
+	// so * accepts any peer with a certificate signed by ca.crt.  Peers are rejected if it is empty.
+	AllowedIdentities []string `json:"allowed_identities,omitempty"`
 }
 
 // MeshFedConfigStatus defines the observed state of MeshFedConfig
 type MeshFedConfigStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// The generation most recently reconciled by the controller
+	ObservedGeneration int64 `json:"observed_generation,omitempty"`
+	// Ready, Reconciled
+	Conditions []Condition `json:"conditions,omitempty"`
+	// The Services, ServiceAccounts and Deployments created for this config
+	GeneratedObjects []GeneratedObject `json:"generated_objects,omitempty"`
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Mode",type="string",JSONPath=".spec.mode"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
 
 // MeshFedConfig is the Schema for the MeshFedConfigs API
 type MeshFedConfig struct {
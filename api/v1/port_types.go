@@ -0,0 +1,87 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// Protocol is the application protocol spoken on a federated service port
+// +kubebuilder:validation:Enum=HTTP;HTTP2;GRPC;TCP;TLS
+type Protocol string
+
+const (
+	ProtocolHTTP  Protocol = "HTTP"
+	ProtocolHTTP2 Protocol = "HTTP2"
+	ProtocolGRPC  Protocol = "GRPC"
+	ProtocolTCP   Protocol = "TCP"
+	ProtocolTLS   Protocol = "TLS"
+)
+
+// Protocols lists the supported protocols
+var Protocols = []Protocol{ProtocolHTTP, ProtocolHTTP2, ProtocolGRPC, ProtocolTCP, ProtocolTLS}
+
+// IsHTTP is true for the protocols that can be routed on HTTP attributes
+func (p Protocol) IsHTTP() bool {
+	return p == ProtocolHTTP || p == ProtocolHTTP2 || p == ProtocolGRPC
+}
+
+// ServicePort describes one port of an exposed or bound service
+type ServicePort struct {
+	// REQUIRED: The name of the port, unique within the service.
+	Name string `json:"name"`
+	// REQUIRED: The port number clients connect to.
+	Number uint32 `json:"number"`
+	// OPTIONAL: The port number on the service's workloads.  Defaults to number.
+	TargetPort uint32 `json:"target_port,omitempty"`
+	// OPTIONAL: One of HTTP, HTTP2, GRPC, TCP or TLS.  Defaults to HTTP.
+	Protocol Protocol `json:"protocol,omitempty"`
+}
+
+// EffectivePorts returns ports with their defaults filled in or, if there are none,
+// a single HTTP port for the deprecated port field.
+func EffectivePorts(port uint32, ports []ServicePort) []ServicePort {
+	if len(ports) == 0 {
+		if port == 0 {
+			return nil
+		}
+		return []ServicePort{
+			{
+				Name:       "http",
+				Number:     port,
+				TargetPort: port,
+				Protocol:   ProtocolHTTP,
+			},
+		}
+	}
+	retval := make([]ServicePort, len(ports))
+	for i, p := range ports {
+		if p.TargetPort == 0 {
+			p.TargetPort = p.Number
+		}
+		if p.Protocol == "" {
+			p.Protocol = ProtocolHTTP
+		}
+		retval[i] = p
+	}
+	return retval
+}
+
+// ServicePorts returns the exposed ports, see EffectivePorts
+func (spec *ServiceExpositionSpec) ServicePorts() []ServicePort {
+	return EffectivePorts(spec.Port, spec.Ports)
+}
+
+// ServicePorts returns the bound ports, see EffectivePorts
+func (spec *ServiceBindingSpec) ServicePorts() []ServicePort {
+	return EffectivePorts(spec.Port, spec.Ports)
+}
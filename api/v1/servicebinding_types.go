@@ -37,23 +37,48 @@ type ServiceBindingSpec struct {
 	//  must be defined in a corresponding DestinationRule.
 	// For binding services, it represents the service as a subset if specified.
 	Subset string `json:"subset,omitempty"`
-	// REQUIRED: The port of the exposed service.
-	// TODO: consider adding support for multiple ports, their types and names.
+	// Deprecated: use ports.  A single HTTP port named "http".
 	Port uint32 `json:"port,omitempty"`
-	//
+	// REQUIRED unless port is set: The ports of the service, their names and protocols.
+	Ports []ServicePort `json:"ports,omitempty"`
+	// OPTIONAL: The namespace of the service in the exposing mesh, which the binding's own
+	// namespace differs from when imported through a namespace mapping.  Defaults to the
+	// namespace of the binding.
 	Namespace string `json:"namespace,omitempty"`
 	// To be filled in by cluster for exposing; already filled in for binding
 	Endpoints []string `json:"endpoints,omitempty"`
+	// OPTIONAL: The locality and network of each endpoint, for locality aware load
+	// balancing and failover between meshes
+	Topology []EndpointTopology `json:"topology,omitempty"`
 	// Important: Run "make" to regenerate code after modifying this file
 }
 
+// EndpointTopology places an endpoint of a binding
+type EndpointTopology struct {
+	// REQUIRED: The ip:port of the endpoint, as in endpoints
+	Endpoint string `json:"endpoint"`
+	// OPTIONAL: The region/zone/subzone of the endpoint
+	Locality string `json:"locality,omitempty"`
+	// OPTIONAL: The Istio network of the endpoint
+	Network string `json:"network,omitempty"`
+	// OPTIONAL: The Istio cluster ID of the endpoint
+	ClusterID string `json:"cluster_id,omitempty"`
+}
+
 // ServiceBindingStatus defines the observed state of ServiceBinding
 type ServiceBindingStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// The generation most recently reconciled by the controller
+	ObservedGeneration int64 `json:"observed_generation,omitempty"`
+	// Ready, Reconciled, EndpointsResolved
+	Conditions []Condition `json:"conditions,omitempty"`
+	// The Istio and Kubernetes objects created for this binding
+	GeneratedObjects []GeneratedObject `json:"generated_objects,omitempty"`
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Service",type="string",JSONPath=".spec.name"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
 
 // ServiceBinding is the Schema for the servicebindings API
 type ServiceBinding struct {
@@ -37,20 +37,40 @@ type ServiceExpositionSpec struct {
 	//  must be defined in a corresponding DestinationRule.
 	// For binding services, it represents the service as a subset if specified.
 	Subset string `json:"subset,omitempty"`
-	// REQUIRED: The port of the exposed service.
-	// TODO: consider adding support for multiple ports, their types and names.
+	// Deprecated: use ports.  A single HTTP port named "http".
 	Port uint32 `json:"port,omitempty"`
+	// REQUIRED unless port is set: The ports of the service, their names and protocols.
+	Ports []ServicePort `json:"ports,omitempty"`
 	// To be filled in by mesh controller
 	Endpoints []string `json:"endpoints,omitempty"`
-	Clusters  []string `json:"clusters,omitempty"`
+	// To be filled in by mesh controller: the region/zone/subzone of the endpoints
+	Locality string `json:"locality,omitempty"`
+	// To be filled in by mesh controller: the Istio network of the endpoints
+	Network string `json:"network,omitempty"`
+	// To be filled in by mesh controller: the Istio cluster ID of the endpoints
+	ClusterID string `json:"cluster_id,omitempty"`
+	// OPTIONAL: The clusters or peer identities (DNS SANs or SPIFFE IDs) that may discover this
+	// service.  A trailing * matches any suffix.  If empty the peers of the selected MeshFedConfigs
+	// apply, and if none of them lists peers every peer may discover it.
+	Clusters []string `json:"clusters,omitempty"`
 }
 
 // ServiceExpositionStatus defines the observed state of ServiceExposition
 type ServiceExpositionStatus struct {
+	// Deprecated: mirrors the Ready condition
 	Ready bool `json:"ready,omitempty"`
+	// The generation most recently reconciled by the controller
+	ObservedGeneration int64 `json:"observed_generation,omitempty"`
+	// Ready, Reconciled, EndpointsResolved, DiscoveryPublished
+	Conditions []Condition `json:"conditions,omitempty"`
+	// The Istio objects created for this exposition
+	GeneratedObjects []GeneratedObject `json:"generated_objects,omitempty"`
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Service",type="string",JSONPath=".spec.name"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
 
 // ServiceExposition is the Schema for the serviceexpositions API
 type ServiceExposition struct {
@@ -23,13 +23,216 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiscoveryPeer) DeepCopyInto(out *DiscoveryPeer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveryPeer.
+func (in *DiscoveryPeer) DeepCopy() *DiscoveryPeer {
+	if in == nil {
+		return nil
+	}
+	out := new(DiscoveryPeer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DiscoveryPeer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiscoveryPeerList) DeepCopyInto(out *DiscoveryPeerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DiscoveryPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveryPeerList.
+func (in *DiscoveryPeerList) DeepCopy() *DiscoveryPeerList {
+	if in == nil {
+		return nil
+	}
+	out := new(DiscoveryPeerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DiscoveryPeerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiscoveryPeerSpec) DeepCopyInto(out *DiscoveryPeerSpec) {
+	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(DiscoveryTLS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MeshFedConfigSelector != nil {
+		in, out := &in.MeshFedConfigSelector, &out.MeshFedConfigSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NamespaceMapping != nil {
+		in, out := &in.NamespaceMapping, &out.NamespaceMapping
+		*out = make([]NamespaceMappingRule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveryPeerSpec.
+func (in *DiscoveryPeerSpec) DeepCopy() *DiscoveryPeerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DiscoveryPeerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiscoveryPeerStatus) DeepCopyInto(out *DiscoveryPeerStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastMessageTime != nil {
+		in, out := &in.LastMessageTime, &out.LastMessageTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Collisions != nil {
+		in, out := &in.Collisions, &out.Collisions
+		*out = make([]ImportCollision, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveryPeerStatus.
+func (in *DiscoveryPeerStatus) DeepCopy() *DiscoveryPeerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DiscoveryPeerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiscoveryTLS) DeepCopyInto(out *DiscoveryTLS) {
+	*out = *in
+	if in.AllowedIdentities != nil {
+		in, out := &in.AllowedIdentities, &out.AllowedIdentities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveryTLS.
+func (in *DiscoveryTLS) DeepCopy() *DiscoveryTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(DiscoveryTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EndpointTopology) DeepCopyInto(out *EndpointTopology) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointTopology.
+func (in *EndpointTopology) DeepCopy() *EndpointTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(EndpointTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GeneratedObject) DeepCopyInto(out *GeneratedObject) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GeneratedObject.
+func (in *GeneratedObject) DeepCopy() *GeneratedObject {
+	if in == nil {
+		return nil
+	}
+	out := new(GeneratedObject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImportCollision) DeepCopyInto(out *ImportCollision) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImportCollision.
+func (in *ImportCollision) DeepCopy() *ImportCollision {
+	if in == nil {
+		return nil
+	}
+	out := new(ImportCollision)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MeshFedConfig) DeepCopyInto(out *MeshFedConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MeshFedConfig.
@@ -106,6 +309,21 @@ func (in *MeshFedConfigSpec) DeepCopyInto(out *MeshFedConfigSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.DiscoveryTLS != nil {
+		in, out := &in.DiscoveryTLS, &out.DiscoveryTLS
+		*out = new(DiscoveryTLS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceMapping != nil {
+		in, out := &in.NamespaceMapping, &out.NamespaceMapping
+		*out = make([]NamespaceMappingRule, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MeshFedConfigSpec.
@@ -121,6 +339,18 @@ func (in *MeshFedConfigSpec) DeepCopy() *MeshFedConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MeshFedConfigStatus) DeepCopyInto(out *MeshFedConfigStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GeneratedObjects != nil {
+		in, out := &in.GeneratedObjects, &out.GeneratedObjects
+		*out = make([]GeneratedObject, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MeshFedConfigStatus.
@@ -133,13 +363,28 @@ func (in *MeshFedConfigStatus) DeepCopy() *MeshFedConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceMappingRule) DeepCopyInto(out *NamespaceMappingRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceMappingRule.
+func (in *NamespaceMappingRule) DeepCopy() *NamespaceMappingRule {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceMappingRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceBinding) DeepCopyInto(out *ServiceBinding) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceBinding.
@@ -202,11 +447,21 @@ func (in *ServiceBindingSpec) DeepCopyInto(out *ServiceBindingSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]ServicePort, len(*in))
+		copy(*out, *in)
+	}
 	if in.Endpoints != nil {
 		in, out := &in.Endpoints, &out.Endpoints
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = make([]EndpointTopology, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceBindingSpec.
@@ -222,6 +477,18 @@ func (in *ServiceBindingSpec) DeepCopy() *ServiceBindingSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceBindingStatus) DeepCopyInto(out *ServiceBindingStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GeneratedObjects != nil {
+		in, out := &in.GeneratedObjects, &out.GeneratedObjects
+		*out = make([]GeneratedObject, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceBindingStatus.
@@ -240,7 +507,7 @@ func (in *ServiceExposition) DeepCopyInto(out *ServiceExposition) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceExposition.
@@ -303,6 +570,11 @@ func (in *ServiceExpositionSpec) DeepCopyInto(out *ServiceExpositionSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]ServicePort, len(*in))
+		copy(*out, *in)
+	}
 	if in.Endpoints != nil {
 		in, out := &in.Endpoints, &out.Endpoints
 		*out = make([]string, len(*in))
@@ -328,6 +600,18 @@ func (in *ServiceExpositionSpec) DeepCopy() *ServiceExpositionSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceExpositionStatus) DeepCopyInto(out *ServiceExpositionStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GeneratedObjects != nil {
+		in, out := &in.GeneratedObjects, &out.GeneratedObjects
+		*out = make([]GeneratedObject, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceExpositionStatus.
@@ -339,3 +623,18 @@ func (in *ServiceExpositionStatus) DeepCopy() *ServiceExpositionStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServicePort) DeepCopyInto(out *ServicePort) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServicePort.
+func (in *ServicePort) DeepCopy() *ServicePort {
+	if in == nil {
+		return nil
+	}
+	out := new(ServicePort)
+	in.DeepCopyInto(out)
+	return out
+}
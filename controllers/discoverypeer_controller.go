@@ -0,0 +1,103 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+
+	"istio.io/pkg/log"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// DiscoveryPeerReconciler reconciles a DiscoveryPeer object
+type DiscoveryPeerReconciler struct {
+	client.Client
+}
+
+// DiscoveryServer tells the discovery client starter to connect to ("U") or
+// disconnect from ("D") a remote exposed services discovery server
+type DiscoveryServer struct {
+	Name      string
+	Address   string
+	Operation string
+	// Peer is the DiscoveryPeer of an "U" operation
+	Peer *mmv1.DiscoveryPeer
+}
+
+var DiscoveryChanel chan DiscoveryServer
+
+// +kubebuilder:rbac:groups=mm.ibm.istio.io,resources=discoverypeers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mm.ibm.istio.io,resources=discoverypeers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *DiscoveryPeerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	var peer mmv1.DiscoveryPeer
+
+	if err := r.Get(ctx, req.NamespacedName, &peer); err != nil {
+		if apierrs.IsNotFound(err) {
+			DiscoveryChanel <- DiscoveryServer{
+				Name:      req.NamespacedName.String(),
+				Operation: "D",
+			}
+		} else {
+			log.Warnf("unable to fetch DiscoveryPeer resource: %v", err)
+		}
+		return ctrl.Result{}, ignoreNotFound(err)
+	}
+
+	if !peer.ObjectMeta.DeletionTimestamp.IsZero() {
+		DiscoveryChanel <- DiscoveryServer{
+			Name:      req.NamespacedName.String(),
+			Operation: "D",
+		}
+		return ctrl.Result{}, nil
+	}
+
+	port := peer.Spec.Port
+	if port == 0 {
+		port = mmv1.DefaultDiscoveryPort
+	}
+	DiscoveryChanel <- DiscoveryServer{
+		Name:      req.NamespacedName.String(),
+		Address:   net.JoinHostPort(peer.Spec.Address, strconv.Itoa(int(port))),
+		Operation: "U",
+		Peer:      peer.DeepCopy(),
+	}
+
+	// The connection state is reported by the discovery client
+	if peer.Status.ObservedGeneration == peer.GetGeneration() {
+		return ctrl.Result{}, nil
+	}
+	peer.Status.ObservedGeneration = peer.GetGeneration()
+	return ctrl.Result{}, updateStatus(ctx, r.Client, &peer, nil)
+}
+
+func (r *DiscoveryPeerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	DiscoveryChanel = make(chan DiscoveryServer, 100)
+	// Status updates by the discovery client must not restart it
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mmv1.DiscoveryPeer{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -19,10 +19,12 @@ import (
 	"context"
 
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/style"
 
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
 	"istio.io/pkg/log"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -31,10 +33,18 @@ import (
 type MeshFedConfigReconciler struct {
 	client.Client
 	istioclient.Interface
+	// Recorder records events on the reconciled objects
+	Recorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=mm.ibm.istio.io,resources=meshfedconfigs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=mm.ibm.istio.io,resources=meshfedconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=services;serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.istio.io,resources=gateways;virtualservices;destinationrules;serviceentries,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=endpoints,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways;httproutes;tlsroutes;referencegrants;backendtlspolicies,verbs=get;list;watch;create;update;patch;delete
 
 func (r *MeshFedConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -46,9 +56,18 @@ func (r *MeshFedConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 		return ctrl.Result{}, ignoreNotFound(err)
 	}
 
-	styleReconciler, err := GetMeshFedConfigReconciler(&mfc, r.Client, r.Interface)
+	styleReconciler, err := GetMeshFedConfigReconciler(&mfc, r.Client, r.Interface, r.Recorder)
 	if err != nil {
-		return ctrl.Result{}, err
+		if mfc.ObjectMeta.DeletionTimestamp.IsZero() || !containsString(mfc.ObjectMeta.Finalizers, myFinalizerName) {
+			return ctrl.Result{}, r.updateStatus(ctx, &mfc, style.Failed(&mfc.Status.Conditions, mmv1.ReasonUnknownMode, err))
+		}
+		// Without a known mode there is no style, but the generated objects carry our labels
+		if err := style.RemoveGenerated(ctx, r.Client, r.Interface, style.KindMeshFedConfig, &mfc); err != nil {
+			return ctrl.Result{}, r.updateStatus(ctx, &mfc, style.Failed(&mfc.Status.Conditions, mmv1.ReasonTeardownFailed, err))
+		}
+		recordRemoved(r.Recorder, &mfc)
+		mfc.ObjectMeta.Finalizers = removeString(mfc.ObjectMeta.Finalizers, myFinalizerName)
+		return ctrl.Result{}, r.Update(context.Background(), &mfc)
 	}
 
 	if mfc.ObjectMeta.DeletionTimestamp.IsZero() {
@@ -58,15 +77,17 @@ func (r *MeshFedConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 				return ctrl.Result{}, err
 			}
 		} else {
+			mfc.Status.GeneratedObjects = nil
 			err = styleReconciler.EffectMeshFedConfig(ctx, &mfc)
-			return ctrl.Result{}, err
+			return ctrl.Result{}, r.updateStatus(ctx, &mfc, err)
 		}
 	} else {
 		// The object is being deleted
 		if containsString(mfc.ObjectMeta.Finalizers, myFinalizerName) {
 			if err := styleReconciler.RemoveMeshFedConfig(ctx, &mfc); err != nil {
-				return ctrl.Result{}, err
+				return ctrl.Result{}, r.updateStatus(ctx, &mfc, style.Failed(&mfc.Status.Conditions, mmv1.ReasonTeardownFailed, err))
 			}
+			recordRemoved(r.Recorder, &mfc)
 			mfc.ObjectMeta.Finalizers = removeString(mfc.ObjectMeta.Finalizers, myFinalizerName)
 			if err := r.Update(context.Background(), &mfc); err != nil {
 				return ctrl.Result{}, err
@@ -0,0 +1,84 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/style"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDeleteMeshFedConfigWithUnknownMode(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	if err := mmv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	for _, gvk := range []schema.GroupVersionKind{style.GatewayGVK, style.HTTPRouteGVK, style.TLSRouteGVK, style.ReferenceGrantGVK, style.BackendTLSPolicyGVK} {
+		scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+	}
+
+	now := metav1.Now()
+	mfc := &mmv1.MeshFedConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "retired",
+			Namespace:         "mesh-system",
+			UID:               "uid-1",
+			Finalizers:        []string{"mm.ibm.istio.io"},
+			DeletionTimestamp: &now,
+		},
+		Spec: mmv1.MeshFedConfigSpec{Mode: "RETIRED"},
+	}
+	generated := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:      "istio-retired-ingress",
+		Namespace: "mesh-system",
+		Labels:    style.OwnerLabels(style.KindMeshFedConfig, mfc, nil),
+	}}
+	cli := fake.NewFakeClientWithScheme(scheme, mfc, generated)
+	r := &MeshFedConfigReconciler{Client: cli, Interface: istiofake.NewSimpleClientset()}
+
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: "mesh-system", Name: "retired"}
+	if _, err := r.Reconcile(ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if err := cli.Get(ctx, types.NamespacedName{Namespace: "mesh-system", Name: "istio-retired-ingress"}, &corev1.Service{}); !apierrs.IsNotFound(err) {
+		t.Errorf("generated Service not removed: %v", err)
+	}
+	var actual mmv1.MeshFedConfig
+	if err := cli.Get(ctx, key, &actual); err != nil {
+		t.Fatal(err)
+	}
+	if containsString(actual.Finalizers, "mm.ibm.istio.io") {
+		t.Errorf("finalizer not removed")
+	}
+}
@@ -17,7 +17,7 @@ package controllers
 
 import (
 	"context"
-	"strconv"
+	"fmt"
 	"strings"
 
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
@@ -27,6 +27,7 @@ import (
 	"istio.io/pkg/log"
 	k8sapi "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -36,24 +37,19 @@ import (
 type ServiceReconciler struct {
 	client.Client
 	istioclient.Interface
-	DiscoveryLabelKey    string
-	DiscoveryLabelVal    string
 	AutoExposeLabelKey   string
 	AutoExposeAsLabelKey string
 	SEReconciler         *ServiceExpositionReconciler
+	// Recorder records events on the auto exposed Services
+	Recorder record.EventRecorder
 }
 
-type DiscoveryServer struct {
-	Name      string
-	Address   string
-	Operation string
-}
-
-var DiscoveryChanel chan DiscoveryServer
-
 const (
 	fedConfig            = "fed-config"
 	defaultMeshFedConfig = "passthrough"
+
+	eventAutoExposed      = "AutoExposed"
+	eventAutoExposeFailed = "AutoExposeFailed"
 )
 
 // +kubebuilder:rbac:groups=mm.ibm.istio.io,resources=services,verbs=get;list;watch;create;update;patch;delete
@@ -83,8 +79,8 @@ func newServiceExposure(svc *k8sapi.Service, name, alias string) *mmv1.ServiceEx
 			OwnerReferences: ownerReference(svc.APIVersion, svc.Kind, svc.ObjectMeta),
 		},
 		Spec: mmv1.ServiceExpositionSpec{
-			Name: svc.Name,
-			Port: uint32(svc.Spec.Ports[0].Port), // TODO one port only?
+			Name:  svc.Name,
+			Ports: servicePorts(svc.Spec.Ports),
 			MeshFedConfigSelector: map[string]string{ // TODO
 				fedConfig: defaultMeshFedConfig,
 			},
@@ -108,7 +104,42 @@ func newServiceExposure(svc *k8sapi.Service, name, alias string) *mmv1.ServiceEx
 	return &se
 }
 
-func createServiceExposure(ser *ServiceExpositionReconciler, svc *k8sapi.Service, alias string) error {
+// servicePorts converts the ports of a Kubernetes Service.  The protocol comes from
+// the Istio port naming convention, <protocol>[-<suffix>], and defaults to HTTP.
+func servicePorts(ports []k8sapi.ServicePort) []mmv1.ServicePort {
+	var retval []mmv1.ServicePort
+	for _, p := range ports {
+		if p.Protocol != "" && p.Protocol != k8sapi.ProtocolTCP {
+			continue // UDP and SCTP cannot be federated
+		}
+		sp := mmv1.ServicePort{
+			Name:       p.Name,
+			Number:     uint32(p.Port),
+			TargetPort: uint32(p.TargetPort.IntValue()),
+			Protocol:   portNameProtocol(p.Name),
+		}
+		if sp.Name == "" {
+			sp.Name = fmt.Sprintf("port-%d", p.Port)
+		}
+		retval = append(retval, sp)
+	}
+	return retval
+}
+
+func portNameProtocol(name string) mmv1.Protocol {
+	prefix := strings.ToUpper(strings.SplitN(name, "-", 2)[0])
+	if prefix == "HTTPS" {
+		return mmv1.ProtocolTLS
+	}
+	for _, protocol := range mmv1.Protocols {
+		if prefix == string(protocol) {
+			return protocol
+		}
+	}
+	return mmv1.ProtocolHTTP
+}
+
+func createServiceExposure(ser *ServiceExpositionReconciler, svc *k8sapi.Service, alias string) (controllerutil.OperationResult, error) {
 	name := svc.GetName() + "-auto-exposed"
 	goalNv := newServiceExposure(svc, name, alias)
 	nv := &mmv1.ServiceExposition{
@@ -117,16 +148,12 @@ func createServiceExposure(ser *ServiceExpositionReconciler, svc *k8sapi.Service
 			Namespace: goalNv.GetNamespace(),
 		},
 	}
-	_, err := controllerutil.CreateOrUpdate(context.Background(), ser.Client, nv, func() error {
+	return controllerutil.CreateOrUpdate(context.Background(), ser.Client, nv, func() error {
 		nv.ObjectMeta.Labels = goalNv.Labels
 		nv.ObjectMeta.OwnerReferences = goalNv.ObjectMeta.OwnerReferences
 		nv.Spec = goalNv.Spec
 		return nil
 	})
-	if err != nil {
-		return err
-	}
-	return nil
 }
 
 // Reconcile reconciles
@@ -139,59 +166,42 @@ func (r *ServiceReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, ignoreNotFound(err)
 	}
 
-	var svcAddr, svcPort string
-	var s DiscoveryServer
-	if r.DiscoveryLabelVal != "" && svc.ObjectMeta.Labels[r.DiscoveryLabelKey] == r.DiscoveryLabelVal {
-		if len(svc.Spec.ExternalIPs) > 0 {
-			svcAddr = svc.Spec.ExternalIPs[0]
-		} else {
-			svcAddr = svc.Spec.ClusterIP
-		}
-		svcPort = strconv.Itoa(int(svc.Spec.Ports[0].Port))
-		s = DiscoveryServer{
-			Name:      svc.GetNamespace() + "/" + svc.GetName(),
-			Address:   svcAddr + ":" + svcPort,
-			Operation: "add",
-		}
-
-		// TODO: For early testing only. Fix.
-		if strings.EqualFold(svcAddr, "9.9.9.9") {
-			s.Address = "127.0.0.1" + ":" + svcPort
-		}
-
-		if svc.ObjectMeta.DeletionTimestamp.IsZero() {
-			if svcAddr != "" {
-				s.Operation = "U"
-				DiscoveryChanel <- s
+	if svc.ObjectMeta.DeletionTimestamp.IsZero() {
+		if alias, ok := svc.ObjectMeta.Labels[r.AutoExposeAsLabelKey]; ok {
+			or, err := createServiceExposure(r.SEReconciler, &svc, alias)
+			if err != nil {
+				log.Warnf("Could not auto expose: %v alias: %v", svc, alias)
 			}
-			return ctrl.Result{}, nil
-		}
-
-		// The object is being deleted
-		if svcAddr != "" {
-			s.Operation = "D"
-			DiscoveryChanel <- s
-		}
-	} else {
-		if svc.ObjectMeta.DeletionTimestamp.IsZero() {
-			if alias, ok := svc.ObjectMeta.Labels[r.AutoExposeAsLabelKey]; ok {
-				if err := createServiceExposure(r.SEReconciler, &svc, alias); err != nil {
-					log.Warnf("Could not auto expose: %v alias: %v", svc, alias)
-				}
-			} else if val, ok := svc.ObjectMeta.Labels[r.AutoExposeLabelKey]; ok && val == "true" {
-				if err := createServiceExposure(r.SEReconciler, &svc, ""); err != nil {
-					log.Warnf("Could not auto expose: %v", svc)
-				}
+			r.recordAutoExposure(&svc, or, err)
+		} else if val, ok := svc.ObjectMeta.Labels[r.AutoExposeLabelKey]; ok && val == "true" {
+			or, err := createServiceExposure(r.SEReconciler, &svc, "")
+			if err != nil {
+				log.Warnf("Could not auto expose: %v", svc)
 			}
+			r.recordAutoExposure(&svc, or, err)
 		}
-		// deleted services are taken care of at the begining of this function
 	}
+	// deleted services are taken care of at the begining of this function
 	return ctrl.Result{}, nil
 
 }
 
+// recordAutoExposure records on svc the outcome of creating or updating its ServiceExposition
+func (r *ServiceReconciler) recordAutoExposure(svc *k8sapi.Service, or controllerutil.OperationResult, err error) {
+	if r.Recorder == nil {
+		return
+	}
+	name := svc.GetName() + "-auto-exposed"
+	if err != nil {
+		r.Recorder.Eventf(svc, k8sapi.EventTypeWarning, eventAutoExposeFailed, "Could not expose as ServiceExposition %s: %v", name, err)
+		return
+	}
+	if or != controllerutil.OperationResultNone {
+		r.Recorder.Eventf(svc, k8sapi.EventTypeNormal, eventAutoExposed, "Exposed as ServiceExposition %s (%s)", name, or)
+	}
+}
+
 func (r *ServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	DiscoveryChanel = make(chan DiscoveryServer, 100)
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&k8sapi.Service{}).
 		Complete(r)
@@ -17,20 +17,27 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/style"
 
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
 
 	"istio.io/pkg/log"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // ServiceBindingReconciler reconciles a ServiceBinding object
 type ServiceBindingReconciler struct {
 	client.Client
 	istioclient.Interface
+	// Recorder records events on the reconciled objects
+	Recorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=mm.ibm.istio.io,resources=servicebindings,verbs=get;list;watch;create;update;patch;delete
@@ -51,9 +58,18 @@ func (r *ServiceBindingReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 	if (err != nil) || (mfc.ObjectMeta.Name == "") {
 		if binding.ObjectMeta.DeletionTimestamp.IsZero() {
 			// log.Warnf("SB did not find an mfc. will requeue the request: %v", err)
+			if err == nil {
+				err = fmt.Errorf("no MeshFedConfig matches %v", mfcSelector)
+			}
+			_ = r.updateStatus(ctx, &binding, style.Failed(&binding.Status.Conditions, mmv1.ReasonMeshFedConfigNotFound, err))
 			return ctrl.Result{Requeue: true}, nil
 		} else {
 			// log.Warnf("SB did not find an mfc. being deleted. not requeueing: %v", err)
+			// Without a MeshFedConfig there is no style, but the generated objects carry our labels
+			if err := style.RemoveGenerated(ctx, r.Client, r.Interface, style.KindServiceBinding, &binding); err != nil {
+				return ctrl.Result{}, r.updateStatus(ctx, &binding, style.Failed(&binding.Status.Conditions, mmv1.ReasonTeardownFailed, err))
+			}
+			recordRemoved(r.Recorder, &binding)
 			binding.ObjectMeta.Finalizers = removeString(binding.ObjectMeta.Finalizers, myFinalizerName)
 			if err := r.Update(context.Background(), &binding); err != nil {
 				return ctrl.Result{}, err
@@ -62,9 +78,9 @@ func (r *ServiceBindingReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 		}
 	}
 
-	styleReconciler, err := GetBindingReconciler(&mfc, r.Client, r.Interface)
+	styleReconciler, err := GetBindingReconciler(&mfc, r.Client, r.Interface, r.Recorder)
 	if err != nil {
-		return ctrl.Result{}, err
+		return ctrl.Result{}, r.updateStatus(ctx, &binding, style.Failed(&binding.Status.Conditions, mmv1.ReasonUnknownMode, err))
 	}
 
 	if binding.ObjectMeta.DeletionTimestamp.IsZero() {
@@ -74,15 +90,17 @@ func (r *ServiceBindingReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 				return ctrl.Result{}, err
 			}
 		} else {
+			binding.Status.GeneratedObjects = nil
 			err = styleReconciler.EffectServiceBinding(ctx, &binding, &mfc)
-			return ctrl.Result{}, err
+			return ctrl.Result{}, r.updateStatus(ctx, &binding, err)
 		}
 	} else {
 		// The object is being deleted
 		if containsString(binding.ObjectMeta.Finalizers, myFinalizerName) {
 			if err := styleReconciler.RemoveServiceBinding(ctx, &binding, &mfc); err != nil {
-				return ctrl.Result{}, err
+				return ctrl.Result{}, r.updateStatus(ctx, &binding, style.Failed(&binding.Status.Conditions, mmv1.ReasonTeardownFailed, err))
 			}
+			recordRemoved(r.Recorder, &binding)
 			binding.ObjectMeta.Finalizers = removeString(binding.ObjectMeta.Finalizers, myFinalizerName)
 			if err := r.Update(context.Background(), &binding); err != nil {
 				return ctrl.Result{}, err
@@ -97,5 +115,6 @@ func (r *ServiceBindingReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 func (r *ServiceBindingReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&mmv1.ServiceBinding{}).
+		Watches(&source.Kind{Type: &mmv1.MeshFedConfig{}}, r.bindingsForMeshFedConfig(), builder.WithPredicates(meshFedConfigChanged)).
 		Complete(r)
 }
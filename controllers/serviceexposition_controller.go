@@ -17,14 +17,20 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/style"
 
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
 
 	"istio.io/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	// Without this (seemingly) unneeded import, fails with 'panic: No Auth Provider found for name "oidc"' on IKS
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
@@ -34,6 +40,8 @@ import (
 type ServiceExpositionReconciler struct {
 	client.Client
 	istioclient.Interface
+	// Recorder records events on the reconciled objects
+	Recorder record.EventRecorder
 }
 
 var UpdateChannel chan int
@@ -41,6 +49,7 @@ var x int
 
 // +kubebuilder:rbac:groups=mm.ibm.istio.io,resources=serviceexpositions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=mm.ibm.istio.io,resources=serviceexpositions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=pods;nodes,verbs=get;list;watch
 
 func (r *ServiceExpositionReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -57,17 +66,26 @@ func (r *ServiceExpositionReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 	if (err != nil) || (mfc.ObjectMeta.Name == "") {
 		if exposition.ObjectMeta.DeletionTimestamp.IsZero() {
 			// log.Warnf("SE did not find an mfc. will requeue the request: %v", err)
+			if err == nil {
+				err = fmt.Errorf("no MeshFedConfig matches %v", mfcSelector)
+			}
+			_ = r.updateStatus(ctx, &exposition, style.Failed(&exposition.Status.Conditions, mmv1.ReasonMeshFedConfigNotFound, err))
 			return ctrl.Result{Requeue: true}, nil
 		}
 		// log.Warnf("SE did not find an mfc. being deleted. not requeueing: %v", err)
+		// Without a MeshFedConfig there is no style, but the generated objects carry our labels
+		if err := style.RemoveGenerated(ctx, r.Client, r.Interface, style.KindServiceExposition, &exposition); err != nil {
+			return ctrl.Result{}, r.updateStatus(ctx, &exposition, style.Failed(&exposition.Status.Conditions, mmv1.ReasonTeardownFailed, err))
+		}
+		recordRemoved(r.Recorder, &exposition)
 		exposition.ObjectMeta.Finalizers = removeString(exposition.ObjectMeta.Finalizers, myFinalizerName)
 		err := r.Update(context.Background(), &exposition)
 		return ctrl.Result{}, err
 	}
 
-	styleReconciler, err := GetExposureReconciler(&mfc, r.Client, r.Interface)
+	styleReconciler, err := GetExposureReconciler(&mfc, r.Client, r.Interface, r.Recorder)
 	if err != nil {
-		return ctrl.Result{}, err
+		return ctrl.Result{}, r.updateStatus(ctx, &exposition, style.Failed(&exposition.Status.Conditions, mmv1.ReasonUnknownMode, err))
 	}
 
 	if exposition.ObjectMeta.DeletionTimestamp.IsZero() {
@@ -75,27 +93,16 @@ func (r *ServiceExpositionReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 			exposition.ObjectMeta.Finalizers = append(exposition.ObjectMeta.Finalizers, myFinalizerName)
 			if err := r.Update(context.Background(), &exposition); err != nil {
 				return ctrl.Result{}, err
-			} else {
-				err = styleReconciler.EffectServiceExposure(ctx, &exposition, &mfc)
-				if err == nil {
-					UpdateChannel <- x
-					x++
-				}
 			}
-		} else {
-			err = styleReconciler.EffectServiceExposure(ctx, &exposition, &mfc)
-			if err == nil {
-				UpdateChannel <- x
-				x++
-			}
-			return ctrl.Result{}, err
 		}
+		return ctrl.Result{}, r.effect(ctx, styleReconciler, &exposition, &mfc)
 	} else {
 		// The object is being deleted
 		if containsString(exposition.ObjectMeta.Finalizers, myFinalizerName) {
 			if err := styleReconciler.RemoveServiceExposure(ctx, &exposition, &mfc); err != nil {
-				return ctrl.Result{}, err
+				return ctrl.Result{}, r.updateStatus(ctx, &exposition, style.Failed(&exposition.Status.Conditions, mmv1.ReasonTeardownFailed, err))
 			}
+			recordRemoved(r.Recorder, &exposition)
 			exposition.ObjectMeta.Finalizers = removeString(exposition.ObjectMeta.Finalizers, myFinalizerName)
 			if err := r.Update(context.Background(), &exposition); err != nil {
 				return ctrl.Result{}, err
@@ -105,11 +112,27 @@ func (r *ServiceExpositionReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 		x++
 		return ctrl.Result{}, err
 	}
-	return ctrl.Result{}, err
+}
+
+// effect applies the style and publishes the exposition to the discovery service
+func (r *ServiceExpositionReconciler) effect(ctx context.Context, styleReconciler style.ServiceExposer, se *mmv1.ServiceExposition, mfc *mmv1.MeshFedConfig) error {
+	se.Status.GeneratedObjects = nil
+	err := styleReconciler.EffectServiceExposure(ctx, se, mfc)
+	if err == nil {
+		UpdateChannel <- x
+		x++
+		mmv1.SetCondition(&se.Status.Conditions, mmv1.ConditionDiscoveryPublished, corev1.ConditionTrue,
+			mmv1.ReasonPublished, "")
+	} else {
+		mmv1.SetCondition(&se.Status.Conditions, mmv1.ConditionDiscoveryPublished, corev1.ConditionFalse,
+			mmv1.ReasonNotPublished, "not published until reconciled")
+	}
+	return r.updateStatus(ctx, se, err)
 }
 
 func (r *ServiceExpositionReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&mmv1.ServiceExposition{}).
+		Watches(&source.Kind{Type: &mmv1.MeshFedConfig{}}, r.expositionsForMeshFedConfig(), builder.WithPredicates(meshFedConfigChanged)).
 		Complete(r)
 }
@@ -0,0 +1,129 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+
+	"istio.io/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// eventRemoved is the reason of the event recorded when an object's generated objects are deleted
+const eventRemoved = "Removed"
+
+// object is a Kubernetes object with metadata
+type object interface {
+	runtime.Object
+	metav1.Object
+}
+
+// finishConditions sets Reconciled from the outcome of a reconcile pass, keeping a more
+// specific reason recorded by the style for the same error, and derives Ready from
+// Reconciled and the required conditions.
+func finishConditions(conditions *[]mmv1.Condition, err error, required ...mmv1.ConditionType) {
+	if err != nil {
+		c := mmv1.GetCondition(*conditions, mmv1.ConditionReconciled)
+		if c == nil || c.Status != corev1.ConditionFalse || c.Message != err.Error() {
+			mmv1.SetCondition(conditions, mmv1.ConditionReconciled, corev1.ConditionFalse, mmv1.ReasonReconcileFailed, err.Error())
+		}
+	} else {
+		mmv1.SetCondition(conditions, mmv1.ConditionReconciled, corev1.ConditionTrue, mmv1.ReasonReconciled, "")
+	}
+
+	var notReady []string
+	for _, t := range append([]mmv1.ConditionType{mmv1.ConditionReconciled}, required...) {
+		if !mmv1.IsConditionTrue(*conditions, t) {
+			notReady = append(notReady, string(t))
+		}
+	}
+	if len(notReady) > 0 {
+		mmv1.SetCondition(conditions, mmv1.ConditionReady, corev1.ConditionFalse, mmv1.ReasonDependenciesNotReady,
+			fmt.Sprintf("not true: %s", strings.Join(notReady, ", ")))
+		return
+	}
+	mmv1.SetCondition(conditions, mmv1.ConditionReady, corev1.ConditionTrue, mmv1.ReasonAllConditionsSatisfied, "")
+}
+
+// updateStatus writes obj's status and returns err, or the status error if err is nil
+func updateStatus(ctx context.Context, cli client.Client, obj object, err error) error {
+	if statusErr := cli.Status().Update(ctx, obj); statusErr != nil {
+		log.Warnf("Could not update status of %s/%s: %v", obj.GetNamespace(), obj.GetName(), statusErr)
+		if err == nil {
+			return statusErr
+		}
+	}
+	return err
+}
+
+// recordOutcome records a Warning event on obj, with the reason of its Reconciled condition,
+// when a reconcile pass failed, and a Normal event when obj became Ready
+func recordOutcome(recorder record.EventRecorder, obj runtime.Object, conditions []mmv1.Condition, wasReady bool, err error) {
+	if recorder == nil {
+		return
+	}
+	if err != nil {
+		reason := mmv1.ReasonReconcileFailed
+		if c := mmv1.GetCondition(conditions, mmv1.ConditionReconciled); c != nil && c.Reason != "" {
+			reason = c.Reason
+		}
+		recorder.Event(obj, corev1.EventTypeWarning, reason, err.Error())
+		return
+	}
+	if !wasReady && mmv1.IsConditionTrue(conditions, mmv1.ConditionReady) {
+		recorder.Event(obj, corev1.EventTypeNormal, mmv1.ReasonReconciled, "Ready")
+	}
+}
+
+// recordRemoved records a Normal event on obj once the objects generated for it are deleted
+func recordRemoved(recorder record.EventRecorder, obj runtime.Object) {
+	if recorder != nil {
+		recorder.Event(obj, corev1.EventTypeNormal, eventRemoved, "Removed the generated objects")
+	}
+}
+
+func (r *MeshFedConfigReconciler) updateStatus(ctx context.Context, mfc *mmv1.MeshFedConfig, err error) error {
+	wasReady := mmv1.IsConditionTrue(mfc.Status.Conditions, mmv1.ConditionReady)
+	mfc.Status.ObservedGeneration = mfc.GetGeneration()
+	finishConditions(&mfc.Status.Conditions, err)
+	recordOutcome(r.Recorder, mfc, mfc.Status.Conditions, wasReady, err)
+	return updateStatus(ctx, r.Client, mfc, err)
+}
+
+func (r *ServiceExpositionReconciler) updateStatus(ctx context.Context, se *mmv1.ServiceExposition, err error) error {
+	wasReady := mmv1.IsConditionTrue(se.Status.Conditions, mmv1.ConditionReady)
+	se.Status.ObservedGeneration = se.GetGeneration()
+	finishConditions(&se.Status.Conditions, err, mmv1.ConditionEndpointsResolved)
+	se.Status.Ready = mmv1.IsConditionTrue(se.Status.Conditions, mmv1.ConditionReady)
+	recordOutcome(r.Recorder, se, se.Status.Conditions, wasReady, err)
+	return updateStatus(ctx, r.Client, se, err)
+}
+
+func (r *ServiceBindingReconciler) updateStatus(ctx context.Context, sb *mmv1.ServiceBinding, err error) error {
+	wasReady := mmv1.IsConditionTrue(sb.Status.Conditions, mmv1.ConditionReady)
+	sb.Status.ObservedGeneration = sb.GetGeneration()
+	finishConditions(&sb.Status.Conditions, err, mmv1.ConditionEndpointsResolved)
+	recordOutcome(r.Recorder, sb, sb.Status.Conditions, wasReady, err)
+	return updateStatus(ctx, r.Client, sb, err)
+}
@@ -18,26 +18,20 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
 	"github.com/istio-ecosystem/emcee/style"
-	"github.com/istio-ecosystem/emcee/style/boundary_protection"
-	"github.com/istio-ecosystem/emcee/style/passthrough"
 
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
 
 	"istio.io/pkg/log"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
 	// DefaultGatewayPort is the port to use if port is not explicitly specified
 	DefaultGatewayPort = 443
-	// ModeBoundary is for boundary protection style
-	ModeBoundary = "BOUNDARY"
-	// ModePassthrough is for the passthrough style
-	ModePassthrough = "PASSTHROUGH"
 )
 
 // GetMeshFedConfig fetches a MeshFedConfig matching mfcSelector
@@ -70,44 +64,48 @@ func GetMeshFedConfig(ctx context.Context, r client.Client, mfcSelector map[stri
 	return mfc, err
 }
 
-// GetMeshFedConfigReconciler creates a MeshFedConfig implementation specific to the MeshFedStyle
-func GetMeshFedConfigReconciler(mfc *mmv1.MeshFedConfig, cli client.Client, istioCli istioclient.Interface) (style.MeshFedConfig, error) {
-	if strings.ToUpper(mfc.Spec.Mode) == ModeBoundary {
-		log.Infof("Creating NewBoundaryProtectionMeshFedConfig reconciler for %s %s", mfc.GetObjectKind().GroupVersionKind().Kind, mfc.GetName())
-		return boundary_protection.NewBoundaryProtectionMeshFedConfig(cli, istioCli), nil
-	} else if strings.ToUpper(mfc.Spec.Mode) == ModePassthrough {
-		log.Infof("Creating NewPassthroughMeshFedConfig reconciler for %s %s", mfc.GetObjectKind().GroupVersionKind().Kind, mfc.GetName())
-		return passthrough.NewPassthroughMeshFedConfig(cli, istioCli), nil
+// lookupStyle returns the style selected by the mode of mfc, and fills in the defaults of the
+// style the defaulting webhook would have persisted, as the webhooks may not be enabled.
+// Styles register themselves when their package is imported, for example from main.
+func lookupStyle(mfc *mmv1.MeshFedConfig) (style.Style, error) {
+	s, ok := style.Lookup(mfc.Spec.Mode)
+	if !ok {
+		return s, fmt.Errorf("No handler for %q style", mfc.Spec.Mode)
+	}
+	if s.Default != nil {
+		s.Default(&mfc.Spec)
 	}
+	return s, nil
+}
 
-	return nil, fmt.Errorf("No handler for %v style", mfc)
+// GetMeshFedConfigReconciler creates a MeshFedConfig implementation specific to the MeshFedStyle
+func GetMeshFedConfigReconciler(mfc *mmv1.MeshFedConfig, cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) (style.MeshFedConfig, error) {
+	s, err := lookupStyle(mfc)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Creating %s MeshFedConfig reconciler for %s %s", s.Mode, mfc.GetObjectKind().GroupVersionKind().Kind, mfc.GetName())
+	return s.NewMeshFedConfig(cli, istioCli, recorder), nil
 }
 
 // GetBindingReconciler creates a ServiceBinding implementation specific to the MeshFedStyle
-func GetBindingReconciler(mfc *mmv1.MeshFedConfig, cli client.Client, istioCli istioclient.Interface) (style.ServiceBinder, error) {
-	// TODO: Detect if mfc refers to a Vadim-style reconciler
-	if strings.ToUpper(mfc.Spec.Mode) == ModeBoundary {
-		log.Infof("Creating NewBoundaryProtectionServiceBinder reconciler for %s %s", mfc.GetObjectKind().GroupVersionKind().Kind, mfc.GetName())
-		return boundary_protection.NewBoundaryProtectionServiceBinder(cli, istioCli), nil
-	} else if strings.ToUpper(mfc.Spec.Mode) == ModePassthrough {
-		log.Infof("Creating NewPassthroughServiceBinder reconciler for %s %s", mfc.GetObjectKind().GroupVersionKind().Kind, mfc.GetName())
-		return passthrough.NewPassthroughServiceBinder(cli, istioCli), nil
+func GetBindingReconciler(mfc *mmv1.MeshFedConfig, cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) (style.ServiceBinder, error) {
+	s, err := lookupStyle(mfc)
+	if err != nil {
+		return nil, err
 	}
-
-	return nil, fmt.Errorf("No handler for %v style", mfc)
+	log.Infof("Creating %s ServiceBinder reconciler for %s %s", s.Mode, mfc.GetObjectKind().GroupVersionKind().Kind, mfc.GetName())
+	return s.NewServiceBinder(cli, istioCli, recorder), nil
 }
 
 // GetExposureReconciler creates a ServiceExposure implementation specific to the MeshFedStyle
-func GetExposureReconciler(mfc *mmv1.MeshFedConfig, cli client.Client, istioCli istioclient.Interface) (style.ServiceExposer, error) {
-	// TODO: Detect if mfc refers to a Vadim-style reconciler
-	if strings.ToUpper(mfc.Spec.Mode) == ModeBoundary {
-		log.Infof("Creating NewBoundaryProtectionServiceExposer reconciler for %s %s", mfc.GetObjectKind().GroupVersionKind().Kind, mfc.GetName())
-		return boundary_protection.NewBoundaryProtectionServiceExposer(cli, istioCli), nil
-	} else if strings.ToUpper(mfc.Spec.Mode) == ModePassthrough {
-		log.Infof("Creating NewPassthroughServiceExposer reconciler for %s %s", mfc.GetObjectKind().GroupVersionKind().Kind, mfc.GetName())
-		return passthrough.NewPassthroughServiceExposer(cli, istioCli), nil
+func GetExposureReconciler(mfc *mmv1.MeshFedConfig, cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) (style.ServiceExposer, error) {
+	s, err := lookupStyle(mfc)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("No handler for %v style", mfc)
+	log.Infof("Creating %s ServiceExposer reconciler for %s %s", s.Mode, mfc.GetObjectKind().GroupVersionKind().Kind, mfc.GetName())
+	return s.NewServiceExposer(cli, istioCli, recorder), nil
 }
 
 func containsString(slice []string, s string) bool {
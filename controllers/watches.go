@@ -0,0 +1,93 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+
+	"istio.io/pkg/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// meshFedConfigChanged ignores MeshFedConfig updates that only touch the status, so
+// that writing a MeshFedConfig's status does not re-reconcile its dependents
+var meshFedConfigChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() ||
+			!reflect.DeepEqual(e.MetaOld.GetLabels(), e.MetaNew.GetLabels())
+	},
+}
+
+// selectsMeshFedConfig is true if selector matches the labels of mfc
+func selectsMeshFedConfig(selector map[string]string, mfc metav1.Object) bool {
+	if len(selector) == 0 {
+		return false
+	}
+	return labels.SelectorFromSet(selector).Matches(labels.Set(mfc.GetLabels()))
+}
+
+// expositionsForMeshFedConfig maps a MeshFedConfig to the ServiceExpositions that select it
+func (r *ServiceExpositionReconciler) expositionsForMeshFedConfig() handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+			var list mmv1.ServiceExpositionList
+			if err := r.List(context.Background(), &list); err != nil {
+				log.Warnf("Could not list ServiceExpositions for MeshFedConfig %s/%s: %v", o.Meta.GetNamespace(), o.Meta.GetName(), err)
+				return nil
+			}
+			var retval []reconcile.Request
+			for _, se := range list.Items {
+				if selectsMeshFedConfig(se.Spec.MeshFedConfigSelector, o.Meta) {
+					retval = append(retval, reconcile.Request{
+						NamespacedName: types.NamespacedName{Namespace: se.GetNamespace(), Name: se.GetName()},
+					})
+				}
+			}
+			return retval
+		}),
+	}
+}
+
+// bindingsForMeshFedConfig maps a MeshFedConfig to the ServiceBindings that select it
+func (r *ServiceBindingReconciler) bindingsForMeshFedConfig() handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+			var list mmv1.ServiceBindingList
+			if err := r.List(context.Background(), &list); err != nil {
+				log.Warnf("Could not list ServiceBindings for MeshFedConfig %s/%s: %v", o.Meta.GetNamespace(), o.Meta.GetName(), err)
+				return nil
+			}
+			var retval []reconcile.Request
+			for _, sb := range list.Items {
+				if selectsMeshFedConfig(sb.Spec.MeshFedConfigSelector, o.Meta) {
+					retval = append(retval, reconcile.Request{
+						NamespacedName: types.NamespacedName{Namespace: sb.GetNamespace(), Name: sb.GetName()},
+					})
+				}
+			}
+			return retval
+		}),
+	}
+}
@@ -27,7 +27,13 @@ import (
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
 	"github.com/istio-ecosystem/emcee/controllers"
 	"github.com/istio-ecosystem/emcee/pkg/discovery"
+	"github.com/istio-ecosystem/emcee/pkg/metrics"
+	"github.com/istio-ecosystem/emcee/style"
+	// The built-in styles register themselves when their package is imported.  Other styles
+	// are made available by importing their package here.
+	_ "github.com/istio-ecosystem/emcee/style/builtin"
 	mfutil "github.com/istio-ecosystem/emcee/util"
+	"github.com/istio-ecosystem/emcee/webhooks"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -39,7 +45,6 @@ import (
 
 const (
 	grpcServerAddress      = ":50051"
-	discoveryLabel         = "emcee:discovery"
 	emceeAutoExposeLabel   = "emcee.io/expose"
 	emceeAutoExposeAsLabel = "emcee.io/exposeAs"
 )
@@ -63,13 +68,15 @@ func main() {
 		enableLeaderElection    bool
 		leaderElectionNamespace string
 		grpcServerAddr          string
-		grpcDiscoveryLabel      string
-		grpcDiscoveryLabelKey   string
-		grpcDiscoveryLabelVal   string
 		autoExposeLabel         string
 		autoExposeLabelKey      string
 		autoExposeAsLabel       string
 		autoExposeAsLabelKey    string
+		esdsTLS                 discovery.TLSOptions
+		esdsAllowedIdentities   string
+		clusterName             string
+		importConflictPolicy    string
+		enableWebhooks          bool
 	)
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&k8sContext, "context", "", "Kubernetes context")
@@ -77,9 +84,21 @@ func main() {
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "", "Kubernetes namespace.")
 	flag.StringVar(&grpcServerAddr, "grpc-server-addr", grpcServerAddress, "The address the grpc server endpoint binds to.")
-	flag.StringVar(&grpcDiscoveryLabel, "discovery-label", discoveryLabel, "The label for grpc servers to connect to.")
 	flag.StringVar(&autoExposeLabel, "auto-expose-label", emceeAutoExposeLabel, "The label for auto exposing a service.")
 	flag.StringVar(&autoExposeAsLabel, "exposeAs-label", emceeAutoExposeAsLabel, "The label for auto exposing a service as a different service.")
+	flag.BoolVar(&esdsTLS.Enabled, "esds-tls", false, "Use mutual TLS for the exposed services discovery server and clients.")
+	flag.StringVar(&esdsTLS.CertFile, "esds-tls-cert", "", "The certificate file for ESDS mutual TLS.")
+	flag.StringVar(&esdsTLS.KeyFile, "esds-tls-key", "", "The private key file for ESDS mutual TLS.")
+	flag.StringVar(&esdsTLS.CAFile, "esds-tls-ca", "", "The CA certificate file used to verify ESDS peers.")
+	flag.StringVar(&esdsTLS.Secret, "esds-tls-secret", "",
+		"The namespace/name of a Secret with tls.crt, tls.key and ca.crt for ESDS mutual TLS. Defaults to a MeshFedConfig's discovery_tls secret.")
+	flag.StringVar(&esdsAllowedIdentities, "esds-allowed-identities", "",
+		"Comma separated DNS SANs or SPIFFE IDs of ESDS peers allowed to connect. A trailing * matches any suffix, so * allows any peer signed by the CA.")
+	flag.StringVar(&clusterName, "cluster-name", "", "The name of this cluster, sent to remote ESDS servers that select exposed services by cluster, and the default cluster ID of exposed services.")
+	flag.StringVar(&importConflictPolicy, "import-conflict-policy", string(discovery.ConflictFirstWins),
+		"How services exported by several peers into the same ServiceBinding are imported: first-wins, merge-endpoints or reject.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Serve the defaulting and validating admission webhooks. Requires the webhook certificate of config/certmanager.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.Logger(true))
@@ -91,10 +110,13 @@ func main() {
 	}
 	setupLog.Info("Loaded config", "context", k8sContext)
 
-	s := strings.Split(grpcDiscoveryLabel, ":")
-	if len(s) == 2 {
-		grpcDiscoveryLabelKey = s[0]
-		grpcDiscoveryLabelVal = s[1]
+	if esdsAllowedIdentities != "" {
+		esdsTLS.AllowedIdentities = strings.Split(esdsAllowedIdentities, ",")
+	}
+	conflictPolicy, err := discovery.ParseConflictPolicy(importConflictPolicy)
+	if err != nil {
+		setupLog.Error(err, "invalid flag", "flag", "import-conflict-policy")
+		os.Exit(1)
 	}
 
 	autoExposeLabelKey = emceeAutoExposeLabel
@@ -114,6 +136,8 @@ func main() {
 	}
 
 	kclient := mgr.GetClient()
+	// Generated objects are removed without caching every Service and Deployment of the cluster
+	style.SetAPIReader(mgr.GetAPIReader())
 
 	istioClient, err := versionedclient.NewForConfig(cfg)
 	if err != nil {
@@ -124,6 +148,7 @@ func main() {
 	if err = (&controllers.MeshFedConfigReconciler{
 		Client:    kclient,
 		Interface: istioClient,
+		Recorder:  mgr.GetEventRecorderFor("emcee-meshfedconfig"),
 		//Log:    ctrl.Log.WithName("controllers").WithName("MeshFedConfig"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MeshFedConfig")
@@ -133,6 +158,7 @@ func main() {
 	ser := controllers.ServiceExpositionReconciler{
 		Client:    kclient,
 		Interface: istioClient,
+		Recorder:  mgr.GetEventRecorderFor("emcee-serviceexposition"),
 		//Log:    ctrl.Log.WithName("controllers").WithName("ServiceExposition"),
 	}
 	if err = (&ser).SetupWithManager(mgr); err != nil {
@@ -143,6 +169,7 @@ func main() {
 	sbr := controllers.ServiceBindingReconciler{
 		Client:    kclient,
 		Interface: istioClient,
+		Recorder:  mgr.GetEventRecorderFor("emcee-servicebinding"),
 		//Log:    ctrl.Log.WithName("controllers").WithName("ServiceBinding"),
 	}
 	if err = (&sbr).SetupWithManager(mgr); err != nil {
@@ -153,11 +180,10 @@ func main() {
 	svcr := controllers.ServiceReconciler{
 		Client:               kclient,
 		Interface:            istioClient,
-		DiscoveryLabelKey:    grpcDiscoveryLabelKey,
-		DiscoveryLabelVal:    grpcDiscoveryLabelVal,
 		AutoExposeLabelKey:   autoExposeLabelKey,
 		AutoExposeAsLabelKey: autoExposeAsLabelKey,
 		SEReconciler:         &ser,
+		Recorder:             mgr.GetEventRecorderFor("emcee-service"),
 		//Log:    ctrl.Log.WithName("controllers").WithName("Service"),
 	}
 
@@ -166,14 +192,41 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&controllers.DiscoveryPeerReconciler{
+		Client: kclient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DiscoveryPeer")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
-	ctx := context.Background()
-	go discovery.Discovery(&ser, &grpcServerAddr)
-	go discovery.ClientStarter(ctx, &sbr, &svcr, controllers.DiscoveryChanel)
+	if enableWebhooks {
+		webhooks.SetupWithManager(mgr)
+	}
+
+	if err = metrics.RegisterFederationCollector(kclient); err != nil {
+		setupLog.Error(err, "unable to register metrics")
+		os.Exit(1)
+	}
+
+	// Discovery clients stop with the manager
+	stop := ctrl.SetupSignalHandler()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+	esdsCreds := discovery.NewCredentials(esdsTLS, kclient)
+	discovery.SetClusterName(clusterName)
+	go discovery.Discovery(&ser, &grpcServerAddr, esdsCreds)
+	go discovery.ClientStarter(ctx, &sbr, controllers.DiscoveryChanel, discovery.ClientOptions{
+		Credentials:    esdsCreds,
+		ConflictPolicy: conflictPolicy,
+		Recorder:       mgr.GetEventRecorderFor("emcee-discovery"),
+	})
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(stop); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
@@ -0,0 +1,173 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/mccli/pkg"
+)
+
+// generateOptions are the flags shared by expose and bind
+type generateOptions struct {
+	name     string
+	alias    string
+	selector string
+	ports    []string
+	apply    bool
+}
+
+func (o *generateOptions) addFlags(cmd *cobra.Command, what string) {
+	cmd.Flags().StringVar(&o.name, "name", "", "Name of the "+what+"; defaults to the service's")
+	cmd.Flags().StringVar(&o.alias, "alias", "", "Name the service is known by in the other meshes")
+	cmd.Flags().StringVarP(&o.selector, "selector", "l", "", "Labels of the MeshFedConfig, such as fed-config=limited-trust")
+	cmd.Flags().StringSliceVarP(&o.ports, "port", "p", nil, "Port of the service as name:number[/protocol], or an HTTP port number")
+	cmd.Flags().BoolVar(&o.apply, "apply", false, "Create or update the "+what+" instead of printing it")
+	_ = cmd.MarkFlagRequired("selector")
+}
+
+func (o *generateOptions) servicePorts() ([]mmv1.ServicePort, error) {
+	var retval []mmv1.ServicePort
+	for _, s := range o.ports {
+		port, err := pkg.ParsePort(s)
+		if err != nil {
+			return nil, err
+		}
+		retval = append(retval, port)
+	}
+	return retval, nil
+}
+
+// client returns the namespace of the flags, and a client if the object is applied.  Only the
+// namespace of the context, if --namespace is not set, requires reading the kubeconfig otherwise.
+func (o *generateOptions) client(global *globalOptions) (client.Client, string, error) {
+	if o.apply || global.namespace == "" {
+		return global.client()
+	}
+	return nil, global.namespace, nil
+}
+
+func newExposeCommand(global *globalOptions) *cobra.Command {
+	var opts generateOptions
+	var subset string
+	cmd := &cobra.Command{
+		Use:   "expose <service>",
+		Short: "Generate a ServiceExposition exposing a service to the other meshes",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			selector, err := pkg.ParseSelector(opts.selector)
+			if err != nil {
+				return err
+			}
+			ports, err := opts.servicePorts()
+			if err != nil {
+				return err
+			}
+			cl, namespace, err := opts.client(global)
+			if err != nil {
+				return err
+			}
+
+			se, err := pkg.NewServiceExposition(pkg.ExposeOptions{
+				Name:                  opts.name,
+				Namespace:             namespace,
+				Service:               args[0],
+				Alias:                 opts.alias,
+				Subset:                subset,
+				Ports:                 ports,
+				MeshFedConfigSelector: selector,
+			})
+			if err != nil {
+				return err
+			}
+			if !opts.apply {
+				return pkg.WriteObject(cmd.OutOrStdout(), se)
+			}
+			result, err := pkg.ApplyServiceExposition(context.Background(), cl, se)
+			return printApplied(cmd.OutOrStdout(), "serviceexposition", se.GetNamespace(), se.GetName(), result, err)
+		},
+	}
+	opts.addFlags(cmd, "exposition")
+	cmd.Flags().StringVar(&subset, "subset", "", "Subset of the service, as defined in a DestinationRule")
+	return cmd
+}
+
+func newBindCommand(global *globalOptions) *cobra.Command {
+	var opts generateOptions
+	var serviceNamespace string
+	var endpoints []string
+	cmd := &cobra.Command{
+		Use:   "bind <service>",
+		Short: "Generate a ServiceBinding binding a service of another mesh",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			selector, err := pkg.ParseSelector(opts.selector)
+			if err != nil {
+				return err
+			}
+			ports, err := opts.servicePorts()
+			if err != nil {
+				return err
+			}
+			cl, namespace, err := opts.client(global)
+			if err != nil {
+				return err
+			}
+
+			sb, err := pkg.NewServiceBinding(pkg.BindOptions{
+				Name:                  opts.name,
+				Namespace:             namespace,
+				Service:               args[0],
+				ServiceNamespace:      serviceNamespace,
+				Alias:                 opts.alias,
+				Ports:                 ports,
+				Endpoints:             endpoints,
+				MeshFedConfigSelector: selector,
+			})
+			if err != nil {
+				return err
+			}
+			if !opts.apply {
+				return pkg.WriteObject(cmd.OutOrStdout(), sb)
+			}
+			result, err := pkg.ApplyServiceBinding(context.Background(), cl, sb)
+			return printApplied(cmd.OutOrStdout(), "servicebinding", sb.GetNamespace(), sb.GetName(), result, err)
+		},
+	}
+	opts.addFlags(cmd, "binding")
+	cmd.Flags().StringVar(&serviceNamespace, "service-namespace", "", "Namespace of the service in the other mesh; defaults to the binding's")
+	cmd.Flags().StringSliceVar(&endpoints, "endpoint", nil, "ip:port of an ingress of the other mesh; may be left to discovery")
+	return cmd
+}
+
+// printApplied reports the outcome of applying an object, like kubectl apply
+func printApplied(out io.Writer, kind, namespace, name string, result controllerutil.OperationResult, err error) error {
+	if err != nil {
+		return fmt.Errorf("cannot apply %s %s/%s: %v", kind, namespace, name, err)
+	}
+	if result == controllerutil.OperationResultNone {
+		result = "unchanged"
+	}
+	fmt.Fprintf(out, "%s %s/%s %s\n", kind, namespace, name, result)
+	return nil
+}
@@ -0,0 +1,124 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/istio-ecosystem/emcee/mccli/pkg"
+)
+
+func newOpenAPICommand(global *globalOptions) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Print the exposed services as OpenAPI",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "yaml" && output != "json" {
+				return fmt.Errorf("unknown output format %q, expected yaml or json", output)
+			}
+			cl, namespace, opts, err := openAPIClient(global)
+			if err != nil {
+				return err
+			}
+			return writeOpenAPI(cmd.OutOrStdout(), cl, namespace, opts, output)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "yaml", "Output format: yaml or json")
+	return cmd
+}
+
+func newServeCommand(global *globalOptions) *cobra.Command {
+	var port string
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the exposed services as OpenAPI",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, namespace, opts, err := openAPIClient(global)
+			if err != nil {
+				return err
+			}
+
+			mux := http.NewServeMux()
+			mux.Handle("/", &openAPIHandler{Client: cl, Namespace: namespace, Options: opts})
+			fmt.Fprintf(cmd.OutOrStdout(), "Serving on %s\n", port)
+			return http.ListenAndServe(":"+port, mux)
+		},
+	}
+	cmd.Flags().StringVar(&port, "port", "8080", "Port to serve on")
+	return cmd
+}
+
+// openAPIHandler serves the expositions of a namespace as OpenAPI.  It serves JSON to clients
+// that accept it, such as Swagger UI, and YAML to the others.
+type openAPIHandler struct {
+	Client    client.Client
+	Namespace string
+	Options   pkg.ConvertOptions
+}
+
+func (o *openAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	output := "yaml"
+	if strings.Contains(r.Header.Get("Accept"), "application/json") || r.URL.Query().Get("format") == "json" {
+		output = "json"
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if err := writeOpenAPI(w, o.Client, o.Namespace, o.Options, output); err != nil {
+		log.Printf("Failed to serve OpenAPI: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// openAPIClient creates a client, and a proxy to fetch the OpenAPI specs of the services
+func openAPIClient(global *globalOptions) (client.Client, string, pkg.ConvertOptions, error) {
+	restConfig, namespace, err := global.restConfig()
+	if err != nil {
+		return nil, "", pkg.ConvertOptions{}, err
+	}
+	cl, err := pkg.NewClient(restConfig)
+	if err != nil {
+		return nil, "", pkg.ConvertOptions{}, err
+	}
+	proxy, err := pkg.NewServiceProxy(restConfig)
+	if err != nil {
+		return nil, "", pkg.ConvertOptions{}, err
+	}
+	return cl, namespace, pkg.ConvertOptions{ServiceProxy: proxy}, nil
+}
+
+func writeOpenAPI(w io.Writer, cl client.Client, namespace string, opts pkg.ConvertOptions, output string) error {
+	expositions, err := pkg.GetExposures(cl, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list exposures: %v", err)
+	}
+	openAPI, err := pkg.Convert(cl, *expositions, opts)
+	if err != nil {
+		return fmt.Errorf("failed to convert: %v", err)
+	}
+	if output == "json" {
+		return pkg.ToJSON(openAPI, w)
+	}
+	return pkg.ToYAML(openAPI, w)
+}
@@ -0,0 +1,86 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+)
+
+func newPeersCommand(global *globalOptions) *cobra.Command {
+	var allNamespaces bool
+	cmd := &cobra.Command{
+		Use:   "peers",
+		Short: "List the discovery peers and the state of their connections",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, namespace, err := global.client()
+			if err != nil {
+				return err
+			}
+			var opts []client.ListOption
+			if !allNamespaces {
+				opts = append(opts, client.InNamespace(namespace))
+			}
+			var peers mmv1.DiscoveryPeerList
+			if err := cl.List(context.Background(), &peers, opts...); err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 2, ' ', 0)
+			fmt.Fprintf(w, "NAMESPACE\tNAME\tADDRESS\tSTATE\tIMPORTED\tCOLLISIONS\tLAST MESSAGE\tLAST ERROR\n")
+			for _, peer := range peers.Items {
+				port := peer.Spec.Port
+				if port == 0 {
+					port = mmv1.DefaultDiscoveryPort
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+					peer.GetNamespace(), peer.GetName(), net.JoinHostPort(peer.Spec.Address, strconv.Itoa(int(port))),
+					orUnknown(string(peer.Status.ConnectionState)), peer.Status.ImportedBindings,
+					len(peer.Status.Collisions), since(peer.Status.LastMessageTime), peer.Status.LastError)
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "List the peers of every namespace")
+	return cmd
+}
+
+// since is how long ago t was, like the ages of kubectl
+func since(t *metav1.Time) string {
+	if t == nil || t.IsZero() {
+		return "<none>"
+	}
+	return duration.HumanDuration(time.Since(t.Time)) + " ago"
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "<unknown>"
+	}
+	return s
+}
@@ -0,0 +1,48 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	istiolog "istio.io/pkg/log"
+
+	"github.com/istio-ecosystem/emcee/mccli/pkg"
+)
+
+func newRenderCommand() *cobra.Command {
+	var ingressAddress string
+	cmd := &cobra.Command{
+		Use:   "render <filename>...",
+		Short: "Print the objects emcee would generate for files of emcee CRs",
+		Long: `Print the Kubernetes and Istio objects emcee would generate for files of
+MeshFedConfigs, ServiceExpositions and ServiceBindings, without a cluster.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The manifests go to stdout; keep the styles' logging out of them
+			o := istiolog.DefaultOptions()
+			o.OutputPaths = []string{"stderr"}
+			if err := istiolog.Configure(o); err != nil {
+				return fmt.Errorf("cannot configure logging: %v", err)
+			}
+
+			return pkg.RenderFiles(cmd.OutOrStdout(), pkg.RenderOptions{IngressAddress: ingressAddress}, args...)
+		},
+	}
+	cmd.Flags().StringVar(&ingressAddress, "ingress-address", pkg.DefaultIngressAddress, "Load balancer IP given to the ingresses")
+	return cmd
+}
@@ -0,0 +1,69 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cmd implements the subcommands of mccli
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/istio-ecosystem/emcee/mccli/pkg"
+)
+
+// globalOptions are the flags shared by the subcommands
+type globalOptions struct {
+	kubeconfig string
+	context    string
+	namespace  string
+}
+
+// client creates a client for the cluster of the flags, and returns it with the namespace.  It
+// is pkg.NewCliClient's client, except that it honors --kubeconfig and resolves the namespace
+// of the context, which the commands use when -n is not given.
+func (o *globalOptions) client() (client.Client, string, error) {
+	return pkg.NewKubeconfigClient(o.kubeconfig, o.namespace, o.context)
+}
+
+// restConfig is client for the commands that need the REST config itself
+func (o *globalOptions) restConfig() (*rest.Config, string, error) {
+	return pkg.NewKubeconfigRESTConfig(o.kubeconfig, o.namespace, o.context)
+}
+
+// NewRootCommand creates the mccli command and its subcommands
+func NewRootCommand() *cobra.Command {
+	var global globalOptions
+	root := &cobra.Command{
+		Use:          "mccli",
+		Short:        "mccli works with the services federated by emcee",
+		SilenceUsage: true,
+	}
+	root.PersistentFlags().StringVar(&global.kubeconfig, "kubeconfig", "", "Kubernetes configuration file; defaults to $KUBECONFIG or ~/.kube/config")
+	root.PersistentFlags().StringVar(&global.context, "context", "", "Kubernetes configuration context")
+	root.PersistentFlags().StringVarP(&global.namespace, "namespace", "n", "", "Kubernetes namespace; defaults to the context's")
+
+	root.AddCommand(
+		newOpenAPICommand(&global),
+		newServeCommand(&global),
+		newValidateCommand(&global),
+		newRenderCommand(),
+		newStatusCommand(&global),
+		newExposeCommand(&global),
+		newBindCommand(&global),
+		newPeersCommand(&global),
+	)
+	return root
+}
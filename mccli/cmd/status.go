@@ -0,0 +1,49 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/istio-ecosystem/emcee/mccli/pkg"
+)
+
+func newStatusCommand(global *globalOptions) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the health of the MeshFedConfigs, ServiceExpositions and ServiceBindings of a namespace",
+		Long: `Show the health of the federation of a namespace: the mode and gateways of each
+MeshFedConfig, and for each ServiceExposition and ServiceBinding the MeshFedConfigs it selects,
+whether it is ready, its endpoints, and how many of its generated Gateways, VirtualServices,
+DestinationRules and ServiceEntries exist.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Not pkg.NewCliClient, which cannot tell the namespace of the context to show
+			cl, namespace, err := global.client()
+			if err != nil {
+				return err
+			}
+			status, err := pkg.GetFederationStatus(cl, namespace)
+			if err != nil {
+				return err
+			}
+			return pkg.WriteStatus(cmd.OutOrStdout(), status, output)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format: table, json or yaml")
+	return cmd
+}
@@ -0,0 +1,70 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+
+	"github.com/istio-ecosystem/emcee/mccli/pkg"
+)
+
+func newValidateCommand(global *globalOptions) *cobra.Command {
+	var bundle, cluster bool
+	cmd := &cobra.Command{
+		Use:   "validate <filename>...",
+		Short: "Validate files of emcee CRs",
+		Long: `Validate files of emcee CRs.  With --bundle each file is also checked against the
+MeshFedConfigs, Services and DestinationRules it holds, and with --cluster against those
+of the cluster.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if bundle && cluster {
+				return fmt.Errorf("--bundle and --cluster are exclusive")
+			}
+			validateFile := pkg.ValidateFile
+			if bundle {
+				validateFile = pkg.ValidateBundle
+			}
+			if cluster {
+				cl, _, err := global.client()
+				if err != nil {
+					return err
+				}
+				validateFile = func(filename string) error {
+					return pkg.ValidateFileInCluster(cl, filename)
+				}
+			}
+
+			var retval error
+			for _, filename := range args {
+				if err := validateFile(filename); err != nil {
+					retval = multierror.Append(retval, fmt.Errorf("%s: %v", filename, err))
+				}
+			}
+			if retval != nil {
+				return retval
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Valid\n")
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&bundle, "bundle", false, "Check the CRs against the other resources of their file")
+	cmd.Flags().BoolVar(&cluster, "cluster", false, "Check the CRs against the resources of the cluster")
+	return cmd
+}
@@ -16,28 +16,18 @@
 package main
 
 import (
-	"flag"
-	"fmt"
-	"log"
 	"os"
 
-	"github.com/istio-ecosystem/emcee/mccli/pkg"
+	// This next line lets us use IBM Kubernetes Service
+	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
+
+	"github.com/istio-ecosystem/emcee/mccli/cmd"
+	// The styles mccli can render, convert and validate
+	_ "github.com/istio-ecosystem/emcee/style/builtin"
 )
 
 func main() {
-	var filename string
-	flag.StringVar(&filename, "filename", "", "Filename")
-	flag.Parse()
-
-	if filename == "" {
-		fmt.Printf("usage: validate --filename <filename>\n")
+	if err := cmd.NewRootCommand().Execute(); err != nil {
 		os.Exit(1)
 	}
-
-	err := pkg.ValidateFile(filename)
-	if err != nil {
-		log.Fatalf("invalid: %v", err)
-	}
-
-	fmt.Printf("File is valid\n")
 }
@@ -19,128 +19,199 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 
 	multierror "github.com/hashicorp/go-multierror"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/istio-ecosystem/emcee/controllers"
+	"github.com/istio-ecosystem/emcee/style"
+	"github.com/istio-ecosystem/emcee/style/boundary_protection"
 
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
 )
 
-// simple mock-up of OpenAPI for proof-of-concept
-type info struct {
-	Title       string
-	Description string
-	Version     string
+// ConvertOptions are the options of Convert
+type ConvertOptions struct {
+	// ServiceProxy fetches the OpenAPI specs that expositions reference by path.  If it is
+	// nil those expositions only get a catch-all path.
+	ServiceProxy ServiceProxy
 }
 
-type server struct {
-	URL         string
-	Description string
-}
-
-type response struct {
-	Description string
-}
-
-type pathOp struct {
-	Responses map[int]response
-}
-
-type path struct {
-	Summary string
-	Get     pathOp
-}
-
-// OpenAPI is simple mock-up of OpenAPI for proof-of-concept
-type OpenAPI struct {
-	OpenAPI string
-	Info    info
-	Servers []server
-	Paths   map[string]path
-}
-
-// Convert converts mmv1.ServiceExpositions to Swagger
-// TODO This version only does Boundary Protection expositions; add the rest
-func Convert(cl client.Client, expositions []mmv1.ServiceExposition) (*OpenAPI, error) {
+// Convert converts mmv1.ServiceExpositions to an OpenAPI document.  Each exposed service has
+// a tag, and its paths are served by the ingress of its MeshFedConfig, at the addresses the
+// style of the MeshFedConfig publishes.  The paths are those of the OpenAPI spec the
+// exposition references, see OpenAPIAnnotation, or else a catch-all path.  Boundary
+// protection routes each HTTP port under its own prefix; the other styles pass the TLS of the
+// first HTTP port through, so its paths are the service's own.
+func Convert(cl client.Client, expositions []mmv1.ServiceExposition, opts ConvertOptions) (*OpenAPI, error) {
 	retval := OpenAPI{
-		OpenAPI: "3.0.0",
-		Info: info{
+		OpenAPI: OpenAPIVersion,
+		Info: Info{
 			Title:       "Ingress API",
 			Description: fmt.Sprintf("%d services advertised for this Private Ingress", len(expositions)),
 			Version:     "0.0.1", // TODO
 		},
-		Paths: map[string]path{},
+		Paths: map[string]*PathItem{},
 	}
 
 	expToFed, err := mapExposureToMFC(cl, expositions)
 	if err != nil {
 		return nil, err
 	}
-	bpMFCs := getBPMFCs(expToFed)
-	for _, bpMFC := range bpMFCs {
-		retval.Servers = append(retval.Servers, server{
-			// TODO Get real exposed IP from MFC's ingress Service external IP reverse lookup
-			URL:         fmt.Sprintf("http://private-ingress-%s.mycluster.us-south.containers.appdomain.cloud:15443/", bpMFC.GetObjectMeta().GetName()),
-			Description: fmt.Sprintf("Private ingress for %q mesh", bpMFC.GetObjectMeta().GetName()),
-		})
+	ctx := context.Background()
+	mfcServers := make(map[string][]Server)
+	for _, mfc := range getMFCs(expToFed) {
+		servers, err := ingressServers(ctx, cl, mfc)
+		if err != nil {
+			log.Printf("Cannot find the ingress of MFC %q: %v", mfc.GetName(), err)
+			continue
+		}
+		mfcServers[mfc.GetName()] = servers
+		retval.Servers = append(retval.Servers, servers...)
 	}
 
-	for _, exposition := range expositions {
+	for i := range expositions {
+		exposition := &expositions[i]
 		mfc, ok := expToFed[kname(exposition.ObjectMeta)]
 		if !ok {
 			log.Printf("Cannot find MFC for %q", exposition.GetObjectMeta().GetName())
 			continue
 		}
+		servers, ok := mfcServers[mfc.GetName()]
+		if !ok {
+			// Paths without servers would be served by the ingresses of the other MFCs
+			log.Printf("Not advertising %q, the ingress of MFC %q is unknown", exposition.GetObjectMeta().GetName(), mfc.GetName())
+			continue
+		}
 
-		if isBoundaryProtection(mfc) {
-			paths := getBPPaths(exposition)
-			for _, p := range paths {
-				retval.Paths[p] = path{
-					Summary: "TODO",
-					Get: pathOp{
-						Responses: map[int]response{
-							200: response{
-								Description: "OK",
-							},
-						},
-					},
+		tag := Tag{
+			Name:        getExposedTag(*exposition),
+			Description: fmt.Sprintf("Service %q exposed through MeshFedConfig %q", exposition.Spec.Name, mfc.GetName()),
+		}
+		retval.Tags = append(retval.Tags, tag)
+
+		spec, err := getUpstreamSpec(ctx, cl, opts, exposition)
+		if err != nil {
+			log.Printf("Cannot get the OpenAPI spec of %q: %v", exposition.GetObjectMeta().GetName(), err)
+		}
+		specPort, _ := openAPIPort(exposition)
+		for _, p := range exposedPorts(exposition, mfc) {
+			prefix := "/"
+			id := "get-" + strings.Replace(tag.Name, "/", "-", -1)
+			if isBoundaryProtection(mfc) {
+				prefix = boundary_protection.ExposedPathPrefix(exposition, p)
+				id = "get-" + strings.Replace(strings.Trim(prefix, "/"), "/", "-", -1)
+			}
+			if spec != nil && p.Name == specPort.Name {
+				err := mergeUpstream(&retval, spec, prefix, tag.Name, servers)
+				if err == nil {
+					continue
 				}
+				log.Printf("Cannot merge the OpenAPI spec of %q: %v", exposition.GetObjectMeta().GetName(), err)
+			}
+			if _, ok := retval.Paths[prefix+"{path}"]; ok {
+				log.Printf("Cannot advertise %q, path %s{path} is already in the catalogue", exposition.GetObjectMeta().GetName(), prefix)
+				continue
 			}
+			retval.Paths[prefix+"{path}"] = catchAllPath(id, tag.Name, servers)
 		}
 	}
 
 	return &retval, nil
 }
 
-func getBPMFCs(expToFed map[string]*mmv1.MeshFedConfig) []*mmv1.MeshFedConfig {
+// catchAllPath passes any path to an exposed service
+func catchAllPath(id, tag string, servers []Server) *PathItem {
+	return &PathItem{
+		Servers: servers,
+		Parameters: []Parameter{
+			{
+				Name:        "path",
+				In:          "path",
+				Description: "The path of the request to the service",
+				Required:    true,
+				Schema:      Schema{"type": "string"},
+			},
+		},
+		Get: &Operation{
+			Tags:        []string{tag},
+			Summary:     fmt.Sprintf("Call %s", tag),
+			OperationID: id,
+			Responses: map[string]*Response{
+				"200": {
+					Description: "OK",
+				},
+			},
+		},
+	}
+}
+
+// exposedPorts are the ports of an exposition with paths in the catalogue: every HTTP port
+// for boundary protection, which routes them by path, and the first one for the other styles
+func exposedPorts(se *mmv1.ServiceExposition, mfc *mmv1.MeshFedConfig) []mmv1.ServicePort {
+	if !isBoundaryProtection(mfc) {
+		if p, ok := openAPIPort(se); ok {
+			return []mmv1.ServicePort{p}
+		}
+		return nil
+	}
+	var retval []mmv1.ServicePort
+	for _, p := range se.Spec.ServicePorts() {
+		if p.Protocol.IsHTTP() {
+			retval = append(retval, p)
+		}
+	}
+	return retval
+}
+
+// ingressServers are the URLs of the ingress of a MeshFedConfig, from the lookup of its style
+func ingressServers(ctx context.Context, cl client.Client, mfc *mmv1.MeshFedConfig) ([]Server, error) {
+	s, ok := style.Lookup(mfc.Spec.Mode)
+	if !ok {
+		return nil, fmt.Errorf("unknown mode %q", mfc.Spec.Mode)
+	}
+	if s.IngressAddresses == nil {
+		return nil, fmt.Errorf("the %s style does not publish its ingress", s.Mode)
+	}
+	eps, err := s.IngressAddresses(ctx, cl, mfc)
+	if err != nil {
+		return nil, err
+	}
+	var servers []Server
+	for _, ep := range eps {
+		servers = append(servers, Server{
+			URL:         fmt.Sprintf("%s://%s/", s.IngressScheme, ep),
+			Description: fmt.Sprintf("Ingress of MeshFedConfig %q", mfc.GetName()),
+		})
+	}
+	return servers, nil
+}
+
+// getMFCs returns the MeshFedConfigs of the expositions, sorted by name
+func getMFCs(expToFed map[string]*mmv1.MeshFedConfig) []*mmv1.MeshFedConfig {
 	meshes := make(map[string]*mmv1.MeshFedConfig)
 	for _, mfc := range expToFed {
-		if isBoundaryProtection(mfc) {
-			meshes[mfc.GetObjectMeta().GetName()] = mfc
-		}
+		meshes[mfc.GetObjectMeta().GetName()] = mfc
 	}
 
 	retval := []*mmv1.MeshFedConfig{}
 	for _, mesh := range meshes {
 		retval = append(retval, mesh)
 	}
+	sort.Slice(retval, func(i, j int) bool { return retval[i].GetName() < retval[j].GetName() })
 	return retval
 }
 
 func isBoundaryProtection(mfc *mmv1.MeshFedConfig) bool {
-	return true
-	// TODO Uncomment the next line when we are testing Boundary Protection again
-	// return mfc.Spec.UseIngressGateway && mfc.Spec.DeepCopy().UseEgressGateway
+	return strings.EqualFold(mfc.Spec.Mode, boundary_protection.Mode)
 }
 
-// TODO depending on the exposure add extra canned paths or consult the microservice's internal OpenAPI
-func getBPPaths(exp mmv1.ServiceExposition) []string {
-	return []string{
-		fmt.Sprintf("/%s/%s%s/*", exp.ObjectMeta.Namespace, getExposedName(exp), getExposedSubset(exp)),
-	}
+// getExposedTag names an exposed service <namespace>/<name>[/<subset>]
+func getExposedTag(exp mmv1.ServiceExposition) string {
+	return fmt.Sprintf("%s/%s%s", exp.ObjectMeta.Namespace, getExposedName(exp), getExposedSubset(exp))
 }
 
 func getExposedSubset(exp mmv1.ServiceExposition) string {
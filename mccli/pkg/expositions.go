@@ -17,7 +17,7 @@ package pkg
 
 import (
 	"context"
-	"log"
+	"fmt"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
@@ -26,21 +26,42 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/pkg/validate"
 )
 
-// NewClient creates a client that can read mmv1 things
+// NewClient creates a client that can read mmv1 things, and the Kubernetes and Istio objects
+// they are validated against
 func NewClient(restConfig *rest.Config) (client.Client, error) {
 	scheme := runtime.NewScheme()
-	_ = mmv1.AddToScheme(scheme)
+	_ = validate.AddToScheme(scheme)
 	cl, err := client.New(restConfig, client.Options{Scheme: scheme})
 	return cl, err
 }
 
 // NewCliClient creates a client based on command-line arguments
 func NewCliClient(namespace, kcontext string) (client.Client, error) {
+	cl, _, err := NewKubeconfigClient("", namespace, kcontext)
+	return cl, err
+}
+
+// NewKubeconfigClient creates a client for a context of a kubeconfig file.  The file defaults
+// to $KUBECONFIG or ~/.kube/config and the context to its current one.  It also returns
+// namespace or, if it is empty, the namespace of the context.
+func NewKubeconfigClient(kubeconfig, namespace, kcontext string) (client.Client, string, error) {
+	restConfig, ns, err := NewKubeconfigRESTConfig(kubeconfig, namespace, kcontext)
+	if err != nil {
+		return nil, "", err
+	}
+	cl, err := NewClient(restConfig)
+	return cl, ns, err
+}
+
+// NewKubeconfigRESTConfig is NewKubeconfigClient for the callers that need the REST config
+func NewKubeconfigRESTConfig(kubeconfig, namespace, kcontext string) (*rest.Config, string, error) {
 
 	// See https://godoc.org/k8s.io/client-go/tools/clientcmd#BuildConfigFromFlags
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
 	configOverrides := &clientcmd.ConfigOverrides{
 		ClusterDefaults: clientcmd.ClusterDefaults,
 		Context: clientcmdapi.Context{
@@ -51,12 +72,14 @@ func NewCliClient(namespace, kcontext string) (client.Client, error) {
 
 	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 	restConfig, err := kubeConfig.ClientConfig()
-
 	if err != nil {
-		log.Fatalf("Failed to create Kubernetes REST config: %s", err)
+		return nil, "", fmt.Errorf("failed to create Kubernetes REST config: %v", err)
 	}
-
-	return NewClient(restConfig)
+	ns, _, err := kubeConfig.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+	return restConfig, ns, nil
 }
 
 // GetExposures returns the exposures in a namespace
@@ -0,0 +1,205 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/pkg/validate"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// ExposeOptions describes a ServiceExposition
+type ExposeOptions struct {
+	// Name and Namespace of the exposition.  The name defaults to the Service's.
+	Name      string
+	Namespace string
+	// Service is the name of the exposed Service, in Namespace
+	Service string
+	Alias   string
+	Subset  string
+	Ports   []mmv1.ServicePort
+	// MeshFedConfigSelector selects the MeshFedConfig exposing the Service
+	MeshFedConfigSelector map[string]string
+}
+
+// BindOptions describes a ServiceBinding
+type BindOptions struct {
+	// Name and Namespace of the binding.  The name defaults to the Service's.
+	Name      string
+	Namespace string
+	// Service and ServiceNamespace name the remote Service.  Its namespace defaults to Namespace.
+	Service          string
+	ServiceNamespace string
+	Alias            string
+	Ports            []mmv1.ServicePort
+	// Endpoints are the ip:port of the remote ingresses.  They may be left to discovery.
+	Endpoints []string
+	// MeshFedConfigSelector selects the MeshFedConfig binding the Service
+	MeshFedConfigSelector map[string]string
+}
+
+// NewServiceExposition returns the ServiceExposition opts describes, if it is valid
+func NewServiceExposition(opts ExposeOptions) (*mmv1.ServiceExposition, error) {
+	name := opts.Name
+	if name == "" {
+		name = opts.Service
+	}
+	se := &mmv1.ServiceExposition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: mmv1.GroupVersion.String(),
+			Kind:       "ServiceExposition",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: opts.Namespace,
+		},
+		Spec: mmv1.ServiceExpositionSpec{
+			Name:                  opts.Service,
+			MeshFedConfigSelector: opts.MeshFedConfigSelector,
+			Alias:                 opts.Alias,
+			Subset:                opts.Subset,
+			Ports:                 opts.Ports,
+		},
+	}
+	if err := validate.ServiceExposition(name, opts.Namespace, se.Spec); err != nil {
+		return nil, err
+	}
+	return se, nil
+}
+
+// NewServiceBinding returns the ServiceBinding opts describes, if it is valid
+func NewServiceBinding(opts BindOptions) (*mmv1.ServiceBinding, error) {
+	name := opts.Name
+	if name == "" {
+		name = opts.Service
+	}
+	serviceNamespace := opts.ServiceNamespace
+	if serviceNamespace == "" {
+		serviceNamespace = opts.Namespace
+	}
+	sb := &mmv1.ServiceBinding{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: mmv1.GroupVersion.String(),
+			Kind:       "ServiceBinding",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: opts.Namespace,
+		},
+		Spec: mmv1.ServiceBindingSpec{
+			Name:                  opts.Service,
+			MeshFedConfigSelector: opts.MeshFedConfigSelector,
+			Alias:                 opts.Alias,
+			Ports:                 opts.Ports,
+			Namespace:             serviceNamespace,
+			Endpoints:             opts.Endpoints,
+		},
+	}
+	if err := validate.ServiceBinding(name, opts.Namespace, sb.Spec); err != nil {
+		return nil, err
+	}
+	return sb, nil
+}
+
+// ParsePort parses name:number[/protocol] or name:number[:protocol], or a bare number for an
+// HTTP port named http
+func ParsePort(s string) (mmv1.ServicePort, error) {
+	fields := strings.Split(s, ":")
+	if len(fields) == 1 {
+		fields = []string{"http", fields[0]}
+	}
+	if i := strings.Index(fields[len(fields)-1], "/"); i >= 0 && len(fields) == 2 {
+		fields = []string{fields[0], fields[1][:i], fields[1][i+1:]}
+	}
+	if len(fields) > 3 {
+		return mmv1.ServicePort{}, fmt.Errorf("invalid port %q; use name:number[/protocol]", s)
+	}
+	number, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		return mmv1.ServicePort{}, fmt.Errorf("invalid port %q: %v", s, err)
+	}
+	port := mmv1.ServicePort{
+		Name:   fields[0],
+		Number: uint32(number),
+	}
+	if len(fields) == 3 {
+		if fields[2] == "" {
+			return mmv1.ServicePort{}, fmt.Errorf("invalid port %q: empty protocol", s)
+		}
+		port.Protocol = mmv1.Protocol(strings.ToUpper(fields[2]))
+	}
+	return port, nil
+}
+
+// ParseSelector parses a MeshFedConfig selector such as fed-config=limited-trust
+func ParseSelector(s string) (map[string]string, error) {
+	selector, err := labels.ConvertSelectorToLabelsMap(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %v", s, err)
+	}
+	return selector, nil
+}
+
+// ApplyServiceExposition creates se, or updates the spec of the exposition of the same name.
+// The fields filled in by the controller are kept.
+func ApplyServiceExposition(ctx context.Context, cl client.Client, se *mmv1.ServiceExposition) (controllerutil.OperationResult, error) {
+	spec := se.Spec
+	return controllerutil.CreateOrUpdate(ctx, cl, se, func() error {
+		existing := se.Spec
+		se.Spec = spec
+		se.Spec.Endpoints = existing.Endpoints
+		se.Spec.Locality = existing.Locality
+		se.Spec.Network = existing.Network
+		se.Spec.ClusterID = existing.ClusterID
+		return nil
+	})
+}
+
+// ApplyServiceBinding creates sb, or updates the spec of the binding of the same name.  If sb
+// has no endpoints, those discovered for the existing binding are kept.
+func ApplyServiceBinding(ctx context.Context, cl client.Client, sb *mmv1.ServiceBinding) (controllerutil.OperationResult, error) {
+	spec := sb.Spec
+	return controllerutil.CreateOrUpdate(ctx, cl, sb, func() error {
+		existing := sb.Spec
+		sb.Spec = spec
+		if len(spec.Endpoints) == 0 {
+			sb.Spec.Endpoints = existing.Endpoints
+			sb.Spec.Topology = existing.Topology
+		}
+		return nil
+	})
+}
+
+// WriteObject writes an emcee CR as a YAML manifest
+func WriteObject(out io.Writer, obj runtime.Object) error {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	m, err := toManifest(gvk, obj)
+	if err != nil {
+		return err
+	}
+	return WriteManifests(out, []map[string]interface{}{m})
+}
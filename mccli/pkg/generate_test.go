@@ -0,0 +1,191 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"reflect"
+	"testing"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+)
+
+func TestParsePort(t *testing.T) {
+	cases := []struct {
+		in       string
+		expected mmv1.ServicePort
+		ok       bool
+	}{
+		{in: "5000", expected: mmv1.ServicePort{Name: "http", Number: 5000}, ok: true},
+		{in: "http:5000", expected: mmv1.ServicePort{Name: "http", Number: 5000}, ok: true},
+		{in: "grpc:9090/grpc", expected: mmv1.ServicePort{Name: "grpc", Number: 9090, Protocol: mmv1.ProtocolGRPC}, ok: true},
+		{in: "db:5432:tcp", expected: mmv1.ServicePort{Name: "db", Number: 5432, Protocol: mmv1.ProtocolTCP}, ok: true},
+		{in: "80/tls", expected: mmv1.ServicePort{Name: "http", Number: 80, Protocol: mmv1.ProtocolTLS}, ok: true},
+		{in: ""},
+		{in: "http"},
+		{in: "http:"},
+		{in: "http:-1"},
+		{in: "http:port"},
+		{in: "http:5000/"},
+		{in: "http:5000:http:tcp"},
+	}
+	for _, c := range cases {
+		actual, err := ParsePort(c.in)
+		if c.ok && err != nil {
+			t.Errorf("%q: %v", c.in, err)
+		}
+		if !c.ok && err == nil {
+			t.Errorf("%q: parsed as %+v", c.in, actual)
+		}
+		if c.ok && actual != c.expected {
+			t.Errorf("%q: parsed as %+v, expected %+v", c.in, actual, c.expected)
+		}
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	cases := []struct {
+		in       string
+		expected map[string]string
+		ok       bool
+	}{
+		{in: "fed-config=limited-trust", expected: map[string]string{"fed-config": "limited-trust"}, ok: true},
+		{in: "fed-config=limited-trust,mesh=cluster1", expected: map[string]string{"fed-config": "limited-trust", "mesh": "cluster1"}, ok: true},
+		{in: "fed-config"},
+		{in: "fed-config!=limited-trust"},
+	}
+	for _, c := range cases {
+		actual, err := ParseSelector(c.in)
+		if c.ok && err != nil {
+			t.Errorf("%q: %v", c.in, err)
+		}
+		if !c.ok && err == nil {
+			t.Errorf("%q: parsed as %v", c.in, actual)
+		}
+		if c.ok && !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%q: parsed as %v, expected %v", c.in, actual, c.expected)
+		}
+	}
+}
+
+func TestNewServiceExposition(t *testing.T) {
+	selector := map[string]string{"fed-config": "limited-trust"}
+	ports := []mmv1.ServicePort{{Name: "http", Number: 5000}}
+	cases := []struct {
+		name     string
+		opts     ExposeOptions
+		expected mmv1.ServiceExpositionSpec
+		seName   string
+		ok       bool
+	}{
+		{
+			name:     "defaults to the service name",
+			opts:     ExposeOptions{Namespace: "limited-trust", Service: "helloworld", Ports: ports, MeshFedConfigSelector: selector},
+			expected: mmv1.ServiceExpositionSpec{Name: "helloworld", Ports: ports, MeshFedConfigSelector: selector},
+			seName:   "helloworld",
+			ok:       true,
+		},
+		{
+			name: "named with alias and subset",
+			opts: ExposeOptions{Name: "hello", Namespace: "limited-trust", Service: "helloworld", Alias: "hello",
+				Subset: "v1", Ports: ports, MeshFedConfigSelector: selector},
+			expected: mmv1.ServiceExpositionSpec{Name: "helloworld", Alias: "hello", Subset: "v1", Ports: ports, MeshFedConfigSelector: selector},
+			seName:   "hello",
+			ok:       true,
+		},
+		{name: "no selector", opts: ExposeOptions{Namespace: "limited-trust", Service: "helloworld", Ports: ports}},
+		{name: "no ports", opts: ExposeOptions{Namespace: "limited-trust", Service: "helloworld", MeshFedConfigSelector: selector}},
+		{name: "bad protocol", opts: ExposeOptions{Namespace: "limited-trust", Service: "helloworld",
+			Ports: []mmv1.ServicePort{{Name: "http", Number: 5000, Protocol: "UDP"}}, MeshFedConfigSelector: selector}},
+	}
+	for _, c := range cases {
+		se, err := NewServiceExposition(c.opts)
+		if !c.ok {
+			if err == nil {
+				t.Errorf("%s: generated %+v", c.name, se)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if se.Kind != "ServiceExposition" || se.APIVersion != mmv1.GroupVersion.String() {
+			t.Errorf("%s: generated a %s %s", c.name, se.APIVersion, se.Kind)
+		}
+		if se.Name != c.seName || se.Namespace != c.opts.Namespace {
+			t.Errorf("%s: generated %s/%s", c.name, se.Namespace, se.Name)
+		}
+		if !reflect.DeepEqual(se.Spec, c.expected) {
+			t.Errorf("%s: generated %+v, expected %+v", c.name, se.Spec, c.expected)
+		}
+	}
+}
+
+func TestNewServiceBinding(t *testing.T) {
+	selector := map[string]string{"fed-config": "limited-trust"}
+	ports := []mmv1.ServicePort{{Name: "http", Number: 5000}}
+	cases := []struct {
+		name     string
+		opts     BindOptions
+		expected mmv1.ServiceBindingSpec
+		sbName   string
+		ok       bool
+	}{
+		{
+			name:     "defaults to the service name and namespace",
+			opts:     BindOptions{Namespace: "limited-trust", Service: "helloworld", Ports: ports, MeshFedConfigSelector: selector},
+			expected: mmv1.ServiceBindingSpec{Name: "helloworld", Namespace: "limited-trust", Ports: ports, MeshFedConfigSelector: selector},
+			sbName:   "helloworld",
+			ok:       true,
+		},
+		{
+			name: "remote namespace and endpoints",
+			opts: BindOptions{Name: "hello", Namespace: "cluster2-shop", Service: "helloworld", ServiceNamespace: "shop",
+				Alias: "hello", Ports: ports, Endpoints: []string{"192.0.2.1:15443"}, MeshFedConfigSelector: selector},
+			expected: mmv1.ServiceBindingSpec{Name: "helloworld", Namespace: "shop", Alias: "hello", Ports: ports,
+				Endpoints: []string{"192.0.2.1:15443"}, MeshFedConfigSelector: selector},
+			sbName: "hello",
+			ok:     true,
+		},
+		{name: "no selector", opts: BindOptions{Namespace: "limited-trust", Service: "helloworld", Ports: ports}},
+		{name: "bad alias", opts: BindOptions{Namespace: "limited-trust", Service: "helloworld", Alias: "Hello.World",
+			Ports: ports, MeshFedConfigSelector: selector}},
+		{name: "duplicate ports", opts: BindOptions{Namespace: "limited-trust", Service: "helloworld",
+			Ports: append(ports, ports...), MeshFedConfigSelector: selector}},
+	}
+	for _, c := range cases {
+		sb, err := NewServiceBinding(c.opts)
+		if !c.ok {
+			if err == nil {
+				t.Errorf("%s: generated %+v", c.name, sb)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if sb.Kind != "ServiceBinding" || sb.APIVersion != mmv1.GroupVersion.String() {
+			t.Errorf("%s: generated a %s %s", c.name, sb.APIVersion, sb.Kind)
+		}
+		if sb.Name != c.sbName || sb.Namespace != c.opts.Namespace {
+			t.Errorf("%s: generated %s/%s", c.name, sb.Namespace, sb.Name)
+		}
+		if !reflect.DeepEqual(sb.Spec, c.expected) {
+			t.Errorf("%s: generated %+v, expected %+v", c.name, sb.Spec, c.expected)
+		}
+	}
+}
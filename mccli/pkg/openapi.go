@@ -16,12 +16,129 @@
 package pkg
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 
 	"gopkg.in/yaml.v2"
 )
 
+// OpenAPIVersion is the version of the OpenAPI specification the documents follow
+const OpenAPIVersion = "3.0.3"
+
+// OpenAPI is an OpenAPI 3.0 document, see https://spec.openapis.org/oas/v3.0.3.  Only the
+// objects mccli generates or merges from the specs of exposed services are modeled; the other
+// fields of those specs are dropped.
+type OpenAPI struct {
+	OpenAPI    string               `json:"openapi" yaml:"openapi"`
+	Info       Info                 `json:"info" yaml:"info"`
+	Servers    []Server             `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths" yaml:"paths"`
+	Components *Components          `json:"components,omitempty" yaml:"components,omitempty"`
+	Tags       []Tag                `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// Components holds the reusable objects of the document
+type Components struct {
+	Schemas       map[string]Schema       `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	Responses     map[string]*Response    `json:"responses,omitempty" yaml:"responses,omitempty"`
+	Parameters    map[string]*Parameter   `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies,omitempty" yaml:"requestBodies,omitempty"`
+}
+
+// Info is the metadata of the API
+type Info struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+// Server is a URL the API is served at
+type Server struct {
+	URL         string `json:"url" yaml:"url"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// Tag groups the operations of an exposed service
+type Tag struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// PathItem describes the operations available on a path.  Its servers override those of
+// the document.
+type PathItem struct {
+	Summary     string      `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+	Servers     []Server    `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Parameters  []Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Get         *Operation  `json:"get,omitempty" yaml:"get,omitempty"`
+	Put         *Operation  `json:"put,omitempty" yaml:"put,omitempty"`
+	Post        *Operation  `json:"post,omitempty" yaml:"post,omitempty"`
+	Delete      *Operation  `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Options     *Operation  `json:"options,omitempty" yaml:"options,omitempty"`
+	Head        *Operation  `json:"head,omitempty" yaml:"head,omitempty"`
+	Patch       *Operation  `json:"patch,omitempty" yaml:"patch,omitempty"`
+	Trace       *Operation  `json:"trace,omitempty" yaml:"trace,omitempty"`
+}
+
+// Operation is a single API operation on a path
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses" yaml:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// Parameter is a parameter of an operation.  A reference only has Ref.
+type Parameter struct {
+	Ref         string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Name        string `json:"name,omitempty" yaml:"name,omitempty"`
+	In          string `json:"in,omitempty" yaml:"in,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool   `json:"required,omitempty" yaml:"required,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Schema      Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// RequestBody is the body of a request.  A reference only has Ref.
+type RequestBody struct {
+	Ref         string               `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+	Required    bool                 `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// Response is a response of an operation.  A reference only has Ref.
+type Response struct {
+	Ref         string               `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// MediaType is the schema of a request or response body of a media type
+type MediaType struct {
+	Schema Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// Schema is a JSON schema.  It is kept as a map, as mccli does not interpret schemas.
+type Schema map[string]interface{}
+
+// operations returns the operations of a path
+func (item *PathItem) operations() []*Operation {
+	var retval []*Operation
+	for _, op := range []*Operation{item.Get, item.Put, item.Post, item.Delete, item.Options, item.Head, item.Patch, item.Trace} {
+		if op != nil {
+			retval = append(retval, op)
+		}
+	}
+	return retval
+}
+
 // ToYAML prints YAML to stdout
 func ToYAML(data *OpenAPI, w io.Writer) error {
 	d, err := yaml.Marshal(&data)
@@ -32,3 +149,14 @@ func ToYAML(data *OpenAPI, w io.Writer) error {
 	fmt.Fprintf(w, "%s", d)
 	return nil
 }
+
+// ToJSON prints indented JSON to stdout
+func ToJSON(data *OpenAPI, w io.Writer) error {
+	d, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s\n", d)
+	return nil
+}
@@ -0,0 +1,302 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/pkg/validate"
+)
+
+// openAPISchema is the JSON schema of OpenAPI 3.0 documents, from github.com/googleapis/gnostic
+const openAPISchema = "../../test/openapi/openapi-3.0.json"
+
+// helloworldSpec is served by the helloworld service, under the path of its server
+const helloworldSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "helloworld", "version": "1.0"},
+  "servers": [{"url": "/api"}],
+  "paths": {
+    "/hello/{name}": {
+      "parameters": [{"name": "name", "in": "path", "required": true, "schema": {"type": "string"}}],
+      "get": {
+        "operationId": "hello",
+        "responses": {
+          "200": {"description": "A greeting", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Greeting"}}}}
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Greeting": {"type": "object", "properties": {"message": {"type": "string"}}}
+    }
+  }
+}`
+
+// ratingsSpec is held by a ConfigMap
+const ratingsSpec = `
+openapi: 3.0.0
+info:
+  title: ratings
+  version: "2.0"
+paths:
+  /ratings:
+    post:
+      operationId: rate
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+      responses:
+        "201":
+          description: Rated
+`
+
+func TestConvertIsValidOpenAPI(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := validate.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	objs := []runtime.Object{
+		&mmv1.MeshFedConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "limited-trust", Labels: map[string]string{"fed-config": "c1"}},
+			Spec:       mmv1.MeshFedConfigSpec{Mode: "BOUNDARY", UseIngressGateway: true, UseEgressGateway: true},
+		},
+		&mmv1.MeshFedConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "c2", Namespace: "limited-trust", Labels: map[string]string{"fed-config": "c2"}},
+			Spec:       mmv1.MeshFedConfigSpec{Mode: "BOUNDARY", UseIngressGateway: true, UseEgressGateway: true},
+		},
+		&mmv1.MeshFedConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "passthrough", Labels: map[string]string{"fed-config": "p1"}},
+			Spec:       mmv1.MeshFedConfigSpec{Mode: "PASSTHROUGH", UseIngressGateway: true},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ratings-api", Namespace: "limited-trust"},
+			Data:       map[string]string{"openapi.yaml": ratingsSpec},
+		},
+		ingressService("limited-trust", "istio-c1-ingress-15443", corev1.LoadBalancerIngress{IP: "192.0.2.10"}),
+		ingressService("limited-trust", "istio-c2-ingress-15443", corev1.LoadBalancerIngress{Hostname: "ingress.example.com"}),
+		ingressService("istio-system", "istio-ingressgateway", corev1.LoadBalancerIngress{IP: "192.0.2.20"}, corev1.LoadBalancerIngress{IP: "2001:db8::20"}),
+	}
+	expositions := []mmv1.ServiceExposition{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "helloworld",
+				Namespace:   "limited-trust",
+				Annotations: map[string]string{OpenAPIPathAnnotation: "/openapi.json"},
+			},
+			Spec: mmv1.ServiceExpositionSpec{
+				Name:                  "helloworld",
+				MeshFedConfigSelector: map[string]string{"fed-config": "c1"},
+				Ports:                 []mmv1.ServicePort{{Name: "http", Number: 5000}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "reviews-v2",
+				Namespace:   "limited-trust",
+				Annotations: map[string]string{OpenAPIConfigMapAnnotation: "ratings-api"},
+			},
+			Spec: mmv1.ServiceExpositionSpec{
+				Name:                  "reviews",
+				Alias:                 "ratings",
+				Subset:                "v2",
+				MeshFedConfigSelector: map[string]string{"fed-config": "c2"},
+				Ports:                 []mmv1.ServicePort{{Name: "http", Number: 9080}, {Name: "admin", Number: 9090}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "details", Namespace: "passthrough"},
+			Spec: mmv1.ServiceExpositionSpec{
+				Name:                  "details",
+				MeshFedConfigSelector: map[string]string{"fed-config": "p1"},
+				Ports:                 []mmv1.ServicePort{{Name: "http", Number: 9080}},
+			},
+		},
+	}
+	opts := ConvertOptions{
+		ServiceProxy: func(ctx context.Context, namespace, name string, port uint32, path string) ([]byte, error) {
+			if namespace != "limited-trust" || name != "helloworld" || port != 5000 || path != "/openapi.json" {
+				return nil, fmt.Errorf("unexpected request for %s from %s/%s:%d", path, namespace, name, port)
+			}
+			return []byte(helloworldSpec), nil
+		},
+	}
+
+	openAPI, err := Convert(fake.NewFakeClientWithScheme(scheme, objs...), expositions, opts)
+	if err != nil {
+		t.Fatalf("Could not convert: %v", err)
+	}
+	var urls []string
+	for _, server := range openAPI.Servers {
+		urls = append(urls, server.URL)
+	}
+	expectedURLs := []string{"https://192.0.2.10:15443/", "https://ingress.example.com:15443/", "https://192.0.2.20:443/", "https://[2001:db8::20]:443/"}
+	if !reflect.DeepEqual(urls, expectedURLs) {
+		t.Fatalf("Expected the servers %v, got %v", expectedURLs, urls)
+	}
+	if len(openAPI.Tags) != 3 {
+		t.Fatalf("Expected three tags, got %+v", openAPI.Tags)
+	}
+
+	hello, ok := openAPI.Paths["/limited-trust/helloworld/api/hello/{name}"]
+	if !ok {
+		t.Fatalf("Expected the path of helloworld under its prefix, got %v", openAPI.Paths)
+	}
+	if hello.Get.OperationID != "limited-trust-helloworld-hello" || hello.Get.Tags[0] != "limited-trust/helloworld" {
+		t.Errorf("Expected the operation to be renamed and tagged, got %+v", hello.Get)
+	}
+	if ref := hello.Get.Responses["200"].Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/limited-trust.helloworld.Greeting" {
+		t.Errorf("Expected the reference to be renamed, got %v", ref)
+	}
+	if _, ok := openAPI.Components.Schemas["limited-trust.helloworld.Greeting"]; !ok {
+		t.Errorf("Expected the schema to be renamed, got %v", openAPI.Components.Schemas)
+	}
+
+	ratings, ok := openAPI.Paths["/limited-trust/ratings/http/ratings"]
+	if !ok || ratings.Post == nil {
+		t.Fatalf("Expected the path of ratings under the prefix of its first port, got %v", openAPI.Paths)
+	}
+	if len(ratings.Servers) != 1 || ratings.Servers[0] != openAPI.Servers[1] {
+		t.Errorf("Expected the path to be served by the ingress of c2, got %v", ratings.Servers)
+	}
+	if _, ok := openAPI.Paths["/limited-trust/ratings/admin/{path}"]; !ok {
+		t.Errorf("Expected a catch-all path for the other port, got %v", openAPI.Paths)
+	}
+	details, ok := openAPI.Paths["/{path}"]
+	if !ok || !reflect.DeepEqual(details.Servers, openAPI.Servers[2:]) {
+		t.Errorf("Expected the passthrough service to be served by Istio's ingress, got %v", openAPI.Paths)
+	}
+	if len(openAPI.Paths) != 4 {
+		t.Errorf("Expected 4 paths, got %v", openAPI.Paths)
+	}
+
+	var out bytes.Buffer
+	if err := ToJSON(openAPI, &out); err != nil {
+		t.Fatalf("Could not write JSON: %v", err)
+	}
+	verifyOpenAPI(t, out.Bytes())
+
+	out.Reset()
+	if err := ToYAML(openAPI, &out); err != nil {
+		t.Fatalf("Could not write YAML: %v", err)
+	}
+	data, err := kubeyaml.ToJSON(out.Bytes())
+	if err != nil {
+		t.Fatalf("Cannot parse the YAML: %v\n%s", err, out.String())
+	}
+	verifyOpenAPI(t, data)
+}
+
+func TestConvertWithoutUpstreamSpec(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := validate.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	objs := []runtime.Object{
+		&mmv1.MeshFedConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "limited-trust", Labels: map[string]string{"fed-config": "c1"}},
+			Spec:       mmv1.MeshFedConfigSpec{Mode: "BOUNDARY", UseIngressGateway: true, UseEgressGateway: true},
+		},
+		ingressService("limited-trust", "istio-c1-ingress-15443", corev1.LoadBalancerIngress{IP: "192.0.2.10"}),
+		// The ingress of c2 has no address yet
+		&mmv1.MeshFedConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "c2", Namespace: "limited-trust", Labels: map[string]string{"fed-config": "c2"}},
+			Spec:       mmv1.MeshFedConfigSpec{Mode: "BOUNDARY", UseIngressGateway: true, UseEgressGateway: true},
+		},
+		ingressService("limited-trust", "istio-c2-ingress-15443"),
+	}
+	expositions := []mmv1.ServiceExposition{
+		{
+			// Without a ServiceProxy the spec cannot be fetched
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "helloworld",
+				Namespace:   "limited-trust",
+				Annotations: map[string]string{OpenAPIPathAnnotation: "/openapi.json"},
+			},
+			Spec: mmv1.ServiceExpositionSpec{
+				Name:                  "helloworld",
+				MeshFedConfigSelector: map[string]string{"fed-config": "c1"},
+				Port:                  5000,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "reviews", Namespace: "limited-trust"},
+			Spec: mmv1.ServiceExpositionSpec{
+				Name:                  "reviews",
+				MeshFedConfigSelector: map[string]string{"fed-config": "c2"},
+				Port:                  9080,
+			},
+		},
+	}
+
+	openAPI, err := Convert(fake.NewFakeClientWithScheme(scheme, objs...), expositions, ConvertOptions{})
+	if err != nil {
+		t.Fatalf("Could not convert: %v", err)
+	}
+	if _, ok := openAPI.Paths["/limited-trust/helloworld/{path}"]; !ok || len(openAPI.Paths) != 1 {
+		t.Fatalf("Expected only a catch-all path for helloworld, got %v", openAPI.Paths)
+	}
+	if len(openAPI.Servers) != 1 || openAPI.Servers[0].URL != "https://192.0.2.10:15443/" {
+		t.Fatalf("Expected only the ingress of c1, got %v", openAPI.Servers)
+	}
+}
+
+// ingressService is a LoadBalancer Service with the addresses of ingresses
+func ingressService(namespace, name string, ingresses ...corev1.LoadBalancerIngress) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: ingresses},
+		},
+	}
+}
+
+// verifyOpenAPI validates a JSON document against the OpenAPI 3.0 schema
+func verifyOpenAPI(t *testing.T, data []byte) {
+	t.Helper()
+
+	schema, err := filepath.Abs(openAPISchema)
+	if err != nil {
+		t.Fatalf("Cannot find the schema: %v", err)
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewReferenceLoader("file://"+filepath.ToSlash(schema)), gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		t.Fatalf("Cannot validate: %v", err)
+	}
+	if !result.Valid() {
+		for _, e := range result.Errors() {
+			t.Errorf("Invalid OpenAPI: %s", e)
+		}
+		t.Fatalf("Document:\n%s", data)
+	}
+}
@@ -0,0 +1,471 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/controllers"
+	"github.com/istio-ecosystem/emcee/style"
+	"gopkg.in/yaml.v2"
+
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// DefaultIngressAddress is the address given to the ingresses while rendering.  It is
+// reserved for documentation (RFC 5737).
+const DefaultIngressAddress = "192.0.2.1"
+
+// RenderOptions describes the cluster the objects are rendered for
+type RenderOptions struct {
+	// IngressAddress is the load balancer IP of every ingress, see DefaultIngressAddress
+	IngressAddress string
+}
+
+// RenderFiles writes, as a YAML stream, the objects emcee generates for the CRs in filenames
+func RenderFiles(out io.Writer, opts RenderOptions, filenames ...string) error {
+	var resources []KubeKind
+	for _, filename := range filenames {
+		r, err := ReadKubernetesYaml(filename)
+		if err != nil {
+			return fmt.Errorf("%s: %v", filename, err)
+		}
+		resources = append(resources, *r...)
+	}
+
+	manifests, err := Render(resources, opts)
+	if err != nil {
+		return err
+	}
+	return WriteManifests(out, manifests)
+}
+
+// Render runs the style of each MeshFedConfig, then each ServiceExposition and ServiceBinding,
+// against fake Kubernetes and Istio clients.  It returns the objects they generated, without
+// their status, sorted by kind, namespace and name.
+//
+// The fake cluster has the istio-ingressgateway Service of istio-system, and a Secret for the
+// tls_context_selector of each MeshFedConfig.  LoadBalancer Services and Gateway API Gateways
+// get opts.IngressAddress once the MeshFedConfigs are rendered, as if a cloud provider assigned it.
+func Render(resources []KubeKind, opts RenderOptions) ([]map[string]interface{}, error) {
+	if opts.IngressAddress == "" {
+		opts.IngressAddress = DefaultIngressAddress
+	}
+	if net.ParseIP(opts.IngressAddress) == nil {
+		return nil, fmt.Errorf("ingress address %q is not an IP address", opts.IngressAddress)
+	}
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = mmv1.AddToScheme(scheme)
+
+	var mfcs []*mmv1.MeshFedConfig
+	var ses []*mmv1.ServiceExposition
+	var sbs []*mmv1.ServiceBinding
+	initObjs := []runtime.Object{renderIngressService(opts.IngressAddress)}
+	for _, obj := range resources {
+		o, err := convertObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		switch val := o.(type) {
+		case *mmv1.MeshFedConfig:
+			mfcs = append(mfcs, val)
+			if len(val.Spec.TlsContextSelector) > 0 {
+				initObjs = append(initObjs, renderSecret(val))
+			}
+		case *mmv1.ServiceExposition:
+			ses = append(ses, val)
+		case *mmv1.ServiceBinding:
+			sbs = append(sbs, val)
+		}
+		initObjs = append(initObjs, o)
+	}
+
+	ctx := context.Background()
+	cli := &recordingClient{
+		Client:  fake.NewFakeClientWithScheme(scheme, initObjs...),
+		scheme:  scheme,
+		written: map[objectKey]bool{},
+	}
+	istioCli := istiofake.NewSimpleClientset()
+
+	for _, mfc := range mfcs {
+		r, err := controllers.GetMeshFedConfigReconciler(mfc, cli, istioCli, nil)
+		if err == nil {
+			err = r.EffectMeshFedConfig(ctx, mfc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("MeshFedConfig %s/%s: %v", mfc.GetNamespace(), mfc.GetName(), err)
+		}
+	}
+
+	if err := cli.assignIngressAddress(ctx, opts.IngressAddress); err != nil {
+		return nil, err
+	}
+
+	for _, se := range ses {
+		mfc, err := controllers.GetMeshFedConfig(ctx, cli, se.Spec.MeshFedConfigSelector)
+		if err == nil {
+			var r style.ServiceExposer
+			r, err = controllers.GetExposureReconciler(&mfc, cli, istioCli, nil)
+			if err == nil {
+				err = r.EffectServiceExposure(ctx, se, &mfc)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ServiceExposition %s/%s: %v", se.GetNamespace(), se.GetName(), err)
+		}
+	}
+
+	for _, sb := range sbs {
+		mfc, err := controllers.GetMeshFedConfig(ctx, cli, sb.Spec.MeshFedConfigSelector)
+		if err == nil {
+			var r style.ServiceBinder
+			r, err = controllers.GetBindingReconciler(&mfc, cli, istioCli, nil)
+			if err == nil {
+				err = r.EffectServiceBinding(ctx, sb, &mfc)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ServiceBinding %s/%s: %v", sb.GetNamespace(), sb.GetName(), err)
+		}
+	}
+
+	manifests, err := cli.manifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	istioManifests, err := renderIstio(ctx, istioCli)
+	if err != nil {
+		return nil, err
+	}
+	manifests = append(manifests, istioManifests...)
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifestKey(manifests[i]) < manifestKey(manifests[j])
+	})
+	return manifests, nil
+}
+
+// WriteManifests writes manifests as a YAML stream
+func WriteManifests(out io.Writer, manifests []map[string]interface{}) error {
+	for i, m := range manifests {
+		d, err := yaml.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintf(out, "---\n")
+		}
+		fmt.Fprintf(out, "%s", d)
+	}
+	return nil
+}
+
+// convertObject returns the emcee CR held in obj
+func convertObject(obj KubeKind) (runtime.Object, error) {
+	spec, err := convertObjectSpec(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	om := obj.ObjectMeta
+	if om.GetNamespace() == "" {
+		om.SetNamespace(metav1.NamespaceDefault)
+	}
+	om.SetResourceVersion("")
+
+	switch val := spec.(type) {
+	case *mmv1.MeshFedConfigSpec:
+		return &mmv1.MeshFedConfig{TypeMeta: obj.TypeMeta, ObjectMeta: om, Spec: *val}, nil
+	case *mmv1.ServiceExpositionSpec:
+		return &mmv1.ServiceExposition{TypeMeta: obj.TypeMeta, ObjectMeta: om, Spec: *val}, nil
+	case *mmv1.ServiceBindingSpec:
+		return &mmv1.ServiceBinding{TypeMeta: obj.TypeMeta, ObjectMeta: om, Spec: *val}, nil
+	}
+	return nil, fmt.Errorf("cannot render: %v (a %T)", spec, spec)
+}
+
+// renderIngressService is the ingress of an Istio installation, used by the passthrough style
+func renderIngressService(address string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeLoadBalancer,
+			Selector: map[string]string{"istio": "ingressgateway"},
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: address}},
+			},
+		},
+	}
+}
+
+// renderSecret stands in for the Secret selected by the tls_context_selector of mfc
+func renderSecret(mfc *mmv1.MeshFedConfig) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-tls-context", mfc.GetName()),
+			Namespace: mfc.GetNamespace(),
+			Labels:    mfc.Spec.TlsContextSelector,
+		},
+	}
+}
+
+// objectKey identifies an object written through a recordingClient
+type objectKey struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+	name      string
+}
+
+// recordingClient remembers the objects written through it, to print them afterwards
+type recordingClient struct {
+	client.Client
+	scheme  *runtime.Scheme
+	written map[objectKey]bool
+}
+
+func (c *recordingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := c.Client.Create(ctx, obj, opts...); err != nil {
+		return err
+	}
+	return c.record(obj, true)
+}
+
+func (c *recordingClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := c.Client.Update(ctx, obj, opts...); err != nil {
+		return err
+	}
+	return c.record(obj, true)
+}
+
+func (c *recordingClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.Client.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	return c.record(obj, true)
+}
+
+func (c *recordingClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if err := c.Client.Delete(ctx, obj, opts...); err != nil {
+		return err
+	}
+	return c.record(obj, false)
+}
+
+func (c *recordingClient) record(obj runtime.Object, written bool) error {
+	key, err := c.keyOf(obj)
+	if err != nil {
+		return err
+	}
+	if key.gvk.Group == mmv1.GroupVersion.Group {
+		// The styles update the CRs, which are not generated
+		return nil
+	}
+	if written {
+		c.written[key] = true
+	} else {
+		delete(c.written, key)
+	}
+	return nil
+}
+
+func (c *recordingClient) keyOf(obj runtime.Object) (objectKey, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return objectKey{}, err
+	}
+	om, err := meta.Accessor(obj)
+	if err != nil {
+		return objectKey{}, err
+	}
+	return objectKey{gvk: gvk, namespace: om.GetNamespace(), name: om.GetName()}, nil
+}
+
+// newObject returns an empty object of the kind of key, unstructured if it has no Go type
+func (c *recordingClient) newObject(key objectKey) runtime.Object {
+	if obj, err := c.scheme.New(key.gvk); err == nil {
+		return obj
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(key.gvk)
+	return u
+}
+
+// assignIngressAddress gives address to the generated LoadBalancer Services and Gateway API Gateways
+func (c *recordingClient) assignIngressAddress(ctx context.Context, address string) error {
+	for key := range c.written {
+		nsn := client.ObjectKey{Namespace: key.namespace, Name: key.name}
+		switch key.gvk {
+		case corev1.SchemeGroupVersion.WithKind("Service"):
+			var svc corev1.Service
+			if err := c.Client.Get(ctx, nsn, &svc); err != nil {
+				return err
+			}
+			if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+				continue
+			}
+			svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: address}}
+			if err := c.Client.Update(ctx, &svc); err != nil {
+				return err
+			}
+		case style.GatewayGVK:
+			gw := c.newObject(key).(*unstructured.Unstructured)
+			if err := c.Client.Get(ctx, nsn, gw); err != nil {
+				return err
+			}
+			addresses := []interface{}{
+				map[string]interface{}{"type": "IPAddress", "value": address},
+			}
+			if err := unstructured.SetNestedSlice(gw.Object, addresses, "status", "addresses"); err != nil {
+				return err
+			}
+			if err := c.Client.Update(ctx, gw); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// manifests returns the objects written through c as they are now
+func (c *recordingClient) manifests(ctx context.Context) ([]map[string]interface{}, error) {
+	var retval []map[string]interface{}
+	for key := range c.written {
+		obj := c.newObject(key)
+		if err := c.Client.Get(ctx, client.ObjectKey{Namespace: key.namespace, Name: key.name}, obj); err != nil {
+			return nil, err
+		}
+		m, err := toManifest(key.gvk, obj)
+		if err != nil {
+			return nil, err
+		}
+		retval = append(retval, m)
+	}
+	return retval, nil
+}
+
+// renderIstio returns the Istio objects the styles created
+func renderIstio(ctx context.Context, istioCli *istiofake.Clientset) ([]map[string]interface{}, error) {
+	networking := istioCli.NetworkingV1alpha3()
+	var objs []runtime.Object
+	var kinds []string
+
+	gws, err := networking.Gateways(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range gws.Items {
+		objs, kinds = append(objs, &gws.Items[i]), append(kinds, "Gateway")
+	}
+	vss, err := networking.VirtualServices(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range vss.Items {
+		objs, kinds = append(objs, &vss.Items[i]), append(kinds, "VirtualService")
+	}
+	drs, err := networking.DestinationRules(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range drs.Items {
+		objs, kinds = append(objs, &drs.Items[i]), append(kinds, "DestinationRule")
+	}
+	ses, err := networking.ServiceEntries(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range ses.Items {
+		objs, kinds = append(objs, &ses.Items[i]), append(kinds, "ServiceEntry")
+	}
+
+	var retval []map[string]interface{}
+	for i, obj := range objs {
+		m, err := toManifest(v1alpha3.SchemeGroupVersion.WithKind(kinds[i]), obj)
+		if err != nil {
+			return nil, err
+		}
+		retval = append(retval, m)
+	}
+	return retval, nil
+}
+
+// toManifest returns obj as it would be applied: without status, server-set metadata or nulls
+func toManifest(gvk schema.GroupVersionKind, obj runtime.Object) (map[string]interface{}, error) {
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	d, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(d, &m); err != nil {
+		return nil, err
+	}
+	delete(m, "status")
+	if om, ok := m["metadata"].(map[string]interface{}); ok {
+		delete(om, "resourceVersion")
+	}
+	dropNulls(m)
+	return m, nil
+}
+
+// dropNulls removes the null fields, such as zero timestamps, of every map in v
+func dropNulls(v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, field := range val {
+			if field == nil {
+				delete(val, k)
+			} else {
+				dropNulls(field)
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			dropNulls(item)
+		}
+	}
+}
+
+// manifestKey orders manifests by kind, API version, namespace and name
+func manifestKey(m map[string]interface{}) string {
+	u := unstructured.Unstructured{Object: m}
+	return fmt.Sprintf("%s %s %s %s", u.GetKind(), u.GetAPIVersion(), u.GetNamespace(), u.GetName())
+}
@@ -0,0 +1,120 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	// The styles the tests render, convert and validate
+	_ "github.com/istio-ecosystem/emcee/style/builtin"
+)
+
+// renderedFile is the golden file of a directory under test/expected.  The other .yaml
+// files of the directory are the CRs it is rendered from.
+const renderedFile = "rendered.yaml"
+
+var update = flag.Bool("update", false, "rewrite the golden files under test/expected")
+
+func TestRender(t *testing.T) {
+	// The boundary style takes its image from the environment
+	os.Unsetenv("ISTIO_PROXY_IMAGE")
+
+	var dirs []string
+	err := filepath.Walk("../../test/expected", func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && info.Name() == renderedFile {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Could not find the golden files: %v", err)
+	}
+	if len(dirs) == 0 {
+		t.Fatalf("No golden files under test/expected")
+	}
+
+	for _, dir := range dirs {
+		dir := dir
+		t.Run(dir, func(t *testing.T) {
+			verifyRender(t, dir)
+		})
+	}
+}
+
+func verifyRender(t *testing.T, dir string) {
+	t.Helper()
+
+	inputs, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		t.Fatalf("Could not list %s: %v", dir, err)
+	}
+	golden := filepath.Join(dir, renderedFile)
+	var filenames []string
+	for _, input := range inputs {
+		if input != golden {
+			filenames = append(filenames, input)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := RenderFiles(&out, RenderOptions{}, filenames...); err != nil {
+		t.Fatalf("Could not render %v: %v", filenames, err)
+	}
+
+	if *update {
+		if err := ioutil.WriteFile(golden, out.Bytes(), 0644); err != nil {
+			t.Fatalf("Could not update %s: %v", golden, err)
+		}
+		return
+	}
+
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Could not read %s: %v", golden, err)
+	}
+	if !reflect.DeepEqual(decodeStream(t, expected), decodeStream(t, out.Bytes())) {
+		t.Fatalf("Rendering %v does not match %s (rerun with -update to accept):\n%s", filenames, golden, out.String())
+	}
+}
+
+// decodeStream decodes a YAML stream, so that the golden files need not match byte for byte
+func decodeStream(t *testing.T, data []byte) []interface{} {
+	t.Helper()
+
+	var retval []interface{}
+	decoder := kubeyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 512*1024)
+	for {
+		var obj interface{}
+		err := decoder.Decode(&obj)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Cannot parse: %v", err)
+		}
+		retval = append(retval, obj)
+	}
+	return retval
+}
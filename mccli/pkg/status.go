@@ -0,0 +1,365 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+)
+
+// StatusKinds are the kinds of generated objects whose presence the status reports
+var StatusKinds = []string{"Gateway", "VirtualService", "DestinationRule", "ServiceEntry"}
+
+// FederationStatus is the health of the federation of a namespace
+type FederationStatus struct {
+	Namespace          string              `json:"namespace" yaml:"namespace"`
+	MeshFedConfigs     []MeshFedConfigInfo `json:"mesh_fed_configs" yaml:"mesh_fed_configs"`
+	ServiceExpositions []ServiceInfo       `json:"service_expositions" yaml:"service_expositions"`
+	ServiceBindings    []ServiceInfo       `json:"service_bindings" yaml:"service_bindings"`
+}
+
+// MeshFedConfigInfo is the health of a MeshFedConfig
+type MeshFedConfigInfo struct {
+	Name           string         `json:"name" yaml:"name"`
+	Mode           string         `json:"mode" yaml:"mode"`
+	Ready          string         `json:"ready" yaml:"ready"`
+	Message        string         `json:"message,omitempty" yaml:"message,omitempty"`
+	IngressGateway *GatewayInfo   `json:"ingress_gateway,omitempty" yaml:"ingress_gateway,omitempty"`
+	EgressGateway  *GatewayInfo   `json:"egress_gateway,omitempty" yaml:"egress_gateway,omitempty"`
+	Objects        []ObjectStatus `json:"objects,omitempty" yaml:"objects,omitempty"`
+}
+
+// GatewayInfo is an ingress or egress gateway of a MeshFedConfig
+type GatewayInfo struct {
+	Selector map[string]string `json:"selector,omitempty" yaml:"selector,omitempty"`
+	Port     uint32            `json:"port,omitempty" yaml:"port,omitempty"`
+}
+
+// ServiceInfo is the health of a ServiceExposition or ServiceBinding
+type ServiceInfo struct {
+	Name           string         `json:"name" yaml:"name"`
+	Service        string         `json:"service" yaml:"service"`
+	MeshFedConfigs []string       `json:"mesh_fed_configs" yaml:"mesh_fed_configs"`
+	Ready          string         `json:"ready" yaml:"ready"`
+	Message        string         `json:"message,omitempty" yaml:"message,omitempty"`
+	Endpoints      []string       `json:"endpoints,omitempty" yaml:"endpoints,omitempty"`
+	Objects        []ObjectStatus `json:"objects,omitempty" yaml:"objects,omitempty"`
+}
+
+// ObjectStatus is an object generated for a MeshFedConfig, ServiceExposition or ServiceBinding
+type ObjectStatus struct {
+	APIVersion string `json:"api_version" yaml:"api_version"`
+	Kind       string `json:"kind" yaml:"kind"`
+	Namespace  string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name       string `json:"name" yaml:"name"`
+	Present    bool   `json:"present" yaml:"present"`
+	// Error is why the presence of the object is unknown, for example because its kind is not
+	// installed or may not be read
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// GetFederationStatus gathers the health of the MeshFedConfigs, ServiceExpositions and
+// ServiceBindings of a namespace, and looks up the objects their status says were generated
+func GetFederationStatus(cl client.Client, namespace string) (*FederationStatus, error) {
+	ctx := context.Background()
+	inNamespace := client.InNamespace(namespace)
+
+	var mfcs mmv1.MeshFedConfigList
+	if err := cl.List(ctx, &mfcs, inNamespace); err != nil {
+		return nil, err
+	}
+	var ses mmv1.ServiceExpositionList
+	if err := cl.List(ctx, &ses, inNamespace); err != nil {
+		return nil, err
+	}
+	var sbs mmv1.ServiceBindingList
+	if err := cl.List(ctx, &sbs, inNamespace); err != nil {
+		return nil, err
+	}
+
+	status := FederationStatus{
+		Namespace:          namespace,
+		MeshFedConfigs:     []MeshFedConfigInfo{},
+		ServiceExpositions: []ServiceInfo{},
+		ServiceBindings:    []ServiceInfo{},
+	}
+	for _, mfc := range mfcs.Items {
+		ready, message := readiness(mfc.Status.Conditions)
+		info := MeshFedConfigInfo{
+			Name:    mfc.GetName(),
+			Mode:    mfc.Spec.Mode,
+			Ready:   ready,
+			Message: message,
+		}
+		if mfc.Spec.UseIngressGateway {
+			info.IngressGateway = &GatewayInfo{Selector: mfc.Spec.IngressGatewaySelector, Port: mfc.Spec.IngressGatewayPort}
+		}
+		if mfc.Spec.UseEgressGateway {
+			info.EgressGateway = &GatewayInfo{Selector: mfc.Spec.EgressGatewaySelector, Port: mfc.Spec.EgressGatewayPort}
+		}
+		info.Objects = lookupObjects(ctx, cl, mfc.GetNamespace(), mfc.Status.GeneratedObjects)
+		status.MeshFedConfigs = append(status.MeshFedConfigs, info)
+	}
+	for _, se := range ses.Items {
+		info := serviceInfo(ctx, cl, mfcs.Items, se.GetName(), se.GetNamespace(), se.Spec.Name,
+			se.Spec.MeshFedConfigSelector, se.Spec.Endpoints, se.Status.Conditions, se.Status.GeneratedObjects)
+		status.ServiceExpositions = append(status.ServiceExpositions, info)
+	}
+	for _, sb := range sbs.Items {
+		info := serviceInfo(ctx, cl, mfcs.Items, sb.GetName(), sb.GetNamespace(), sb.Spec.Name,
+			sb.Spec.MeshFedConfigSelector, sb.Spec.Endpoints, sb.Status.Conditions, sb.Status.GeneratedObjects)
+		status.ServiceBindings = append(status.ServiceBindings, info)
+	}
+	return &status, nil
+}
+
+func serviceInfo(ctx context.Context, cl client.Client, mfcs []mmv1.MeshFedConfig, name, namespace, service string,
+	selector map[string]string, endpoints []string, conditions []mmv1.Condition, generated []mmv1.GeneratedObject) ServiceInfo {
+	ready, message := readiness(conditions)
+	return ServiceInfo{
+		Name:           name,
+		Service:        service,
+		MeshFedConfigs: selectedMeshFedConfigs(mfcs, selector),
+		Ready:          ready,
+		Message:        message,
+		Endpoints:      endpoints,
+		Objects:        lookupObjects(ctx, cl, namespace, generated),
+	}
+}
+
+// readiness returns the status and, unless it is true, the message of the Ready condition
+func readiness(conditions []mmv1.Condition) (string, string) {
+	c := mmv1.GetCondition(conditions, mmv1.ConditionReady)
+	if c == nil {
+		return string(corev1.ConditionUnknown), ""
+	}
+	if c.Status == corev1.ConditionTrue {
+		return string(c.Status), ""
+	}
+	return string(c.Status), c.Message
+}
+
+// selectedMeshFedConfigs returns the names of the MeshFedConfigs matching selector
+func selectedMeshFedConfigs(mfcs []mmv1.MeshFedConfig, selector map[string]string) []string {
+	names := []string{}
+	if len(selector) == 0 {
+		return names
+	}
+	s := labels.SelectorFromSet(selector)
+	for _, mfc := range mfcs {
+		if s.Matches(labels.Set(mfc.GetLabels())) {
+			names = append(names, mfc.GetName())
+		}
+	}
+	return names
+}
+
+// lookupObjects checks that the generated objects exist.  They are read as unstructured
+// objects, so that the kinds of every style can be looked up.  An object that cannot be read,
+// for example because its kind is not installed or reading it is forbidden, is reported with
+// the error rather than failing the whole status.
+func lookupObjects(ctx context.Context, cl client.Client, namespace string, generated []mmv1.GeneratedObject) []ObjectStatus {
+	var objects []ObjectStatus
+	for _, g := range generated {
+		o := ObjectStatus{
+			APIVersion: g.APIVersion,
+			Kind:       g.Kind,
+			Namespace:  g.Namespace,
+			Name:       g.Name,
+		}
+		if o.Namespace == "" {
+			o.Namespace = namespace
+		}
+		gv, err := schema.ParseGroupVersion(g.APIVersion)
+		if err != nil {
+			o.Error = fmt.Sprintf("invalid API version: %v", err)
+			objects = append(objects, o)
+			continue
+		}
+		var u unstructured.Unstructured
+		u.SetGroupVersionKind(gv.WithKind(g.Kind))
+		err = cl.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: o.Name}, &u)
+		switch {
+		case err == nil:
+			o.Present = true
+		case !apierrors.IsNotFound(err):
+			o.Error = err.Error()
+		}
+		objects = append(objects, o)
+	}
+	return objects
+}
+
+// WriteStatus writes status in format, which is table, json or yaml
+func WriteStatus(out io.Writer, status *FederationStatus, format string) error {
+	switch format {
+	case "", "table":
+		return writeStatusTable(out, status)
+	case "json":
+		data, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%s\n", data)
+		return err
+	case "yaml":
+		data, err := yaml.Marshal(status)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+	}
+	return fmt.Errorf("unknown output format %q, expected table, json or yaml", format)
+}
+
+func writeStatusTable(out io.Writer, status *FederationStatus) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "MESHFEDCONFIG\tMODE\tREADY\tINGRESS GATEWAY\tEGRESS GATEWAY\tOBJECTS\n")
+	for _, mfc := range status.MeshFedConfigs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", mfc.Name, orNone(mfc.Mode), mfc.Ready,
+			gatewayString(mfc.IngressGateway), gatewayString(mfc.EgressGateway), presentString(mfc.Objects))
+	}
+	fmt.Fprintf(w, "\n")
+	writeServiceTable(w, "EXPOSITION", status.ServiceExpositions)
+	fmt.Fprintf(w, "\n")
+	writeServiceTable(w, "BINDING", status.ServiceBindings)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// The reasons for not being ready are too long for the tables
+	for _, mfc := range status.MeshFedConfigs {
+		writeMessage(out, "MeshFedConfig", mfc.Name, mfc.Message)
+	}
+	for _, se := range status.ServiceExpositions {
+		writeMessage(out, "ServiceExposition", se.Name, se.Message)
+	}
+	for _, sb := range status.ServiceBindings {
+		writeMessage(out, "ServiceBinding", sb.Name, sb.Message)
+	}
+
+	// And so are the reasons the presence of objects is unknown
+	for _, mfc := range status.MeshFedConfigs {
+		writeObjectErrors(out, mfc.Objects)
+	}
+	for _, se := range status.ServiceExpositions {
+		writeObjectErrors(out, se.Objects)
+	}
+	for _, sb := range status.ServiceBindings {
+		writeObjectErrors(out, sb.Objects)
+	}
+	return nil
+}
+
+func writeServiceTable(w io.Writer, kind string, services []ServiceInfo) {
+	fmt.Fprintf(w, "%s\tSERVICE\tMESHFEDCONFIG\tREADY\tENDPOINTS", kind)
+	for _, k := range StatusKinds {
+		fmt.Fprintf(w, "\t%s", strings.ToUpper(k))
+	}
+	fmt.Fprintf(w, "\n")
+	for _, s := range services {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s", s.Name, s.Service, orNone(strings.Join(s.MeshFedConfigs, ",")),
+			s.Ready, orNone(strings.Join(s.Endpoints, ",")))
+		for _, k := range StatusKinds {
+			fmt.Fprintf(w, "\t%s", presentString(objectsOfKind(s.Objects, k)))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+func writeMessage(out io.Writer, kind, name, message string) {
+	if message != "" {
+		fmt.Fprintf(out, "%s %s: %s\n", kind, name, message)
+	}
+}
+
+func writeObjectErrors(out io.Writer, objects []ObjectStatus) {
+	for _, o := range objects {
+		writeMessage(out, o.Kind, o.Namespace+"/"+o.Name, o.Error)
+	}
+}
+
+func objectsOfKind(objects []ObjectStatus, kind string) []ObjectStatus {
+	var retval []ObjectStatus
+	for _, o := range objects {
+		if o.Kind == kind {
+			retval = append(retval, o)
+		}
+	}
+	return retval
+}
+
+// presentString is how many of objects are present, e.g. 1/2, and how many are unknown
+func presentString(objects []ObjectStatus) string {
+	if len(objects) == 0 {
+		return "-"
+	}
+	present, unknown := 0, 0
+	for _, o := range objects {
+		if o.Present {
+			present++
+		} else if o.Error != "" {
+			unknown++
+		}
+	}
+	if unknown > 0 {
+		return fmt.Sprintf("%d/%d (%d unknown)", present, len(objects), unknown)
+	}
+	return fmt.Sprintf("%d/%d", present, len(objects))
+}
+
+func gatewayString(gw *GatewayInfo) string {
+	if gw == nil {
+		return "-"
+	}
+	keys := make([]string, 0, len(gw.Selector))
+	for k := range gw.Selector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+gw.Selector[k])
+	}
+	retval := orNone(strings.Join(pairs, ","))
+	if gw.Port != 0 {
+		retval = fmt.Sprintf("%s:%d", retval, gw.Port)
+	}
+	return retval
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	istiov1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/pkg/validate"
+)
+
+// forbiddingClient forbids reading the objects of a kind
+type forbiddingClient struct {
+	client.Client
+	kind string
+}
+
+func (c forbiddingClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if gvk := obj.GetObjectKind().GroupVersionKind(); gvk.Kind == c.kind {
+		return apierrors.NewForbidden(schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind) + "s"}, key.Name, nil)
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestFederationStatus(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := validate.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+
+	mfc := &mmv1.MeshFedConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "boundary", Namespace: "test", Labels: map[string]string{"fed-config": "boundary"}},
+		Spec: mmv1.MeshFedConfigSpec{
+			Mode:                   "BOUNDARY",
+			UseIngressGateway:      true,
+			IngressGatewaySelector: map[string]string{"istio": "ingressgateway"},
+			IngressGatewayPort:     15443,
+		},
+	}
+	mmv1.SetCondition(&mfc.Status.Conditions, mmv1.ConditionReady, corev1.ConditionTrue, "Reconciled", "")
+	se := &mmv1.ServiceExposition{
+		ObjectMeta: metav1.ObjectMeta{Name: "helloworld", Namespace: "test"},
+		Spec: mmv1.ServiceExpositionSpec{
+			Name:                  "helloworld",
+			MeshFedConfigSelector: map[string]string{"fed-config": "boundary"},
+			Endpoints:             []string{"10.0.0.1:15443"},
+		},
+		Status: mmv1.ServiceExpositionStatus{
+			GeneratedObjects: []mmv1.GeneratedObject{
+				{APIVersion: "networking.istio.io/v1alpha3", Kind: "VirtualService", Name: "helloworld-vs"},
+				{APIVersion: "networking.istio.io/v1alpha3", Kind: "DestinationRule", Name: "helloworld-dr"},
+				// Reading TLSRoutes is forbidden
+				{APIVersion: "gateway.networking.k8s.io/v1alpha2", Kind: "TLSRoute", Name: "helloworld-route"},
+			},
+		},
+	}
+	mmv1.SetCondition(&se.Status.Conditions, mmv1.ConditionReady, corev1.ConditionFalse, "Missing", "helloworld-dr is missing")
+	vs := &istiov1alpha3.VirtualService{ObjectMeta: metav1.ObjectMeta{Name: "helloworld-vs", Namespace: "test"}}
+
+	cl := forbiddingClient{Client: fake.NewFakeClientWithScheme(scheme, mfc, se, vs), kind: "TLSRoute"}
+	status, err := GetFederationStatus(cl, "test")
+	if err != nil {
+		t.Fatalf("Could not get the status: %v", err)
+	}
+
+	expected := &FederationStatus{
+		Namespace: "test",
+		MeshFedConfigs: []MeshFedConfigInfo{{
+			Name:           "boundary",
+			Mode:           "BOUNDARY",
+			Ready:          "True",
+			IngressGateway: &GatewayInfo{Selector: map[string]string{"istio": "ingressgateway"}, Port: 15443},
+		}},
+		ServiceExpositions: []ServiceInfo{{
+			Name:           "helloworld",
+			Service:        "helloworld",
+			MeshFedConfigs: []string{"boundary"},
+			Ready:          "False",
+			Message:        "helloworld-dr is missing",
+			Endpoints:      []string{"10.0.0.1:15443"},
+			Objects: []ObjectStatus{
+				{APIVersion: "networking.istio.io/v1alpha3", Kind: "VirtualService", Namespace: "test", Name: "helloworld-vs", Present: true},
+				{APIVersion: "networking.istio.io/v1alpha3", Kind: "DestinationRule", Namespace: "test", Name: "helloworld-dr"},
+				{APIVersion: "gateway.networking.k8s.io/v1alpha2", Kind: "TLSRoute", Namespace: "test", Name: "helloworld-route"},
+			},
+		}},
+		ServiceBindings: []ServiceInfo{},
+	}
+	route := &status.ServiceExpositions[0].Objects[2]
+	if route.Present || route.Error == "" {
+		t.Fatalf("Expected the presence of an object that may not be read to be unknown, got %+v", route)
+	}
+	expected.ServiceExpositions[0].Objects[2].Error = route.Error
+	if !reflect.DeepEqual(status, expected) {
+		t.Fatalf("Expected status %+v, got %+v", expected, status)
+	}
+
+	var table bytes.Buffer
+	if err := WriteStatus(&table, status, "table"); err != nil {
+		t.Fatalf("Could not write the table: %v", err)
+	}
+	for _, s := range []string{"istio=ingressgateway:15443", "10.0.0.1:15443", "ServiceExposition helloworld: helloworld-dr is missing",
+		"TLSRoute test/helloworld-route: "} {
+		if !strings.Contains(table.String(), s) {
+			t.Errorf("Expected %q in the table:\n%s", s, table.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := WriteStatus(&out, status, "json"); err != nil {
+		t.Fatalf("Could not write JSON: %v", err)
+	}
+	var decoded FederationStatus
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("Could not parse the JSON status: %v\n%s", err, out.String())
+	}
+	if !reflect.DeepEqual(&decoded, expected) {
+		t.Fatalf("JSON status does not round trip: %s", out.String())
+	}
+
+	if err := WriteStatus(&out, status, "xml"); err == nil {
+		t.Fatalf("Expected an error for an unknown format")
+	}
+}
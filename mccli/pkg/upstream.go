@@ -0,0 +1,280 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+)
+
+// The annotations of a ServiceExposition referencing the OpenAPI spec of the exposed service.
+// The spec describes the first HTTP port of the service; its paths replace the catch-all path
+// of that port.
+const (
+	// OpenAPIAnnotation holds the spec itself, as YAML or JSON
+	OpenAPIAnnotation = "mm.ibm.istio.io/openapi"
+	// OpenAPIConfigMapAnnotation names a ConfigMap holding the spec, as <name> or <name>/<key>.
+	// The key defaults to openapi.yaml or openapi.json.
+	OpenAPIConfigMapAnnotation = "mm.ibm.istio.io/openapi-configmap"
+	// OpenAPIPathAnnotation is the path the service serves its spec on, e.g. /openapi.json
+	OpenAPIPathAnnotation = "mm.ibm.istio.io/openapi-path"
+)
+
+// defaultOpenAPIKeys are the ConfigMap keys looked up for the spec
+var defaultOpenAPIKeys = []string{"openapi.yaml", "openapi.json"}
+
+// ServiceProxy GETs a path from a port of the service namespace/name
+type ServiceProxy func(ctx context.Context, namespace, name string, port uint32, path string) ([]byte, error)
+
+// NewServiceProxy creates a ServiceProxy going through the proxy of the Kubernetes API server,
+// so that mccli can reach services from outside the cluster
+func NewServiceProxy(restConfig *rest.Config) (ServiceProxy, error) {
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, namespace, name string, port uint32, path string) ([]byte, error) {
+		return clientset.CoreV1().Services(namespace).ProxyGet("http", name, strconv.Itoa(int(port)), path, nil).DoRaw(ctx)
+	}, nil
+}
+
+// openAPIPort is the port the spec of an exposed service describes
+func openAPIPort(se *mmv1.ServiceExposition) (mmv1.ServicePort, bool) {
+	for _, p := range se.Spec.ServicePorts() {
+		if p.Protocol.IsHTTP() {
+			return p, true
+		}
+	}
+	return mmv1.ServicePort{}, false
+}
+
+// getUpstreamSpec returns the OpenAPI spec an exposition references, or nil if it has none
+func getUpstreamSpec(ctx context.Context, cl client.Client, opts ConvertOptions, se *mmv1.ServiceExposition) (*OpenAPI, error) {
+	data, err := fetchUpstreamSpec(ctx, cl, opts, se)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return parseOpenAPI(data)
+}
+
+func fetchUpstreamSpec(ctx context.Context, cl client.Client, opts ConvertOptions, se *mmv1.ServiceExposition) ([]byte, error) {
+	annotations := se.GetAnnotations()
+	if spec, ok := annotations[OpenAPIAnnotation]; ok {
+		return []byte(spec), nil
+	}
+
+	if ref, ok := annotations[OpenAPIConfigMapAnnotation]; ok {
+		name, keys := ref, defaultOpenAPIKeys
+		if i := strings.Index(ref, "/"); i >= 0 {
+			name, keys = ref[:i], []string{ref[i+1:]}
+		}
+		var cm corev1.ConfigMap
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: se.GetNamespace(), Name: name}, &cm); err != nil {
+			return nil, fmt.Errorf("could not get ConfigMap %s/%s: %v", se.GetNamespace(), name, err)
+		}
+		for _, key := range keys {
+			if spec, ok := cm.Data[key]; ok {
+				return []byte(spec), nil
+			}
+		}
+		return nil, fmt.Errorf("ConfigMap %s/%s has no key %s", se.GetNamespace(), name, strings.Join(keys, " or "))
+	}
+
+	if path, ok := annotations[OpenAPIPathAnnotation]; ok {
+		if opts.ServiceProxy == nil {
+			return nil, fmt.Errorf("cannot fetch %s without a connection to the cluster", path)
+		}
+		port, ok := openAPIPort(se)
+		if !ok {
+			return nil, fmt.Errorf("service %q exposes no HTTP port to fetch %s from", se.Spec.Name, path)
+		}
+		data, err := opts.ServiceProxy(ctx, se.GetNamespace(), se.Spec.Name, port.Number, path)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch %s from service %s/%s: %v", path, se.GetNamespace(), se.Spec.Name, err)
+		}
+		return data, nil
+	}
+
+	return nil, nil
+}
+
+// parseOpenAPI parses an OpenAPI 3 spec in YAML or JSON
+func parseOpenAPI(data []byte) (*OpenAPI, error) {
+	jsonData, err := kubeyaml.ToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var spec OpenAPI
+	if err := json.Unmarshal(jsonData, &spec); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("not an OpenAPI 3 spec: openapi is %q", spec.OpenAPI)
+	}
+	return &spec, nil
+}
+
+// mergeUpstream adds the paths of the spec of an exposed service to retval.  The paths are
+// moved under prefix, where the ingress routes the service.  Their operations are tagged with
+// tag, and their operation IDs and components are prefixed so that they are unique.
+func mergeUpstream(retval *OpenAPI, spec *OpenAPI, prefix string, tag string, servers []Server) error {
+	idPrefix := strings.Replace(tag, "/", "-", -1) + "-"
+	componentPrefix := strings.Replace(tag, "/", ".", -1) + "."
+	rename := func(ref string) string {
+		// Only local references to components are renamed
+		parts := strings.SplitN(ref, "/", 4)
+		if len(parts) != 4 || parts[0] != "#" || parts[1] != "components" {
+			return ref
+		}
+		return strings.Join([]string{parts[0], parts[1], parts[2], componentPrefix + parts[3]}, "/")
+	}
+
+	// The ingress rewrites the prefix to /, so the paths of the spec, which are relative to
+	// the path of its server URL, follow the prefix
+	base := ""
+	if len(spec.Servers) > 0 {
+		if u, err := url.Parse(spec.Servers[0].URL); err == nil {
+			base = strings.TrimSuffix(u.Path, "/")
+		}
+	}
+
+	paths := make(map[string]*PathItem)
+	for p, item := range spec.Paths {
+		if item == nil {
+			continue
+		}
+		path := strings.TrimSuffix(prefix, "/") + base + p
+		if _, ok := retval.Paths[path]; ok {
+			return fmt.Errorf("path %s is already in the catalogue", path)
+		}
+		paths[path] = item
+	}
+
+	for path, item := range paths {
+		item.Servers = servers
+		renameParameters(item.Parameters, rename)
+		for _, op := range item.operations() {
+			op.Tags = []string{tag}
+			if op.OperationID != "" {
+				op.OperationID = idPrefix + op.OperationID
+			}
+			renameParameters(op.Parameters, rename)
+			renameRequestBody(op.RequestBody, rename)
+			for _, resp := range op.Responses {
+				renameResponse(resp, rename)
+			}
+		}
+		retval.Paths[path] = item
+	}
+
+	if spec.Components == nil {
+		return nil
+	}
+	if retval.Components == nil {
+		retval.Components = &Components{}
+	}
+	for name, schema := range spec.Components.Schemas {
+		if retval.Components.Schemas == nil {
+			retval.Components.Schemas = map[string]Schema{}
+		}
+		renameSchema(schema, rename)
+		retval.Components.Schemas[componentPrefix+name] = schema
+	}
+	for name, resp := range spec.Components.Responses {
+		if retval.Components.Responses == nil {
+			retval.Components.Responses = map[string]*Response{}
+		}
+		renameResponse(resp, rename)
+		retval.Components.Responses[componentPrefix+name] = resp
+	}
+	for name, param := range spec.Components.Parameters {
+		if retval.Components.Parameters == nil {
+			retval.Components.Parameters = map[string]*Parameter{}
+		}
+		if param != nil {
+			param.Ref = rename(param.Ref)
+			renameSchema(param.Schema, rename)
+		}
+		retval.Components.Parameters[componentPrefix+name] = param
+	}
+	for name, body := range spec.Components.RequestBodies {
+		if retval.Components.RequestBodies == nil {
+			retval.Components.RequestBodies = map[string]*RequestBody{}
+		}
+		renameRequestBody(body, rename)
+		retval.Components.RequestBodies[componentPrefix+name] = body
+	}
+	return nil
+}
+
+func renameParameters(params []Parameter, rename func(string) string) {
+	for i := range params {
+		params[i].Ref = rename(params[i].Ref)
+		renameSchema(params[i].Schema, rename)
+	}
+}
+
+func renameRequestBody(body *RequestBody, rename func(string) string) {
+	if body == nil {
+		return
+	}
+	body.Ref = rename(body.Ref)
+	for _, mt := range body.Content {
+		renameSchema(mt.Schema, rename)
+	}
+}
+
+func renameResponse(resp *Response, rename func(string) string) {
+	if resp == nil {
+		return
+	}
+	resp.Ref = rename(resp.Ref)
+	for _, mt := range resp.Content {
+		renameSchema(mt.Schema, rename)
+	}
+}
+
+// renameSchema renames the references of a schema and of the schemas it nests
+func renameSchema(v interface{}, rename func(string) string) {
+	switch val := v.(type) {
+	case Schema:
+		renameSchema(map[string]interface{}(val), rename)
+	case map[string]interface{}:
+		for k, nested := range val {
+			if ref, ok := nested.(string); ok && k == "$ref" {
+				val[k] = rename(ref)
+			} else {
+				renameSchema(nested, rename)
+			}
+		}
+	case []interface{}:
+		for _, nested := range val {
+			renameSchema(nested, rename)
+		}
+	}
+}
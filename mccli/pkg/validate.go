@@ -16,13 +16,18 @@
 package pkg
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 
 	multierror "github.com/hashicorp/go-multierror"
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
 	"github.com/istio-ecosystem/emcee/pkg/validate"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // ValidateFile validates a .yaml file of Emcee CRs
@@ -61,6 +66,47 @@ func ValidateFile(filename string) error {
 	return retval
 }
 
+// ValidateBundle validates a .yaml file of Emcee CRs against the MeshFedConfigs, Services and
+// DestinationRules of the same file
+func ValidateBundle(filename string) error {
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint: errcheck
+
+	return validate.Bundle(context.Background(), in)
+}
+
+// ValidateFileInCluster validates a .yaml file of Emcee CRs against the resources of the cluster
+// cl reads, as if the file was applied to it
+func ValidateFileInCluster(cl client.Client, filename string) error {
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint: errcheck
+
+	scheme := runtime.NewScheme()
+	if err := validate.AddToScheme(scheme); err != nil {
+		return err
+	}
+	objs, err := validate.Decode(scheme, in)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	c := validate.Cluster{Client: cl}
+	var retval error
+	for _, obj := range objs {
+		if err := c.Validate(ctx, obj); err != nil {
+			retval = multierror.Append(retval, err)
+		}
+	}
+	return retval
+}
+
 func convertObjectSpec(obj KubeKind) (interface{}, error) {
 	var retval interface{}
 	switch obj.TypeMeta.Kind {
@@ -23,6 +23,7 @@ import (
 
 type testcase struct {
 	filename       string
+	bundle         bool
 	expectedRegexp *regexp.Regexp
 }
 
@@ -36,6 +37,10 @@ func TestValidation(t *testing.T) {
 			filename:       "test/samples/invalid-mfc-egress.yaml",
 			expectedRegexp: regexp.MustCompile("does not specify egress, but selects one"),
 		},
+		{
+			filename:       "test/samples/invalid-mfc-gateway-api.yaml",
+			expectedRegexp: regexp.MustCompile("does not use an egress gateway(.|\n)*deploys its own ingress, but selects one"),
+		},
 		{
 			filename:       "test/samples/invalid-expose.yaml",
 			expectedRegexp: regexp.MustCompile("requires mesh_fed_config_selector"),
@@ -44,6 +49,10 @@ func TestValidation(t *testing.T) {
 			filename:       "test/samples/invalid-bind.yaml",
 			expectedRegexp: regexp.MustCompile("helloworld: invalid alias"),
 		},
+		{
+			filename:       "test/samples/invalid-ports.yaml",
+			expectedRegexp: regexp.MustCompile("duplicate port name \"http\"(.|\n)*unknown protocol \"UDP\""),
+		},
 		{
 			filename: "samples/limited-trust/limited-trust-c1.yaml",
 		},
@@ -53,6 +62,28 @@ func TestValidation(t *testing.T) {
 		{
 			filename: "samples/limited-trust/helloworld-binding.yaml",
 		},
+		{
+			filename: "samples/gateway-api/gateway-api-c1.yaml",
+		},
+		{
+			filename: "samples/gateway-api/helloworld-expose.yaml",
+		},
+		{
+			filename: "samples/gateway-api/helloworld-binding.yaml",
+		},
+		{
+			filename: "test/samples/bundle.yaml",
+			bundle:   true,
+		},
+		{
+			filename: "test/samples/invalid-bundle.yaml",
+			bundle:   true,
+			expectedRegexp: regexp.MustCompile("matches 2 MeshFedConfigs: limited-trust/c1, limited-trust/c2(.|\n)*" +
+				"does not expose port 8080(.|\n)*subset \"v2\" of helloworld is not defined(.|\n)*" +
+				"matches no MeshFedConfig(.|\n)*Service default/goodbyeworld not found(.|\n)*" +
+				"\"helloworld\" collides with Service default/helloworld(.|\n)*" +
+				"endpoint \"c2.example.com:15443\" is not an IPv4 or IPv6 address(.|\n)*invalid endpoint \"192.0.2.1\""),
+		},
 	}
 
 	for i, c := range cases {
@@ -71,7 +102,12 @@ func verifyValidation(t *testing.T, c testcase) {
 		t.Fatalf("Could not load test file %s", filename)
 	}
 
-	fErr := ValidateFile(filename)
+	var fErr error
+	if c.bundle {
+		fErr = ValidateBundle(filename)
+	} else {
+		fErr = ValidateFile(filename)
+	}
 
 	if c.expectedRegexp != nil {
 		if fErr == nil {
@@ -24,11 +24,24 @@ const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 // The response message containing the greetings
 type ExposedServicesMessages struct {
-	Name                 string                                    `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	ExposedServices      []*ExposedServicesMessages_ExposedService `protobuf:"bytes,2,rep,name=ExposedServices,proto3" json:"ExposedServices,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                                  `json:"-"`
-	XXX_unrecognized     []byte                                    `json:"-"`
-	XXX_sizecache        int32                                     `json:"-"`
+	Name            string                                    `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	ExposedServices []*ExposedServicesMessages_ExposedService `protobuf:"bytes,2,rep,name=ExposedServices,proto3" json:"ExposedServices,omitempty"`
+	// The name of the requesting cluster, used to select the services it may see
+	// when the connection carries no verified certificate identity
+	Cluster string `protobuf:"bytes,3,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	// xDS style versioning.  A response carries the version of the state it describes and a
+	// nonce.  A request ACKs a response by echoing its nonce and version, or NACKs it by echoing
+	// its nonce with the last applied version and an error detail.
+	VersionInfo string `protobuf:"bytes,4,opt,name=versionInfo,proto3" json:"versionInfo,omitempty"`
+	Nonce       string `protobuf:"bytes,5,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	ErrorDetail string `protobuf:"bytes,6,opt,name=errorDetail,proto3" json:"errorDetail,omitempty"`
+	// In a request, asks for incremental responses.  In a response, ExposedServices holds only
+	// the added or changed services and removedServices the names of the removed ones.
+	Delta                bool     `protobuf:"varint,7,opt,name=delta,proto3" json:"delta,omitempty"`
+	RemovedServices      []string `protobuf:"bytes,8,rep,name=removedServices,proto3" json:"removedServices,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *ExposedServicesMessages) Reset()         { *m = ExposedServicesMessages{} }
@@ -70,14 +83,62 @@ func (m *ExposedServicesMessages) GetExposedServices() []*ExposedServicesMessage
 	return nil
 }
 
+func (m *ExposedServicesMessages) GetCluster() string {
+	if m != nil {
+		return m.Cluster
+	}
+	return ""
+}
+
+func (m *ExposedServicesMessages) GetVersionInfo() string {
+	if m != nil {
+		return m.VersionInfo
+	}
+	return ""
+}
+
+func (m *ExposedServicesMessages) GetNonce() string {
+	if m != nil {
+		return m.Nonce
+	}
+	return ""
+}
+
+func (m *ExposedServicesMessages) GetErrorDetail() string {
+	if m != nil {
+		return m.ErrorDetail
+	}
+	return ""
+}
+
+func (m *ExposedServicesMessages) GetDelta() bool {
+	if m != nil {
+		return m.Delta
+	}
+	return false
+}
+
+func (m *ExposedServicesMessages) GetRemovedServices() []string {
+	if m != nil {
+		return m.RemovedServices
+	}
+	return nil
+}
+
 type ExposedServicesMessages_ExposedService struct {
 	Name                  string            `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Port                  uint32            `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
 	MeshFedConfigSelector map[string]string `protobuf:"bytes,3,rep,name=meshFedConfigSelector,proto3" json:"meshFedConfigSelector,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	Endpoints             []string          `protobuf:"bytes,4,rep,name=endpoints,proto3" json:"endpoints,omitempty"`
-	XXX_NoUnkeyedLiteral  struct{}          `json:"-"`
-	XXX_unrecognized      []byte            `json:"-"`
-	XXX_sizecache         int32             `json:"-"`
+	Ports                 []*ServicePort    `protobuf:"bytes,5,rep,name=ports,proto3" json:"ports,omitempty"`
+	// Where the endpoints are: the region/zone/subzone, Istio network and cluster ID of the
+	// exposing mesh's ingress
+	Locality             string   `protobuf:"bytes,6,opt,name=locality,proto3" json:"locality,omitempty"`
+	Network              string   `protobuf:"bytes,7,opt,name=network,proto3" json:"network,omitempty"`
+	ClusterID            string   `protobuf:"bytes,8,opt,name=clusterID,proto3" json:"clusterID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *ExposedServicesMessages_ExposedService) Reset() {
@@ -135,34 +196,139 @@ func (m *ExposedServicesMessages_ExposedService) GetEndpoints() []string {
 	return nil
 }
 
+func (m *ExposedServicesMessages_ExposedService) GetPorts() []*ServicePort {
+	if m != nil {
+		return m.Ports
+	}
+	return nil
+}
+
+func (m *ExposedServicesMessages_ExposedService) GetLocality() string {
+	if m != nil {
+		return m.Locality
+	}
+	return ""
+}
+
+func (m *ExposedServicesMessages_ExposedService) GetNetwork() string {
+	if m != nil {
+		return m.Network
+	}
+	return ""
+}
+
+func (m *ExposedServicesMessages_ExposedService) GetClusterID() string {
+	if m != nil {
+		return m.ClusterID
+	}
+	return ""
+}
+
+// A named port of an exposed service
+type ServicePort struct {
+	Name       string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Number     uint32 `protobuf:"varint,2,opt,name=number,proto3" json:"number,omitempty"`
+	TargetPort uint32 `protobuf:"varint,3,opt,name=targetPort,proto3" json:"targetPort,omitempty"`
+	// HTTP, HTTP2, GRPC, TCP or TLS
+	Protocol             string   `protobuf:"bytes,4,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ServicePort) Reset()         { *m = ServicePort{} }
+func (m *ServicePort) String() string { return proto.CompactTextString(m) }
+func (*ServicePort) ProtoMessage()    {}
+func (*ServicePort) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e7ff60feb39c8d0, []int{1}
+}
+
+func (m *ServicePort) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ServicePort.Unmarshal(m, b)
+}
+func (m *ServicePort) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ServicePort.Marshal(b, m, deterministic)
+}
+func (m *ServicePort) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ServicePort.Merge(m, src)
+}
+func (m *ServicePort) XXX_Size() int {
+	return xxx_messageInfo_ServicePort.Size(m)
+}
+func (m *ServicePort) XXX_DiscardUnknown() {
+	xxx_messageInfo_ServicePort.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ServicePort proto.InternalMessageInfo
+
+func (m *ServicePort) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ServicePort) GetNumber() uint32 {
+	if m != nil {
+		return m.Number
+	}
+	return 0
+}
+
+func (m *ServicePort) GetTargetPort() uint32 {
+	if m != nil {
+		return m.TargetPort
+	}
+	return 0
+}
+
+func (m *ServicePort) GetProtocol() string {
+	if m != nil {
+		return m.Protocol
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*ExposedServicesMessages)(nil), "pb.ExposedServicesMessages")
 	proto.RegisterType((*ExposedServicesMessages_ExposedService)(nil), "pb.ExposedServicesMessages.ExposedService")
 	proto.RegisterMapType((map[string]string)(nil), "pb.ExposedServicesMessages.ExposedService.MeshFedConfigSelectorEntry")
+	proto.RegisterType((*ServicePort)(nil), "pb.ServicePort")
 }
 
 func init() { proto.RegisterFile("discovery.proto", fileDescriptor_1e7ff60feb39c8d0) }
 
 var fileDescriptor_1e7ff60feb39c8d0 = []byte{
-	// 277 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x92, 0x41, 0x4b, 0xc4, 0x30,
-	0x10, 0x85, 0x4d, 0x5b, 0x85, 0x8e, 0xe8, 0xca, 0xa0, 0x18, 0xaa, 0x87, 0xb2, 0xa7, 0xe2, 0xa1,
-	0xc8, 0x7a, 0x11, 0xaf, 0x6e, 0xc5, 0xcb, 0x5e, 0x52, 0x4f, 0xde, 0xb6, 0xed, 0xb8, 0x16, 0x77,
-	0x9b, 0x90, 0xc4, 0x62, 0xf1, 0xb7, 0x78, 0xf2, 0x8f, 0x8a, 0x51, 0x11, 0x4b, 0x77, 0xf1, 0xf6,
-	0xf2, 0x12, 0xde, 0xbc, 0x8f, 0x0c, 0x8c, 0xaa, 0xda, 0x94, 0xb2, 0x25, 0xdd, 0xa5, 0x4a, 0x4b,
-	0x2b, 0xd1, 0x53, 0xc5, 0xf8, 0xdd, 0x87, 0xe3, 0xec, 0x45, 0x49, 0x43, 0x55, 0x4e, 0xba, 0xad,
-	0x4b, 0x32, 0x33, 0x32, 0x66, 0xbe, 0x20, 0x83, 0x08, 0x41, 0x33, 0x5f, 0x11, 0x67, 0x31, 0x4b,
-	0x42, 0xe1, 0x34, 0xde, 0xc1, 0xa8, 0xf7, 0x9c, 0x7b, 0xb1, 0x9f, 0xec, 0x4e, 0xce, 0x52, 0x55,
-	0xa4, 0x6b, 0x92, 0x7a, 0xbe, 0xe8, 0x47, 0x44, 0x6f, 0x1e, 0xec, 0xff, 0xf5, 0x06, 0x87, 0x23,
-	0x04, 0x4a, 0x6a, 0xcb, 0xbd, 0x98, 0x25, 0x7b, 0xc2, 0x69, 0x7c, 0x85, 0xa3, 0x15, 0x99, 0xc7,
-	0x1b, 0xaa, 0xae, 0x65, 0xf3, 0x50, 0x2f, 0x72, 0x5a, 0x52, 0x69, 0xa5, 0xe6, 0xbe, 0xab, 0x95,
-	0xfd, 0xbf, 0x56, 0x3a, 0x1b, 0xca, 0xc9, 0x1a, 0xab, 0x3b, 0x31, 0x3c, 0x03, 0x4f, 0x21, 0xa4,
-	0xa6, 0x52, 0xb2, 0x6e, 0xac, 0xe1, 0x41, 0xec, 0x27, 0xa1, 0xf8, 0x35, 0xa2, 0x5b, 0x88, 0xd6,
-	0x47, 0xe2, 0x01, 0xf8, 0x4f, 0xd4, 0x7d, 0xf3, 0x7d, 0x4a, 0x3c, 0x84, 0xed, 0x76, 0xbe, 0x7c,
-	0x26, 0xc7, 0x17, 0x8a, 0xaf, 0xc3, 0x95, 0x77, 0xc9, 0x26, 0x05, 0x04, 0x59, 0x3e, 0xcd, 0xf1,
-	0x1e, 0x78, 0x8f, 0x65, 0xfa, 0xf3, 0xa7, 0x78, 0xb2, 0x81, 0x34, 0xda, 0x74, 0x39, 0xde, 0x4a,
-	0xd8, 0x39, 0x2b, 0x76, 0xdc, 0x52, 0x5c, 0x7c, 0x04, 0x00, 0x00, 0xff, 0xff, 0x35, 0x73, 0x45,
-	0xea, 0x27, 0x02, 0x00, 0x00,
+	// 458 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0x4d, 0x8f, 0xd3, 0x30,
+	0x10, 0x25, 0x4d, 0xd2, 0x6d, 0xa6, 0x5a, 0x8a, 0x2c, 0x3e, 0xac, 0x80, 0x50, 0x54, 0x09, 0x29,
+	0xe2, 0x50, 0xa1, 0xe5, 0x82, 0xb8, 0xd2, 0x22, 0xf6, 0xb0, 0x12, 0x72, 0x39, 0x71, 0xcb, 0xc7,
+	0x6c, 0x89, 0xd6, 0xb5, 0x23, 0xdb, 0x0d, 0x54, 0xfc, 0x11, 0x7e, 0x17, 0xbf, 0x08, 0xd9, 0x49,
+	0x77, 0x43, 0xd4, 0xad, 0xf6, 0xe6, 0xf7, 0x26, 0xe3, 0x99, 0xf7, 0x5e, 0x0c, 0xb3, 0xb2, 0xd2,
+	0x85, 0x6c, 0x50, 0xed, 0x17, 0xb5, 0x92, 0x46, 0x92, 0x51, 0x9d, 0xcf, 0xff, 0x86, 0xf0, 0x62,
+	0xf5, 0xab, 0x96, 0x1a, 0xcb, 0x35, 0xaa, 0xa6, 0x2a, 0x50, 0x5f, 0xa1, 0xd6, 0xd9, 0x06, 0x35,
+	0x21, 0x10, 0x88, 0x6c, 0x8b, 0xd4, 0x4b, 0xbc, 0x34, 0x62, 0xee, 0x4c, 0xbe, 0xc1, 0x6c, 0xf0,
+	0x39, 0x1d, 0x25, 0x7e, 0x3a, 0xbd, 0x78, 0xbb, 0xa8, 0xf3, 0xc5, 0x3d, 0x37, 0x0d, 0x78, 0x36,
+	0xbc, 0x82, 0x50, 0x38, 0x2b, 0xf8, 0x4e, 0x1b, 0x54, 0xd4, 0x77, 0xc3, 0x0e, 0x90, 0x24, 0x30,
+	0x6d, 0x50, 0xe9, 0x4a, 0x8a, 0x4b, 0x71, 0x2d, 0x69, 0xe0, 0xaa, 0x7d, 0x8a, 0x3c, 0x85, 0x50,
+	0x48, 0x51, 0x20, 0x0d, 0x5d, 0xad, 0x05, 0xb6, 0x0f, 0x95, 0x92, 0x6a, 0x89, 0x26, 0xab, 0x38,
+	0x1d, 0xb7, 0x7d, 0x3d, 0xca, 0xf6, 0x95, 0xc8, 0x4d, 0x46, 0xcf, 0x12, 0x2f, 0x9d, 0xb0, 0x16,
+	0x90, 0x14, 0x66, 0x0a, 0xb7, 0xb2, 0xe9, 0xe9, 0x9b, 0x24, 0x7e, 0x1a, 0xb1, 0x21, 0x1d, 0xff,
+	0xf1, 0xe1, 0xf1, 0xff, 0x3a, 0x8e, 0x1a, 0x46, 0x20, 0xa8, 0xa5, 0x32, 0x74, 0x94, 0x78, 0xe9,
+	0x39, 0x73, 0x67, 0xf2, 0x1b, 0x9e, 0x6d, 0x51, 0xff, 0xf8, 0x8c, 0xe5, 0x27, 0x29, 0xae, 0xab,
+	0xcd, 0x1a, 0x39, 0x16, 0x46, 0x5a, 0xf1, 0xd6, 0xca, 0xd5, 0xc3, 0xad, 0x5c, 0x5c, 0x1d, 0xbb,
+	0x67, 0x25, 0x8c, 0xda, 0xb3, 0xe3, 0x33, 0xc8, 0x2b, 0x88, 0x50, 0x94, 0xb5, 0xac, 0x84, 0xd1,
+	0x34, 0x70, 0xda, 0xee, 0x08, 0xf2, 0x06, 0x42, 0xbb, 0xa2, 0xa6, 0xa1, 0x5b, 0x65, 0x66, 0x57,
+	0xe9, 0x66, 0x7d, 0x95, 0xca, 0xb0, 0xb6, 0x4a, 0x62, 0x98, 0x70, 0x59, 0x64, 0xbc, 0x32, 0xfb,
+	0xce, 0xdb, 0x5b, 0x6c, 0xc3, 0x14, 0x68, 0x7e, 0x4a, 0x75, 0xe3, 0xac, 0x8d, 0xd8, 0x01, 0xda,
+	0xd1, 0x5d, 0xae, 0x97, 0x4b, 0x3a, 0x71, 0xb5, 0x3b, 0x22, 0xfe, 0x02, 0xf1, 0xfd, 0x6a, 0xc8,
+	0x13, 0xf0, 0x6f, 0x70, 0xdf, 0x59, 0x6b, 0x8f, 0x36, 0xc0, 0x26, 0xe3, 0x3b, 0x74, 0xd6, 0x46,
+	0xac, 0x05, 0x1f, 0x47, 0x1f, 0xbc, 0xf9, 0x0e, 0xa6, 0xbd, 0x9d, 0x8f, 0xc6, 0xf2, 0x1c, 0xc6,
+	0x62, 0xb7, 0xcd, 0x51, 0x75, 0xc1, 0x74, 0x88, 0xbc, 0x06, 0x30, 0x99, 0xda, 0xa0, 0xb1, 0x9d,
+	0xee, 0x67, 0x3c, 0x67, 0x3d, 0xc6, 0x0a, 0x77, 0x8f, 0xa7, 0x90, 0xbc, 0xfb, 0x19, 0x6f, 0xf1,
+	0x45, 0x0e, 0xc1, 0x6a, 0xbd, 0x5c, 0x93, 0xef, 0x40, 0x07, 0xe9, 0x2d, 0x0f, 0x2f, 0x8f, 0xbc,
+	0x3c, 0x91, 0x6d, 0x7c, 0xaa, 0x38, 0x7f, 0x94, 0x7a, 0xef, 0xbc, 0x7c, 0xec, 0xa6, 0xbd, 0xff,
+	0x37, 0x00, 0xb4, 0x84, 0x94, 0x7e, 0xcd, 0x03, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
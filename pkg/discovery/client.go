@@ -21,59 +21,102 @@ package discovery
 import (
 	"context"
 	"errors"
-	"io"
 	"strings"
-	"time"
 
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
 	"github.com/istio-ecosystem/emcee/controllers"
 	pb "github.com/istio-ecosystem/emcee/pkg/discovery/api"
+	"github.com/istio-ecosystem/emcee/pkg/metrics"
 	"google.golang.org/grpc"
 	"istio.io/pkg/log"
-	k8sapi "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
 	defaultName = "Server"
+)
 
-	connTimeoutSeconds = 10
-	connMonitorSeconds = 3
+var discoveryServices map[string]*discoveryClient
 
-	clientSched     = 1
-	clientTimedout  = 2
-	clientCanceled  = 3
-	clientConnected = 4
-)
+// clusterName is sent to remote servers that select exposed services by cluster, and is the
+// default cluster ID of the services exposed to remote clients
+var clusterName string
 
-type discoveryClient struct {
-	name               string
-	address            string
-	waitChan           chan struct{}
-	cancel             context.CancelFunc
-	status             int
-	discoveredServices map[string]int
+// SetClusterName names this cluster.  Call it before starting the server and the clients.
+func SetClusterName(name string) {
+	clusterName = name
 }
 
-var discoveryServices map[string]*discoveryClient
-
 const (
-	DEFAULT_NAMESPACE = "default" // TODO  generalize
-	CLEAR             = 0
-	CREATED           = 1
+	DEFAULT_NAMESPACE = "default" // The namespace of remote services named without one
+
+	// importedFromAnnotation names the DiscoveryPeer, as namespace/name, that imported a ServiceBinding
+	importedFromAnnotation = "mm.ibm.istio.io/imported-from"
+	// remoteServiceAnnotation is the remote namespace/name of an imported ServiceBinding
+	remoteServiceAnnotation = "mm.ibm.istio.io/remote-service"
 )
 
-func newServiceBinding(in *pb.ExposedServicesMessages_ExposedService) *mmv1.ServiceBinding {
-	var newName, newNamespace string
-	s := strings.Split(in.Name, "/")
+// remoteName returns the namespace and name of a remote service named namespace/name
+func remoteName(remote string) (string, string) {
+	s := strings.Split(remote, "/")
 	if len(s) == 2 {
-		newNamespace = s[0]
-		newName = s[1]
-	} else {
-		newNamespace = DEFAULT_NAMESPACE
-		newName = s[0]
+		return s[0], s[1]
+	}
+	return DEFAULT_NAMESPACE, s[0]
+}
+
+// bindingName returns the local namespace and name of the binding for a remote service,
+// applying the namespace mapping of the peer and then of the MeshFedConfigs with rules
+func bindingName(remote string, peer *mmv1.DiscoveryPeer, mfcRules []mmv1.NamespaceMappingRule) (string, string) {
+	newNamespace, newName := remoteName(remote)
+	var peerName string
+	var peerRules []mmv1.NamespaceMappingRule
+	if peer != nil {
+		peerName = peer.GetName()
+		peerRules = peer.Spec.NamespaceMapping
+	}
+	return mapNamespace(newNamespace, peerName, peerRules, mfcRules), newName
+}
+
+func newServiceBinding(in *pb.ExposedServicesMessages_ExposedService, disc *discoveryClient,
+	mfcs []mmv1.MeshFedConfig) *mmv1.ServiceBinding {
+	peer := disc.peer
+	mfcSelector := in.MeshFedConfigSelector
+	if peer != nil && len(peer.Spec.MeshFedConfigSelector) > 0 {
+		mfcSelector = peer.Spec.MeshFedConfigSelector
+	}
+	newNamespace, newName := bindingName(in.Name, peer, meshFedConfigRules(mfcs, mfcSelector))
+	// The styles reach the service by its name in the exposing mesh
+	remoteNamespace, _ := remoteName(in.Name)
+
+	var port uint32
+	var ports []mmv1.ServicePort
+	for _, p := range in.GetPorts() {
+		ports = append(ports, mmv1.ServicePort{
+			Name:       p.GetName(),
+			Number:     p.GetNumber(),
+			TargetPort: p.GetTargetPort(),
+			Protocol:   mmv1.Protocol(p.GetProtocol()),
+		})
+	}
+	if len(ports) == 0 {
+		// The exposing side predates ports
+		port = in.Port
+	}
+
+	// Every endpoint is the ingress of the exposing mesh
+	var topology []mmv1.EndpointTopology
+	if in.GetLocality() != "" || in.GetNetwork() != "" || in.GetClusterID() != "" {
+		for _, ep := range in.GetEndpoints() {
+			topology = append(topology, mmv1.EndpointTopology{
+				Endpoint:  ep,
+				Locality:  in.GetLocality(),
+				Network:   in.GetNetwork(),
+				ClusterID: in.GetClusterID(),
+			})
+		}
 	}
 
 	return &mmv1.ServiceBinding{
@@ -83,244 +126,198 @@ func newServiceBinding(in *pb.ExposedServicesMessages_ExposedService) *mmv1.Serv
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      newName,
 			Namespace: newNamespace,
+			Annotations: map[string]string{
+				importedFromAnnotation:  disc.name,
+				remoteServiceAnnotation: in.Name,
+			},
 		},
 		Spec: mmv1.ServiceBindingSpec{
 			Name:                  newName,
-			Namespace:             newNamespace,
-			Port:                  in.Port,
-			MeshFedConfigSelector: in.MeshFedConfigSelector,
+			Namespace:             remoteNamespace,
+			Port:                  port,
+			Ports:                 ports,
+			MeshFedConfigSelector: mfcSelector,
 			Endpoints:             in.Endpoints,
+			Topology:              topology,
 			// TODO Alias: in.Alias, // This is the alias on the binding side
 		},
 	}
 }
 
+// createServiceBindings applies a complete ESDS response
 func createServiceBindings(sbr *controllers.ServiceBindingReconciler, in *pb.ExposedServicesMessages,
 	disc *discoveryClient) error {
+	mfcs, err := listMeshFedConfigs(sbr)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(in.GetExposedServices()))
+	for _, v := range in.GetExposedServices() {
+		seen[v.GetName()] = true
+	}
+	// The registry, not this client, knows what the peer imported before this client started
+	if err := imports.removeMissing(disc.name, seen); err != nil {
+		log.Warnf("error in cleanup of withdrawn discovered services: %v", err)
+		return err
+	}
 	for k := range disc.discoveredServices {
-		disc.discoveredServices[k] = CLEAR
+		if !seen[k] {
+			delete(disc.discoveredServices, k)
+		}
 	}
 
 	for _, v := range in.GetExposedServices() {
-		goalNv := newServiceBinding(v)
-		nv := &mmv1.ServiceBinding{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      goalNv.ObjectMeta.Name,
-				Namespace: goalNv.ObjectMeta.Namespace,
-			},
+		if err := applyServiceBinding(v, disc, mfcs); err != nil {
+			return err
 		}
-		_, err := controllerutil.CreateOrUpdate(context.Background(), sbr.Client, nv, func() error {
-			nv.ObjectMeta.Labels = goalNv.Labels
-			nv.ObjectMeta.OwnerReferences = goalNv.ObjectMeta.OwnerReferences
-			nv.Spec = goalNv.Spec
-			return nil
-		})
-		if err != nil {
+	}
+	return nil
+}
+
+// updateServiceBindings applies an incremental ESDS response
+func updateServiceBindings(sbr *controllers.ServiceBindingReconciler, in *pb.ExposedServicesMessages,
+	disc *discoveryClient) error {
+	mfcs, err := listMeshFedConfigs(sbr)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range in.GetRemovedServices() {
+		if err := removeServiceBinding(k, disc); err != nil {
+			return err
+		}
+	}
+	for _, v := range in.GetExposedServices() {
+		if err := applyServiceBinding(v, disc, mfcs); err != nil {
 			return err
 		}
-		disc.discoveredServices[v.GetName()] = CREATED
+	}
+	// Services whose binding was held are retried as the holder may be gone
+	return imports.retry(disc.name)
+}
 
+func listMeshFedConfigs(sbr *controllers.ServiceBindingReconciler) ([]mmv1.MeshFedConfig, error) {
+	var list mmv1.MeshFedConfigList
+	if err := sbr.Client.List(context.Background(), &list); err != nil {
+		return nil, err
 	}
+	return list.Items, nil
+}
 
-	for k := range disc.discoveredServices {
-		if disc.discoveredServices[k] == CLEAR {
-			var newName, newNamespace string
-			s := strings.Split(k, "/")
-			if len(s) == 2 {
-				newNamespace = s[0]
-				newName = s[1]
-			} else {
-				newNamespace = DEFAULT_NAMESPACE
-				newName = s[0]
-			}
+// applyServiceBinding imports a remote service.  The binding is written according to the
+// conflict policy if other remote services map to it.
+func applyServiceBinding(v *pb.ExposedServicesMessages_ExposedService, disc *discoveryClient,
+	mfcs []mmv1.MeshFedConfig) error {
+	goalNv := newServiceBinding(v, disc, mfcs)
+	if err := imports.apply(contributor{peer: disc.name, service: v.GetName()}, disc.peer, goalNv); err != nil {
+		return err
+	}
+	disc.discoveredServices[v.GetName()] = types.NamespacedName{
+		Name:      goalNv.ObjectMeta.Name,
+		Namespace: goalNv.ObjectMeta.Namespace,
+	}
+	return nil
+}
 
-			var binding mmv1.ServiceBinding
-			nsn := types.NamespacedName{
-				Name:      newName,
-				Namespace: newNamespace,
-			}
-			if err := sbr.Client.Get(context.Background(), nsn, &binding); err == nil {
-				sbr.Client.Delete(context.Background(), &binding)
-			} else {
-				log.Warnf("error in cleanup of deleted discovered service: %v", err)
-			}
-			delete(disc.discoveredServices, k)
-		}
+// removeServiceBinding removes remote service k from the binding it was imported into
+func removeServiceBinding(k string, disc *discoveryClient) error {
+	if err := imports.remove(contributor{peer: disc.name, service: k}); err != nil {
+		log.Warnf("error in cleanup of deleted discovered service: %v", err)
+		return err
 	}
+	delete(disc.discoveredServices, k)
 	return nil
 }
 
-// ClientStarter starting the clients for remote discovery servers
+// ClientOptions configures the clients of remote discovery servers
+type ClientOptions struct {
+	// Credentials secure the connections
+	Credentials *Credentials
+	// ConflictPolicy decides how remote services mapping to the same ServiceBinding are imported
+	ConflictPolicy ConflictPolicy
+	// Recorder records import conflicts on DiscoveryPeers and ServiceBindings
+	Recorder record.EventRecorder
+}
+
+// newDiscoveryClient creates the client of a remote discovery server that imports its
+// services as ServiceBindings and reports its state in the DiscoveryPeer's status
+func newDiscoveryClient(svc controllers.DiscoveryServer, sbr *controllers.ServiceBindingReconciler,
+	creds *Credentials) *discoveryClient {
+	dc := &discoveryClient{
+		name:               svc.Name,
+		address:            svc.Address,
+		peer:               svc.Peer,
+		backoff:            defaultBackoff(),
+		discoveredServices: make(map[string]types.NamespacedName),
+	}
+	if svc.Peer != nil {
+		creds = creds.forPeer(svc.Peer.GetNamespace(), svc.Peer.Spec.TLS)
+	}
+	dc.dialOptions = func(ctx context.Context) ([]grpc.DialOption, error) {
+		security, err := creds.dialOption(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []grpc.DialOption{security, keepaliveOption()}, nil
+	}
+	dc.apply = func(in *pb.ExposedServicesMessages) (int, []mmv1.ImportCollision, error) {
+		var err error
+		if in.GetDelta() {
+			err = updateServiceBindings(sbr, in, dc)
+		} else {
+			err = createServiceBindings(sbr, in, dc)
+		}
+		collisions := imports.collisionsOf(dc.name)
+		return len(dc.discoveredServices) - len(collisions), collisions, err
+	}
+	dc.report = func(update func(*mmv1.DiscoveryPeerStatus)) {
+		updatePeerStatus(sbr.Client, dc.name, update)
+	}
+	return dc
+}
+
+// ClientStarter starts, restarts and stops the clients of remote discovery servers as
+// DiscoveryPeers change.  Each client reconnects by itself until it is stopped.
 func ClientStarter(ctx context.Context, sbr *controllers.ServiceBindingReconciler,
-	svcr *controllers.ServiceReconciler, discoveryChannel chan controllers.DiscoveryServer) {
+	discoveryChannel chan controllers.DiscoveryServer, opts ClientOptions) {
 	discoveryServices = make(map[string]*discoveryClient)
+	imports = newImportRegistry(sbr.Client, opts.ConflictPolicy, opts.Recorder)
 
-	monitor := time.NewTicker(connMonitorSeconds * time.Millisecond)
 	for {
 		select {
 		case svc := <-discoveryChannel:
-			_, ok := discoveryServices[svc.Name]
-			if svc.Operation == "U" {
-				// This is in response to either a new or an existing service
-				if !ok {
-					// This is in response to a new service
-					// Create the client for it
-					waitc := make(chan struct{})
-					dc := discoveryClient{
-						name:     svc.Name,
-						address:  svc.Address,
-						waitChan: waitc,
-						//cancel:   cancel, // to be set when starting client
-						status: clientSched,
-					}
-					discoveryServices[svc.Name] = &dc
-					discoveryServices[svc.Name].discoveredServices = make(map[string]int)
-					go client(ctx, sbr, &dc)
-				} else {
-					// This is in response to an update to service
-					// If address has changed, kill the existing client and start a new one
-					// TODO: using a versioned entry, we could delegate this to the mon
-					if discoveryServices[svc.Name].address != svc.Address {
-						discoveryServices[svc.Name].cancel()
-						if discoveryServices[svc.Name].status == clientConnected {
-							discoveryServices[svc.Name].waitChan <- struct{}{}
-						}
-						delete(discoveryServices, svc.Name)
-						waitc := make(chan struct{})
-						dc := discoveryClient{
-							name:     svc.Name,
-							address:  svc.Address,
-							waitChan: waitc,
-							// cancel:   cancel, // to be set when starting client
-							status: clientSched,
-						}
-						discoveryServices[svc.Name] = &dc
-						go client(ctx, sbr, &dc)
-					}
-
+			dc, ok := discoveryServices[svc.Name]
+			switch svc.Operation {
+			case "U":
+				if ok && dc.address == svc.Address && dc.peer.GetGeneration() == svc.Peer.GetGeneration() {
+					// Unchanged
+					continue
 				}
-			} else if svc.Operation == "D" {
-				// This is in response to a deletion of a service
-				// if exists, delet the client
 				if ok {
-					discoveryServices[svc.Name].cancel()
-					if discoveryServices[svc.Name].status == clientConnected {
-						discoveryServices[svc.Name].waitChan <- struct{}{}
-					}
-					delete(discoveryServices, svc.Name)
+					// The peer has changed.  The old client must stop before the new one
+					// changes the same bindings.
+					dc.stop()
 				}
-			}
-		case <-monitor.C:
-			for k, v := range discoveryServices {
-				var oldsvc k8sapi.Service
-				ns, n, err := getNamespceAndName(v.name)
-				if err == nil {
-					key := types.NamespacedName{
-						Namespace: ns,
-						Name:      n,
-					}
-					err := svcr.Get(context.Background(), key, &oldsvc)
-					if err == nil {
-						switch v.status {
-						case clientTimedout:
-							// if svc still exists, reschedule client
-							delete(discoveryServices, k)
-
-							waitc := make(chan struct{})
-							dc := discoveryClient{
-								name:     v.name,
-								address:  v.address,
-								waitChan: waitc,
-								// cancel:   cancel,
-								status: clientSched,
-							}
-							discoveryServices[k] = &dc
-							go client(ctx, sbr, &dc)
-						case clientCanceled:
-							// Not dealing with cancels here yet.
-							delete(discoveryServices, k)
-						case clientConnected:
-							// do nothing
-						case clientSched:
-							// do nothing
-						}
-					} else {
-						// svc has been deleted, (if already connected) stop the client
-						v.cancel()
-						v.waitChan <- struct{}{}
-						delete(discoveryServices, k)
-					}
-				} else {
-					// shouldn't get here.
-					log.Warnf("Incorrect key for a discovery server. Deleting it: key: %v value: %v", k, v)
-					delete(discoveryServices, k)
+				dc = newDiscoveryClient(svc, sbr, opts.Credentials)
+				discoveryServices[svc.Name] = dc
+				dc.start(ctx)
+			case "D":
+				if ok {
+					dc.stop()
+					delete(discoveryServices, svc.Name)
 				}
+				// The services imported only from the deleted peer are removed
+				imports.forget(svc.Name)
+				metrics.ForgetPeer(svc.Name)
 			}
-		}
-	}
-}
-
-// Client is the ESDS grpc client
-func client(ctx context.Context, sbr *controllers.ServiceBindingReconciler, disc *discoveryClient) {
-	// Set up a connection to the server.
-	var err error
-	var conn *grpc.ClientConn = nil
-
-	discoveryClientCtx, cancel := context.WithTimeout(ctx, connTimeoutSeconds*time.Second)
-	disc.cancel = cancel
-	conn, err = grpc.DialContext(discoveryClientCtx, disc.address, grpc.WithInsecure(), grpc.WithBlock())
-
-	if err != nil {
-		log.Infof("Did not connect to %v. Error: %v", disc.address, err)
-		if strings.Contains(err.Error(), "context deadline exceeded") {
-			disc.status = clientTimedout
-		} else {
-			disc.status = clientCanceled
-		}
-		return
-	}
-	defer conn.Close()
-
-	c := pb.NewESDSClient(conn)
-	stream, _ := c.ExposedServicesDiscovery(ctx)
-	waitc := disc.waitChan
-	disc.status = clientConnected
-
-	var note pb.ExposedServicesMessages
-	note.Name = "Request from client"
-
-	go func() {
-		for {
-			in, err := stream.Recv()
-			if err == io.EOF {
-				// read done.
-				close(waitc)
-				return
+		case <-ctx.Done():
+			for name, dc := range discoveryServices {
+				dc.stop()
+				delete(discoveryServices, name)
 			}
-			if err != nil {
-				log.Warnf("Failed to receive a note : %v", err)
-				return
-			}
-			log.Infof("Received ESDA Discovery message: <%v>", in)
-			createServiceBindings(sbr, in, disc)
-			log.Infof("Processed ESDA Discovery message")
+			return
 		}
-	}()
-
-	go func() {
-		for {
-			if err := stream.Send(&note); err != nil {
-				log.Warnf("Failed to send a note: %v", err)
-				return
-			}
-			time.Sleep(connTimeoutSeconds * time.Second)
-		}
-	}()
-
-	select {
-	case <-waitc:
-		stream.CloseSend()
-		return
 	}
 }
 
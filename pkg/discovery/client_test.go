@@ -0,0 +1,85 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	pb "github.com/istio-ecosystem/emcee/pkg/discovery/api"
+	"github.com/istio-ecosystem/emcee/style/passthrough"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// A mapped import lives in the local namespace but keeps reaching the service by its remote
+// name, which is the SNI the exposing ingress routes on
+func TestMappedBindingKeepsRemoteSNI(t *testing.T) {
+	disc := &discoveryClient{
+		name: "mesh-system/cluster2",
+		peer: &mmv1.DiscoveryPeer{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster2", Namespace: "mesh-system"},
+			Spec: mmv1.DiscoveryPeerSpec{
+				NamespaceMapping: []mmv1.NamespaceMappingRule{{From: "shop", Prefix: "{peer}-"}},
+			},
+		},
+	}
+	in := &pb.ExposedServicesMessages_ExposedService{
+		Name:                  "shop/helloworld",
+		MeshFedConfigSelector: map[string]string{"fed-config": "passthrough"},
+		Endpoints:             []string{"192.0.2.10:15443"},
+		Ports:                 []*pb.ServicePort{{Name: "http", Number: 5000, Protocol: "HTTP"}},
+	}
+	sb := newServiceBinding(in, disc, nil)
+	if sb.GetNamespace() != "cluster2-shop" || sb.Spec.Namespace != "shop" {
+		t.Fatalf("binding in %q for namespace %q, expected cluster2-shop for shop", sb.GetNamespace(), sb.Spec.Namespace)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	istioCli := istiofake.NewSimpleClientset()
+	binder := passthrough.NewPassthroughServiceBinder(fake.NewFakeClientWithScheme(scheme), istioCli, nil)
+	mfc := &mmv1.MeshFedConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "passthrough", Namespace: "mesh-system"},
+		Spec:       mmv1.MeshFedConfigSpec{Mode: passthrough.Mode, UseIngressGateway: true},
+	}
+	ctx := context.Background()
+	if err := binder.EffectServiceBinding(ctx, sb, mfc); err != nil {
+		t.Fatalf("EffectServiceBinding failed: %v", err)
+	}
+	drs, err := istioCli.NetworkingV1alpha3().DestinationRules("cluster2-shop").List(ctx, metav1.ListOptions{})
+	if err != nil || len(drs.Items) != 1 {
+		t.Fatalf("expected a DestinationRule in the mapped namespace, got %v, %v", drs, err)
+	}
+	settings := drs.Items[0].Spec.GetTrafficPolicy().GetPortLevelSettings()
+	if len(settings) != 1 {
+		t.Fatalf("%d port settings for one port", len(settings))
+	}
+	for _, s := range settings {
+		if sni := s.GetTls().GetSni(); sni != "helloworld.shop.svc.cluster.local" {
+			t.Errorf("port %d originates TLS with SNI %q", s.GetPort().GetNumber(), sni)
+		}
+	}
+	if host := drs.Items[0].Spec.GetHost(); host != "helloworld.cluster2-shop.svc.cluster.local" {
+		t.Errorf("DestinationRule for %q, expected the local service", host)
+	}
+}
@@ -0,0 +1,124 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/istio-ecosystem/emcee/pkg/discovery/api"
+	"github.com/istio-ecosystem/emcee/pkg/metrics"
+	"istio.io/pkg/log"
+)
+
+var nonceCounter uint64
+
+// processRequest records the peer's identity, delta support and any ACK or NACK carried by req.
+// It returns true if req asks for the current state, false if it only answers a response.
+func (con *EsdsConnection) processRequest(req *pb.ExposedServicesMessages) bool {
+	con.mutex.Lock()
+	defer con.mutex.Unlock()
+
+	// A peer's claimed cluster is only trusted when it has no certificate identity
+	if !con.verified && req.GetCluster() != "" {
+		con.Identities = []string{req.GetCluster()}
+	}
+	if req.GetDelta() {
+		con.delta = true
+	}
+
+	if req.GetNonce() == "" {
+		// An initial or periodic request
+		return true
+	}
+	if req.GetNonce() != con.nonce {
+		// Answers a response that has since been superseded
+		log.Debugf("ESDS: %s answered stale nonce %s", con.ConID, req.GetNonce())
+		return false
+	}
+	if req.GetErrorDetail() != "" {
+		// The peer keeps its last applied version.  The next response is computed against
+		// that version so that everything rejected is sent again.
+		log.Warnf("ESDS: %s rejected version %d: %s", con.ConID, con.version, req.GetErrorDetail())
+		metrics.PushAnswered(con.sentAt, true)
+		con.sent = con.acked
+		return false
+	}
+	metrics.PushAnswered(con.sentAt, false)
+	con.acked = con.sent
+	con.AckedVersion = req.GetVersionInfo()
+	return false
+}
+
+// send sends the exposed services the peer may discover.  Delta peers only receive the services
+// added, changed or removed since the state they will have after the last response, and receive
+// nothing if that state is current.  The first response on a connection is always complete so
+// that a reconnecting peer drops the services removed while it was disconnected.
+func (con *EsdsConnection) send(in *pb.ExposedServicesMessages) error {
+	con.mutex.Lock()
+	defer con.mutex.Unlock()
+
+	var all pb.ExposedServicesMessages
+	if err := getAllExposedService(&all, in, con.Identities); err != nil {
+		// Sending a partial list would remove the peer's bindings
+		log.Warnf("ESDS: not responding to %s: %v", con.ConID, err)
+		return nil
+	}
+	current := make(map[string]*pb.ExposedServicesMessages_ExposedService, len(all.ExposedServices))
+	for _, svc := range all.ExposedServices {
+		current[svc.GetName()] = svc
+	}
+
+	out := pb.ExposedServicesMessages{
+		Name: all.Name,
+	}
+	pushType := metrics.PushFull
+	if con.delta && con.version > 0 {
+		for _, svc := range all.ExposedServices {
+			if old, ok := con.sent[svc.GetName()]; !ok || !proto.Equal(old, svc) {
+				out.ExposedServices = append(out.ExposedServices, svc)
+			}
+		}
+		for name := range con.sent {
+			if _, ok := current[name]; !ok {
+				out.RemovedServices = append(out.RemovedServices, name)
+			}
+		}
+		sort.Strings(out.RemovedServices)
+		if len(out.ExposedServices) == 0 && len(out.RemovedServices) == 0 {
+			return nil
+		}
+		out.Delta = true
+		pushType = metrics.PushDelta
+	} else {
+		out.ExposedServices = all.ExposedServices
+	}
+
+	out.VersionInfo = strconv.FormatInt(con.version+1, 10)
+	out.Nonce = strconv.FormatUint(atomic.AddUint64(&nonceCounter, 1), 10)
+	if err := con.stream.Send(&out); err != nil {
+		return err
+	}
+	metrics.PushSent(pushType)
+	con.version++
+	con.nonce = out.Nonce
+	con.sentAt = time.Now()
+	con.sent = current
+	return nil
+}
@@ -0,0 +1,211 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/controllers"
+	pb "github.com/istio-ecosystem/emcee/pkg/discovery/api"
+	"google.golang.org/grpc"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// recordingStream records the responses sent to a peer
+type recordingStream struct {
+	grpc.ServerStream
+	sent []*pb.ExposedServicesMessages
+}
+
+func (s *recordingStream) Send(m *pb.ExposedServicesMessages) error {
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func (s *recordingStream) Recv() (*pb.ExposedServicesMessages, error) {
+	select {}
+}
+
+// last returns the last response sent, or nil if nothing was sent since the last call
+func (s *recordingStream) last() *pb.ExposedServicesMessages {
+	if len(s.sent) == 0 {
+		return nil
+	}
+	m := s.sent[len(s.sent)-1]
+	s.sent = nil
+	return m
+}
+
+func testExposition(name string) *mmv1.ServiceExposition {
+	return &mmv1.ServiceExposition{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       mmv1.ServiceExpositionSpec{Name: name, Port: 9080},
+	}
+}
+
+// newDeltaTest serves the given services to a new connection that asked for delta or not
+func newDeltaTest(t *testing.T, delta bool, names ...string) (*EsdsConnection, *recordingStream, client.Client) {
+	cli := newTestTLSClient(t)
+	for _, name := range names {
+		if err := cli.Create(context.Background(), testExposition(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := seReconciler
+	seReconciler = &controllers.ServiceExpositionReconciler{Client: cli}
+	t.Cleanup(func() { seReconciler = old })
+
+	stream := &recordingStream{}
+	con := newEsdsConnection("10.0.0.1:1234", stream)
+	con.verified = true
+	if !con.processRequest(&pb.ExposedServicesMessages{Delta: delta}) {
+		t.Fatal("initial request does not ask for the current state")
+	}
+	return con, stream, cli
+}
+
+func sendAndCheck(t *testing.T, con *EsdsConnection, stream *recordingStream, delta bool, exposed, removed []string) *pb.ExposedServicesMessages {
+	t.Helper()
+	if err := con.send(&pb.ExposedServicesMessages{}); err != nil {
+		t.Fatal(err)
+	}
+	m := stream.last()
+	if m == nil {
+		t.Fatal("nothing sent")
+	}
+	if m.GetDelta() != delta {
+		t.Errorf("delta %v, expected %v", m.GetDelta(), delta)
+	}
+	var names []string
+	for _, svc := range m.GetExposedServices() {
+		names = append(names, svc.GetName())
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, exposed) {
+		t.Errorf("sent %v, expected %v", names, exposed)
+	}
+	if !reflect.DeepEqual(m.GetRemovedServices(), removed) {
+		t.Errorf("removed %v, expected %v", m.GetRemovedServices(), removed)
+	}
+	return m
+}
+
+func ack(con *EsdsConnection, m *pb.ExposedServicesMessages) {
+	con.processRequest(&pb.ExposedServicesMessages{VersionInfo: m.GetVersionInfo(), Nonce: m.GetNonce()})
+}
+
+func TestDeltaAddRemove(t *testing.T) {
+	ctx := context.Background()
+	con, stream, cli := newDeltaTest(t, true, "details", "reviews")
+
+	// The first response on a connection is complete
+	m := sendAndCheck(t, con, stream, false, []string{"default/details", "default/reviews"}, nil)
+	ack(con, m)
+	if con.AckedVersion != m.GetVersionInfo() || len(con.acked) != 2 {
+		t.Errorf("ACK of version %s not recorded: version %q, %d services", m.GetVersionInfo(), con.AckedVersion, len(con.acked))
+	}
+
+	if err := cli.Create(ctx, testExposition("ratings")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Delete(ctx, testExposition("details")); err != nil {
+		t.Fatal(err)
+	}
+	m = sendAndCheck(t, con, stream, true, []string{"default/ratings"}, []string{"default/details"})
+	ack(con, m)
+
+	// Up to date delta peers receive nothing
+	if err := con.send(&pb.ExposedServicesMessages{}); err != nil {
+		t.Fatal(err)
+	}
+	if m := stream.last(); m != nil {
+		t.Errorf("sent %v to an up to date peer", m)
+	}
+}
+
+func TestDeltaNackRollsBack(t *testing.T) {
+	ctx := context.Background()
+	con, stream, cli := newDeltaTest(t, true, "details", "reviews")
+	first := sendAndCheck(t, con, stream, false, []string{"default/details", "default/reviews"}, nil)
+	ack(con, first)
+
+	if err := cli.Create(ctx, testExposition("ratings")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Delete(ctx, testExposition("details")); err != nil {
+		t.Fatal(err)
+	}
+	m := sendAndCheck(t, con, stream, true, []string{"default/ratings"}, []string{"default/details"})
+	con.processRequest(&pb.ExposedServicesMessages{VersionInfo: first.GetVersionInfo(), Nonce: m.GetNonce(), ErrorDetail: "rejected"})
+	if !reflect.DeepEqual(con.sent, con.acked) {
+		t.Errorf("NACK did not roll the sent services back to the ACKed ones")
+	}
+	if con.AckedVersion != first.GetVersionInfo() {
+		t.Errorf("NACK changed the ACKed version to %s", con.AckedVersion)
+	}
+
+	// Everything rejected is sent again
+	sendAndCheck(t, con, stream, true, []string{"default/ratings"}, []string{"default/details"})
+}
+
+func TestDeltaIgnoresStaleNonces(t *testing.T) {
+	ctx := context.Background()
+	con, stream, cli := newDeltaTest(t, true, "details")
+	first := sendAndCheck(t, con, stream, false, []string{"default/details"}, nil)
+	if err := cli.Create(ctx, testExposition("reviews")); err != nil {
+		t.Fatal(err)
+	}
+	second := sendAndCheck(t, con, stream, true, []string{"default/reviews"}, nil)
+	sent := con.sent
+
+	for _, req := range []*pb.ExposedServicesMessages{
+		{VersionInfo: first.GetVersionInfo(), Nonce: first.GetNonce()},
+		{VersionInfo: first.GetVersionInfo(), Nonce: first.GetNonce(), ErrorDetail: "rejected"},
+		{VersionInfo: second.GetVersionInfo(), Nonce: "unknown"},
+		{Nonce: "unknown", ErrorDetail: "rejected"},
+	} {
+		if con.processRequest(req) {
+			t.Errorf("answer %v asks for the current state", req)
+		}
+		if con.acked != nil || con.AckedVersion != "" {
+			t.Errorf("answer %v with a stale nonce was taken as an ACK", req)
+		}
+		if !reflect.DeepEqual(con.sent, sent) {
+			t.Errorf("answer %v with a stale nonce was taken as a NACK", req)
+		}
+	}
+}
+
+func TestFullResyncWithoutDelta(t *testing.T) {
+	ctx := context.Background()
+	con, stream, cli := newDeltaTest(t, false, "details", "reviews")
+	m := sendAndCheck(t, con, stream, false, []string{"default/details", "default/reviews"}, nil)
+	ack(con, m)
+
+	if err := cli.Delete(ctx, testExposition("details")); err != nil {
+		t.Fatal(err)
+	}
+	m = sendAndCheck(t, con, stream, false, []string{"default/reviews"}, nil)
+	ack(con, m)
+
+	// Peers that predate delta receive the full list even when nothing changed
+	sendAndCheck(t, con, stream, false, []string{"default/reviews"}, nil)
+}
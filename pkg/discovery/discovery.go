@@ -25,12 +25,15 @@ import (
 	"io"
 	"net"
 	"sync"
+	"time"
 
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
 	"github.com/istio-ecosystem/emcee/controllers"
 	pb "github.com/istio-ecosystem/emcee/pkg/discovery/api"
+	"github.com/istio-ecosystem/emcee/pkg/metrics"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
@@ -72,15 +75,43 @@ type EsdsConnection struct {
 
 	mutex sync.RWMutex
 	added bool
+
+	// Identities of the peer, used to select the services it may discover.  These are the
+	// verified certificate identities or, without TLS, the cluster named in its requests.
+	Identities []string
+	verified   bool
+
+	// delta is true if the peer asked for incremental responses
+	delta bool
+	// version and nonce of the last response sent
+	version int64
+	nonce   string
+	// sentAt is when the last response was sent
+	sentAt time.Time
+	// sent are the services the peer has once it ACKs the last response, acked those of
+	// the last version it ACKed.  Both are keyed by service name.
+	sent  map[string]*pb.ExposedServicesMessages_ExposedService
+	acked map[string]*pb.ExposedServicesMessages_ExposedService
+	// AckedVersion is the version the peer last applied
+	AckedVersion string
 }
 
-func getAllExposedService(z, in *pb.ExposedServicesMessages) {
+// getAllExposedService fills z with the exposed services a peer with identities may discover
+func getAllExposedService(z, in *pb.ExposedServicesMessages, identities []string) error {
 	var list mmv1.ServiceExpositionList
 	err := seReconciler.List(context.Background(), &list)
 	z.Name = "Exposed Services for " + in.Name
 
+	var mfcs mmv1.MeshFedConfigList
+	if err == nil {
+		err = seReconciler.List(context.Background(), &mfcs)
+	}
+
 	if err == nil {
 		for _, v := range list.Items {
+			if !exposedTo(&v, mfcs.Items, identities) {
+				continue
+			}
 			name := v.Spec.Name
 			if v.Spec.Alias != "" {
 				name = v.Spec.Alias
@@ -96,12 +127,31 @@ func getAllExposedService(z, in *pb.ExposedServicesMessages) {
 				Port:                  v.Spec.Port,
 				MeshFedConfigSelector: v.Spec.MeshFedConfigSelector,
 			}
+			for _, p := range v.Spec.ServicePorts() {
+				entry.Ports = append(entry.Ports, &pb.ServicePort{
+					Name:       p.Name,
+					Number:     p.Number,
+					TargetPort: p.TargetPort,
+					Protocol:   string(p.Protocol),
+				})
+			}
+			// Peers that predate ports only read port
+			if entry.Port == 0 && len(entry.Ports) > 0 {
+				entry.Port = entry.Ports[0].Number
+			}
 			for _, w := range v.Spec.Endpoints {
 				entry.Endpoints = append(entry.Endpoints, w)
 			}
+			entry.Locality = v.Spec.Locality
+			entry.Network = v.Spec.Network
+			entry.ClusterID = v.Spec.ClusterID
+			if entry.ClusterID == "" {
+				entry.ClusterID = clusterName
+			}
 			z.ExposedServices = append(z.ExposedServices, &entry)
 		}
 	}
+	return err
 }
 
 func receiveThread(stream pb.ESDS_ExposedServicesDiscoveryServer, reqChannel chan *pb.ExposedServicesMessages, receiveError *error) {
@@ -129,9 +179,21 @@ func updateThread(updateChannel chan int, updateError *error) {
 	for {
 		select {
 		case <-updateChannel:
-			for _, v := range esdsClients {
-				v.pushChannel <- &EsdsEvent{}
-			}
+			pushAll()
+		}
+	}
+}
+
+// pushAll signals every connection to push.  A connection whose push is still pending
+// already has one queued, and a connection whose handler has exited is skipped, so a
+// slow or dead peer never stalls the others.
+func pushAll() {
+	esdsClientsMutex.RLock()
+	defer esdsClientsMutex.RUnlock()
+	for _, v := range esdsClients {
+		select {
+		case v.pushChannel <- &EsdsEvent{}:
+		default:
 		}
 	}
 }
@@ -145,6 +207,7 @@ func (s *server) ExposedServicesDiscovery(stream pb.ESDS_ExposedServicesDiscover
 		peerAddr = peerInfo.Addr.String()
 	}
 	con := newEsdsConnection(peerAddr, stream)
+	con.Identities, con.verified = peerIdentities(peerInfo)
 
 	var receiveError error
 	reqChannel := make(chan *pb.ExposedServicesMessages)
@@ -159,9 +222,11 @@ func (s *server) ExposedServicesDiscovery(stream pb.ESDS_ExposedServicesDiscover
 				// Remote side closed connection.
 				return receiveError
 			}
-			var out pb.ExposedServicesMessages
-			getAllExposedService(&out, discReq)
-			if err := stream.Send(&out); err != nil {
+			if !con.processRequest(discReq) {
+				// An ACK or NACK
+				continue
+			}
+			if err := con.send(discReq); err != nil {
 				return err
 			}
 
@@ -180,20 +245,16 @@ func (s *server) ExposedServicesDiscovery(stream pb.ESDS_ExposedServicesDiscover
 			in := pb.ExposedServicesMessages{
 				Name: "Eventer",
 			}
-			var out pb.ExposedServicesMessages
-			getAllExposedService(&out, &in)
-			err := stream.Send(&out)
-			//pushEv.done()
-			if err != nil {
-				log.Fatalf("Discovery Server failed.")
-				return nil
+			if err := con.send(&in); err != nil {
+				log.Warnf("ESDS: push to %s failed: %v", con.ConID, err)
+				return err
 			}
 		}
 	}
 }
 
-// Discovery creates a grpc server
-func Discovery(ser *controllers.ServiceExpositionReconciler, grpcServerAddr *string) {
+// Discovery creates a grpc server, secured by creds if they are enabled
+func Discovery(ser *controllers.ServiceExpositionReconciler, grpcServerAddr *string, creds *Credentials) {
 	var updateError error
 	if ser == nil {
 		log.Fatalf("Need Service Exposition Reconciler; None provided")
@@ -206,7 +267,17 @@ func Discovery(ser *controllers.ServiceExpositionReconciler, grpcServerAddr *str
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	s := grpc.NewServer()
+	opts := append(creds.serverOptions(),
+		// Clients ping idle connections to detect broken streams, and so does the server
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             keepaliveTime / 2,
+			PermitWithoutStream: true,
+		}),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    keepaliveTime,
+			Timeout: keepaliveTimeout,
+		}))
+	s := grpc.NewServer(opts...)
 	pb.RegisterESDSServer(s, &server{})
 
 	// Register reflection service on gRPC server.
@@ -218,7 +289,8 @@ func Discovery(ser *controllers.ServiceExpositionReconciler, grpcServerAddr *str
 
 func newEsdsConnection(peerAddr string, stream pb.ESDS_ExposedServicesDiscoveryServer) *EsdsConnection {
 	return &EsdsConnection{
-		pushChannel: make(chan *EsdsEvent),
+		// One pending push is enough; further updates coalesce into it
+		pushChannel: make(chan *EsdsEvent, 1),
 		PeerAddr:    peerAddr,
 		ConID:       peerAddr, // TODO: maybe update
 		stream:      stream,
@@ -229,6 +301,7 @@ func addCon(conID string, con *EsdsConnection) {
 	esdsClientsMutex.Lock()
 	defer esdsClientsMutex.Unlock()
 	esdsClients[conID] = con
+	metrics.ESDSConnections(len(esdsClients))
 }
 
 func removeCon(conID string, con *EsdsConnection) {
@@ -240,4 +313,5 @@ func removeCon(conID string, con *EsdsConnection) {
 	} else {
 		delete(esdsClients, conID)
 	}
+	metrics.ESDSConnections(len(esdsClients))
 }
@@ -0,0 +1,44 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPushAllCoalesces(t *testing.T) {
+	con := newEsdsConnection("10.0.0.1:1234", nil)
+	addCon(con.ConID, con)
+	defer removeCon(con.ConID, con)
+
+	// Nobody receives on the connection, as when its handler has exited
+	done := make(chan struct{})
+	go func() {
+		pushAll()
+		pushAll()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pushAll blocked on a connection that is not receiving")
+	}
+
+	if n := len(con.pushChannel); n != 1 {
+		t.Errorf("%d pushes pending, want 1", n)
+	}
+}
@@ -0,0 +1,489 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"istio.io/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// ConflictPolicy decides how a ServiceBinding is imported when several remote services map to it
+type ConflictPolicy string
+
+const (
+	// ConflictFirstWins imports the service that arrived first.  The others are imported
+	// in turn if it goes away.
+	ConflictFirstWins ConflictPolicy = "first-wins"
+	// ConflictMergeEndpoints imports the first service with the endpoints of all those
+	// with the same ports
+	ConflictMergeEndpoints ConflictPolicy = "merge-endpoints"
+	// ConflictReject imports none of the services while more than one maps to the binding
+	ConflictReject ConflictPolicy = "reject"
+)
+
+const (
+	// mergedFromAnnotation lists the other DiscoveryPeers whose endpoints a binding merges
+	mergedFromAnnotation = "mm.ibm.istio.io/merged-from"
+
+	eventImportConflict = "ImportConflict"
+	eventImportMerged   = "ImportMerged"
+)
+
+// ParseConflictPolicy returns the policy named s
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case ConflictFirstWins, ConflictMergeEndpoints, ConflictReject:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown import conflict policy %q; use %s, %s or %s",
+		s, ConflictFirstWins, ConflictMergeEndpoints, ConflictReject)
+}
+
+// contributor is a remote service of a DiscoveryPeer
+type contributor struct {
+	// peer is the namespace/name of the DiscoveryPeer
+	peer string
+	// service is the remote namespace/name of the service
+	service string
+}
+
+func (c contributor) String() string {
+	return "service " + c.service + " of DiscoveryPeer " + c.peer
+}
+
+// contribution is the binding a remote service would have on its own
+type contribution struct {
+	contributor
+	binding *mmv1.ServiceBinding
+	// source is the DiscoveryPeer, for events
+	source *mmv1.DiscoveryPeer
+}
+
+// importRegistry tracks the remote services imported into each ServiceBinding by all discovery
+// clients, and writes the bindings according to the conflict policy
+type importRegistry struct {
+	cli      client.Client
+	policy   ConflictPolicy
+	recorder record.EventRecorder
+
+	mutex sync.Mutex
+	// contributions to each binding, in order of arrival
+	contributions map[types.NamespacedName][]contribution
+	// bindings of each contributor
+	bindings map[contributor]types.NamespacedName
+	// collisions are the contributors not imported, or not merged, into their binding
+	collisions map[contributor]mmv1.ImportCollision
+	// peers are the DiscoveryPeers that imported bindings
+	peers map[string]bool
+}
+
+// imports is shared by the discovery clients
+var imports *importRegistry
+
+func newImportRegistry(cli client.Client, policy ConflictPolicy, recorder record.EventRecorder) *importRegistry {
+	if policy == "" {
+		policy = ConflictFirstWins
+	}
+	return &importRegistry{
+		cli:           cli,
+		policy:        policy,
+		recorder:      recorder,
+		contributions: make(map[types.NamespacedName][]contribution),
+		bindings:      make(map[contributor]types.NamespacedName),
+		collisions:    make(map[contributor]mmv1.ImportCollision),
+		peers:         make(map[string]bool),
+	}
+}
+
+// apply records goal as the binding of c, a service of source, and writes the binding
+func (r *importRegistry) apply(c contributor, source *mmv1.DiscoveryPeer, goal *mmv1.ServiceBinding) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	nsn := types.NamespacedName{Namespace: goal.GetNamespace(), Name: goal.GetName()}
+	if old, ok := r.bindings[c]; ok && old != nsn {
+		// The namespace mapping has changed
+		r.unregister(c)
+		if err := r.sync(old); err != nil {
+			return err
+		}
+	}
+	list := r.contributions[nsn]
+	i := 0
+	for i < len(list) && list[i].contributor != c {
+		i++
+	}
+	if i == len(list) {
+		list = append(list, contribution{contributor: c})
+	}
+	list[i].binding = goal
+	list[i].source = source
+	r.contributions[nsn] = list
+	r.bindings[c] = nsn
+	r.peers[c.peer] = true
+	return r.sync(nsn)
+}
+
+// remove forgets c and writes its binding without it
+func (r *importRegistry) remove(c contributor) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	nsn, ok := r.bindings[c]
+	if !ok {
+		return nil
+	}
+	r.unregister(c)
+	return r.sync(nsn)
+}
+
+// removeMissing removes the services of peer that are not in seen, the services of its latest
+// complete response.  This includes the bindings the peer imported before this client started,
+// after a restart of the controller or a change of the peer, which the registry does not know.
+func (r *importRegistry) removeMissing(peer string, seen map[string]bool) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for c, nsn := range r.bindings {
+		if c.peer == peer && !seen[c.service] {
+			r.unregister(c)
+			if err := r.sync(nsn); err != nil {
+				return err
+			}
+		}
+	}
+
+	var list mmv1.ServiceBindingList
+	if err := r.cli.List(context.Background(), &list); err != nil {
+		return err
+	}
+	for i := range list.Items {
+		sb := &list.Items[i]
+		annotations := sb.GetAnnotations()
+		if annotations[importedFromAnnotation] != peer {
+			continue
+		}
+		c := contributor{peer: peer, service: annotations[remoteServiceAnnotation]}
+		if _, ok := r.bindings[c]; ok || seen[c.service] {
+			continue
+		}
+		log.Infof("Deleting ServiceBinding %s/%s of withdrawn %v", sb.GetNamespace(), sb.GetName(), c)
+		if err := r.cli.Delete(context.Background(), sb); err != nil && !apierrs.IsNotFound(err) {
+			return err
+		}
+		// Another service may now be imported into the binding
+		if err := r.sync(types.NamespacedName{Namespace: sb.GetNamespace(), Name: sb.GetName()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forget removes every service of a deleted DiscoveryPeer
+func (r *importRegistry) forget(peer string) {
+	var cs []contributor
+	r.mutex.Lock()
+	for c := range r.bindings {
+		if c.peer == peer {
+			cs = append(cs, c)
+		}
+	}
+	r.mutex.Unlock()
+	for _, c := range cs {
+		if err := r.remove(c); err != nil {
+			log.Warnf("Could not remove %v: %v", c, err)
+		}
+	}
+	r.mutex.Lock()
+	delete(r.peers, peer)
+	r.mutex.Unlock()
+}
+
+// retry writes again the bindings the services of peer collide on, as what holds them may be gone
+func (r *importRegistry) retry(peer string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var err error
+	for c := range r.collisions {
+		if c.peer == peer {
+			if e := r.sync(r.bindings[c]); e != nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+// collisionsOf returns the services of peer not imported, or not merged, into their binding
+func (r *importRegistry) collisionsOf(peer string) []mmv1.ImportCollision {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.peerCollisionList(peer)
+}
+
+func (r *importRegistry) peerCollisionList(peer string) []mmv1.ImportCollision {
+	var collisions []mmv1.ImportCollision
+	for c, collision := range r.collisions {
+		if c.peer == peer {
+			collisions = append(collisions, collision)
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i].Service < collisions[j].Service
+	})
+	return collisions
+}
+
+func (r *importRegistry) unregister(c contributor) {
+	nsn := r.bindings[c]
+	list := r.contributions[nsn]
+	for i := range list {
+		if list[i].contributor == c {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(r.contributions, nsn)
+	} else {
+		r.contributions[nsn] = list
+	}
+	delete(r.bindings, c)
+	delete(r.collisions, c)
+}
+
+// sync writes the binding named nsn from its contributions and the policy.  A binding that was
+// not imported, or was imported by a DiscoveryPeer not known to this controller, is left alone.
+func (r *importRegistry) sync(nsn types.NamespacedName) error {
+	ctx := context.Background()
+	list := r.contributions[nsn]
+
+	var existing mmv1.ServiceBinding
+	found := true
+	if err := r.cli.Get(ctx, nsn, &existing); err != nil {
+		if !apierrs.IsNotFound(err) {
+			return err
+		}
+		found = false
+	}
+
+	owner, imported := existing.GetAnnotations()[importedFromAnnotation]
+	if found && imported {
+		remote := existing.GetAnnotations()[remoteServiceAnnotation]
+		for i, c := range list {
+			if i > 0 && c.peer == owner && c.service == remote {
+				// The binding keeps the service it was imported for
+				list = append([]contribution{c}, append(list[:i:i], list[i+1:]...)...)
+				r.contributions[nsn] = list
+				break
+			}
+		}
+	}
+
+	collisions := make(map[contributor]string)
+	var winner *contribution
+	var merged []contribution
+	switch {
+	case found && !imported:
+		for _, c := range list {
+			collisions[c.contributor] = "local ServiceBinding"
+		}
+	case found && !r.peers[owner]:
+		// Imported before a restart by a peer that has not reconnected, or that was deleted
+		for _, c := range list {
+			collisions[c.contributor] = "DiscoveryPeer " + owner
+		}
+	case len(list) == 0:
+	case r.policy == ConflictReject && len(list) > 1:
+		for _, c := range list {
+			var others []string
+			for _, o := range list {
+				if o.contributor != c.contributor {
+					others = append(others, o.contributor.String())
+				}
+			}
+			collisions[c.contributor] = strings.Join(others, ", ")
+		}
+	default:
+		winner = &list[0]
+		for _, c := range list[1:] {
+			if r.policy == ConflictMergeEndpoints && samePorts(winner.binding, c.binding) {
+				merged = append(merged, c)
+			} else {
+				collisions[c.contributor] = winner.contributor.String()
+			}
+		}
+	}
+	r.recordCollisions(nsn, list, collisions, found, &existing)
+
+	if winner == nil {
+		if found && imported && r.peers[owner] {
+			log.Infof("Deleting imported ServiceBinding %v", nsn)
+			if err := r.cli.Delete(ctx, &existing); err != nil && !apierrs.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	goal := winner.binding.DeepCopy()
+	var mergedFrom []string
+	for _, c := range merged {
+		goal.Spec.Endpoints = appendMissing(goal.Spec.Endpoints, c.binding.Spec.Endpoints...)
+		goal.Spec.Topology = appendMissingTopology(goal.Spec.Topology, c.binding.Spec.Topology...)
+		mergedFrom = appendMissing(mergedFrom, c.peer)
+	}
+	nv := &mmv1.ServiceBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nsn.Name,
+			Namespace: nsn.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.cli, nv, func() error {
+		if nv.ObjectMeta.Annotations == nil {
+			nv.ObjectMeta.Annotations = make(map[string]string)
+		}
+		for k, a := range goal.Annotations {
+			nv.ObjectMeta.Annotations[k] = a
+		}
+		if len(mergedFrom) > 0 {
+			nv.ObjectMeta.Annotations[mergedFromAnnotation] = strings.Join(mergedFrom, ",")
+		} else {
+			delete(nv.ObjectMeta.Annotations, mergedFromAnnotation)
+		}
+		nv.ObjectMeta.Labels = goal.Labels
+		nv.ObjectMeta.OwnerReferences = goal.ObjectMeta.OwnerReferences
+		nv.Spec = goal.Spec
+		return nil
+	})
+	if err == nil && len(merged) > 0 && r.recorder != nil &&
+		existing.GetAnnotations()[mergedFromAnnotation] != strings.Join(mergedFrom, ",") {
+		r.recorder.Eventf(nv, corev1.EventTypeNormal, eventImportMerged,
+			"Merged the endpoints of %s into those of %s", describe(merged), winner.contributor)
+	}
+	return err
+}
+
+// recordCollisions records the collisions of the contributions to nsn, emits events for the new
+// ones and updates the status of the other DiscoveryPeers whose collisions changed
+func (r *importRegistry) recordCollisions(nsn types.NamespacedName, list []contribution,
+	collisions map[contributor]string, found bool, existing *mmv1.ServiceBinding) {
+	changed := make(map[string]bool)
+	for _, c := range list {
+		holder, collides := collisions[c.contributor]
+		old, collided := r.collisions[c.contributor]
+		if !collides {
+			if collided {
+				delete(r.collisions, c.contributor)
+				changed[c.peer] = true
+			}
+			continue
+		}
+		collision := mmv1.ImportCollision{
+			Service: c.service,
+			Binding: nsn.String(),
+			Holder:  holder,
+		}
+		if collided && old == collision {
+			continue
+		}
+		r.collisions[c.contributor] = collision
+		changed[c.peer] = true
+		log.Warnf("Not importing %v into ServiceBinding %v: conflicts with %s", c.contributor, nsn, holder)
+		if r.recorder == nil {
+			continue
+		}
+		if found {
+			r.recorder.Eventf(existing, corev1.EventTypeWarning, eventImportConflict,
+				"Not importing %s: conflicts with %s", c.contributor, holder)
+		}
+		if c.source != nil {
+			r.recorder.Eventf(c.source, corev1.EventTypeWarning, eventImportConflict,
+				"Not importing service %s into ServiceBinding %v: conflicts with %s", c.service, nsn, holder)
+		}
+	}
+	for peer := range changed {
+		r.reportCollisions(peer)
+	}
+}
+
+// reportCollisions updates the status of peer with its collisions
+func (r *importRegistry) reportCollisions(peer string) {
+	collisions := r.peerCollisionList(peer)
+	updatePeerStatus(r.cli, peer, func(status *mmv1.DiscoveryPeerStatus) {
+		setCollisions(status, collisions)
+	})
+}
+
+func samePorts(a, b *mmv1.ServiceBinding) bool {
+	return a.Spec.Port == b.Spec.Port && reflect.DeepEqual(a.Spec.Ports, b.Spec.Ports)
+}
+
+func appendMissing(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, l := range list {
+			if l == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// appendMissingTopology appends the topology of the endpoints not yet placed in list
+func appendMissingTopology(list []mmv1.EndpointTopology, items ...mmv1.EndpointTopology) []mmv1.EndpointTopology {
+	for _, item := range items {
+		found := false
+		for _, l := range list {
+			if l.Endpoint == item.Endpoint {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func describe(list []contribution) string {
+	var s []string
+	for _, c := range list {
+		s = append(s, c.contributor.String())
+	}
+	return strings.Join(s, ", ")
+}
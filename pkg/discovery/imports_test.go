@@ -0,0 +1,218 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var testBindingName = types.NamespacedName{Namespace: "default", Name: "helloworld"}
+
+func newTestRegistry(t *testing.T, policy ConflictPolicy, objs ...runtime.Object) *importRegistry {
+	scheme := runtime.NewScheme()
+	if err := mmv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	return newImportRegistry(fake.NewFakeClientWithScheme(scheme, objs...), policy, nil)
+}
+
+// importedBinding is the binding service helloworld of peer would have on its own
+func importedBinding(peer string, port uint32, endpoints ...string) *mmv1.ServiceBinding {
+	return &mmv1.ServiceBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testBindingName.Name,
+			Namespace: testBindingName.Namespace,
+			Annotations: map[string]string{
+				importedFromAnnotation:  peer,
+				remoteServiceAnnotation: "default/helloworld",
+			},
+		},
+		Spec: mmv1.ServiceBindingSpec{
+			Name:      testBindingName.Name,
+			Namespace: testBindingName.Namespace,
+			Port:      port,
+			Endpoints: endpoints,
+		},
+	}
+}
+
+func applyTestBinding(t *testing.T, r *importRegistry, peer string, port uint32, endpoints ...string) {
+	c := contributor{peer: peer, service: "default/helloworld"}
+	if err := r.apply(c, nil, importedBinding(peer, port, endpoints...)); err != nil {
+		t.Fatalf("Could not apply %v: %v", c, err)
+	}
+}
+
+// getTestBinding returns the binding, or nil if there is none
+func getTestBinding(t *testing.T, r *importRegistry) *mmv1.ServiceBinding {
+	var sb mmv1.ServiceBinding
+	if err := r.cli.Get(context.Background(), testBindingName, &sb); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+		t.Fatalf("Could not get binding: %v", err)
+	}
+	return &sb
+}
+
+func TestImportFirstWins(t *testing.T) {
+	r := newTestRegistry(t, ConflictFirstWins)
+	applyTestBinding(t, r, "peers/a", 5000, "10.0.0.1:15443")
+	applyTestBinding(t, r, "peers/b", 5000, "10.0.0.2:15443")
+
+	sb := getTestBinding(t, r)
+	if sb == nil || !reflect.DeepEqual(sb.Spec.Endpoints, []string{"10.0.0.1:15443"}) {
+		t.Fatalf("first service not imported: %v", sb)
+	}
+	if collisions := r.collisionsOf("peers/b"); len(collisions) != 1 || collisions[0].Holder != "service default/helloworld of DiscoveryPeer peers/a" {
+		t.Errorf("unexpected collisions of the second peer: %v", collisions)
+	}
+
+	// The held service is imported once the first goes away
+	if err := r.remove(contributor{peer: "peers/a", service: "default/helloworld"}); err != nil {
+		t.Fatalf("Could not remove: %v", err)
+	}
+	sb = getTestBinding(t, r)
+	if sb == nil || !reflect.DeepEqual(sb.Spec.Endpoints, []string{"10.0.0.2:15443"}) {
+		t.Fatalf("second service not imported: %v", sb)
+	}
+	if owner := sb.GetAnnotations()[importedFromAnnotation]; owner != "peers/b" {
+		t.Errorf("binding imported from %q", owner)
+	}
+	if collisions := r.collisionsOf("peers/b"); len(collisions) != 0 {
+		t.Errorf("unexpected collisions: %v", collisions)
+	}
+}
+
+func TestImportMergeEndpoints(t *testing.T) {
+	r := newTestRegistry(t, ConflictMergeEndpoints)
+	applyTestBinding(t, r, "peers/a", 5000, "10.0.0.1:15443")
+	applyTestBinding(t, r, "peers/b", 5000, "10.0.0.2:15443", "10.0.0.1:15443")
+	applyTestBinding(t, r, "peers/c", 6000, "10.0.0.3:15443")
+
+	sb := getTestBinding(t, r)
+	if sb == nil || !reflect.DeepEqual(sb.Spec.Endpoints, []string{"10.0.0.1:15443", "10.0.0.2:15443"}) {
+		t.Fatalf("endpoints not merged: %v", sb)
+	}
+	if merged := sb.GetAnnotations()[mergedFromAnnotation]; merged != "peers/b" {
+		t.Errorf("merged from %q", merged)
+	}
+	if collisions := r.collisionsOf("peers/b"); len(collisions) != 0 {
+		t.Errorf("unexpected collisions of the merged peer: %v", collisions)
+	}
+	// Services with other ports are not merged
+	if collisions := r.collisionsOf("peers/c"); len(collisions) != 1 {
+		t.Errorf("unexpected collisions of the peer with other ports: %v", collisions)
+	}
+
+	if err := r.remove(contributor{peer: "peers/b", service: "default/helloworld"}); err != nil {
+		t.Fatalf("Could not remove: %v", err)
+	}
+	sb = getTestBinding(t, r)
+	if sb == nil || !reflect.DeepEqual(sb.Spec.Endpoints, []string{"10.0.0.1:15443"}) {
+		t.Fatalf("endpoints of the removed service kept: %v", sb)
+	}
+	if _, ok := sb.GetAnnotations()[mergedFromAnnotation]; ok {
+		t.Errorf("merged-from annotation kept")
+	}
+}
+
+func TestImportReject(t *testing.T) {
+	r := newTestRegistry(t, ConflictReject)
+	applyTestBinding(t, r, "peers/a", 5000, "10.0.0.1:15443")
+	if getTestBinding(t, r) == nil {
+		t.Fatalf("single service not imported")
+	}
+
+	applyTestBinding(t, r, "peers/b", 5000, "10.0.0.2:15443")
+	if sb := getTestBinding(t, r); sb != nil {
+		t.Fatalf("conflicting services imported: %v", sb)
+	}
+	for _, peer := range []string{"peers/a", "peers/b"} {
+		if collisions := r.collisionsOf(peer); len(collisions) != 1 {
+			t.Errorf("unexpected collisions of %s: %v", peer, collisions)
+		}
+	}
+
+	if err := r.remove(contributor{peer: "peers/a", service: "default/helloworld"}); err != nil {
+		t.Fatalf("Could not remove: %v", err)
+	}
+	sb := getTestBinding(t, r)
+	if sb == nil || !reflect.DeepEqual(sb.Spec.Endpoints, []string{"10.0.0.2:15443"}) {
+		t.Fatalf("remaining service not imported: %v", sb)
+	}
+}
+
+func TestImportKeepsLocalBinding(t *testing.T) {
+	local := importedBinding("", 5000, "10.0.0.9:15443")
+	local.Annotations = nil
+	r := newTestRegistry(t, ConflictFirstWins, local)
+	applyTestBinding(t, r, "peers/a", 5000, "10.0.0.1:15443")
+
+	sb := getTestBinding(t, r)
+	if sb == nil || !reflect.DeepEqual(sb.Spec.Endpoints, []string{"10.0.0.9:15443"}) {
+		t.Fatalf("local binding overwritten: %v", sb)
+	}
+	if collisions := r.collisionsOf("peers/a"); len(collisions) != 1 || collisions[0].Holder != "local ServiceBinding" {
+		t.Errorf("unexpected collisions: %v", collisions)
+	}
+}
+
+func TestImportRemoveMissing(t *testing.T) {
+	// Imported by peers/a before this registry existed, as after a restart
+	stale := importedBinding("peers/a", 5000, "10.0.0.1:15443")
+	r := newTestRegistry(t, ConflictFirstWins, stale)
+
+	if err := r.removeMissing("peers/a", map[string]bool{"default/helloworld": true}); err != nil {
+		t.Fatalf("Could not remove missing services: %v", err)
+	}
+	if getTestBinding(t, r) == nil {
+		t.Fatalf("binding of a service still exposed deleted")
+	}
+	if err := r.removeMissing("peers/b", nil); err != nil {
+		t.Fatalf("Could not remove missing services: %v", err)
+	}
+	if getTestBinding(t, r) == nil {
+		t.Fatalf("binding of another peer deleted")
+	}
+
+	// Another peer waits for the binding
+	applyTestBinding(t, r, "peers/b", 5000, "10.0.0.2:15443")
+	if err := r.removeMissing("peers/a", nil); err != nil {
+		t.Fatalf("Could not remove missing services: %v", err)
+	}
+	sb := getTestBinding(t, r)
+	if sb == nil || !reflect.DeepEqual(sb.Spec.Endpoints, []string{"10.0.0.2:15443"}) {
+		t.Fatalf("waiting service not imported after the withdrawn one was removed: %v", sb)
+	}
+
+	// Withdrawn services known to the registry are removed too
+	if err := r.removeMissing("peers/b", map[string]bool{}); err != nil {
+		t.Fatalf("Could not remove missing services: %v", err)
+	}
+	if sb := getTestBinding(t, r); sb != nil {
+		t.Fatalf("binding of withdrawn service kept: %v", sb)
+	}
+}
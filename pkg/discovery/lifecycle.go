@@ -0,0 +1,276 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	pb "github.com/istio-ecosystem/emcee/pkg/discovery/api"
+	"github.com/istio-ecosystem/emcee/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+	"istio.io/pkg/log"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// connTimeout bounds the dial of a remote discovery server
+	connTimeout = 10 * time.Second
+
+	// The first retry is after about initialBackoff, doubling up to maxBackoff.  Each wait
+	// is lengthened by up to backoffJitter of itself so peers do not retry in lockstep.
+	initialBackoff = time.Second
+	maxBackoff     = 2 * time.Minute
+	backoffJitter  = 0.5
+
+	// keepaliveTime is how long a connection may be idle before the client pings the
+	// server, keepaliveTimeout how long it waits for the reply before closing it
+	keepaliveTime    = 30 * time.Second
+	keepaliveTimeout = 10 * time.Second
+)
+
+// clientState is a state of a discovery client.  A client goes from connecting to
+// connected, back to connecting through backoff when the connection fails or breaks,
+// and to stopped when it is canceled.
+type clientState int
+
+const (
+	clientConnecting clientState = iota
+	clientConnected
+	clientBackoff
+	clientStopped
+)
+
+func (s clientState) String() string {
+	switch s {
+	case clientConnecting:
+		return "Connecting"
+	case clientConnected:
+		return "Connected"
+	case clientBackoff:
+		return "Backoff"
+	case clientStopped:
+		return "Stopped"
+	}
+	return "Unknown"
+}
+
+var errStreamClosed = errors.New("discovery stream closed by the server")
+
+// discoveryClient is the connection to a remote ESDS server
+type discoveryClient struct {
+	name    string
+	address string
+	// peer configures the connection and the imported bindings
+	peer *mmv1.DiscoveryPeer
+
+	// dialOptions returns the options, such as the transport security, to dial the server
+	dialOptions func(ctx context.Context) ([]grpc.DialOption, error)
+	// apply applies an ESDS response, returning the number of imported services and the
+	// services not imported because their binding name is taken
+	apply func(in *pb.ExposedServicesMessages) (int, []mmv1.ImportCollision, error)
+	// report updates the status of the peer
+	report func(update func(*mmv1.DiscoveryPeerStatus))
+	// backoff is the retry schedule from the last successful response
+	backoff wait.Backoff
+
+	mutex sync.Mutex
+	state clientState
+	// version is the last ESDS version applied
+	version string
+	// discoveredServices maps the imported remote services to their binding
+	discoveredServices map[string]types.NamespacedName
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// defaultBackoff is the jittered exponential schedule of reconnection attempts
+func defaultBackoff() wait.Backoff {
+	return wait.Backoff{
+		Duration: initialBackoff,
+		Factor:   2,
+		Jitter:   backoffJitter,
+		Steps:    32,
+		Cap:      maxBackoff,
+	}
+}
+
+// keepaliveOption detects broken connections to idle servers
+func keepaliveOption() grpc.DialOption {
+	return grpc.WithKeepaliveParams(keepalive.ClientParameters{
+		Time:                keepaliveTime,
+		Timeout:             keepaliveTimeout,
+		PermitWithoutStream: true,
+	})
+}
+
+// getState returns the current state of the client
+func (dc *discoveryClient) getState() clientState {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	return dc.state
+}
+
+func (dc *discoveryClient) setState(state clientState) {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	if dc.state != state {
+		log.Debugf("Discovery client %s: %v -> %v", dc.name, dc.state, state)
+	}
+	dc.state = state
+}
+
+// getVersion returns the last ESDS version applied
+func (dc *discoveryClient) getVersion() string {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	return dc.version
+}
+
+func (dc *discoveryClient) setVersion(version string) {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	dc.version = version
+}
+
+// start runs the client until ctx is done or it is stopped
+func (dc *discoveryClient) start(ctx context.Context) {
+	ctx, dc.cancel = context.WithCancel(ctx)
+	dc.done = make(chan struct{})
+	go dc.run(ctx)
+}
+
+// stop cancels the client and waits until it no longer changes bindings or status
+func (dc *discoveryClient) stop() {
+	if dc.cancel == nil {
+		return
+	}
+	dc.cancel()
+	<-dc.done
+}
+
+// run connects to the server, reconnecting with backoff, until ctx is done
+func (dc *discoveryClient) run(ctx context.Context) {
+	defer close(dc.done)
+	defer dc.setState(clientStopped)
+
+	backoff := dc.backoff
+	for {
+		dc.setState(clientConnecting)
+		dc.report(peerConnecting)
+		err := dc.session(ctx, func() { backoff = dc.backoff })
+		if ctx.Err() != nil {
+			return
+		}
+		dc.report(peerDisconnected(err))
+		metrics.ConnectionError(dc.name)
+
+		dc.setState(clientBackoff)
+		delay := backoff.Step()
+		log.Infof("Discovery client %s: %v; reconnecting to %s in %v", dc.name, err, dc.address, delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// session dials the server and applies its responses until the stream breaks or ctx
+// is done.  It calls healthy once a response is received.
+func (dc *discoveryClient) session(ctx context.Context, healthy func()) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	opts, err := dc.dialOptions(ctx)
+	if err != nil {
+		return err
+	}
+	dialCtx, dialCancel := context.WithTimeout(ctx, connTimeout)
+	conn, err := grpc.DialContext(dialCtx, dc.address, append(opts, grpc.WithBlock())...)
+	dialCancel()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stream, err := pb.NewESDSClient(conn).ExposedServicesDiscovery(ctx)
+	if err != nil {
+		return err
+	}
+	dc.setState(clientConnected)
+	dc.report(peerConnected)
+	metrics.PeerConnected(dc.name, true)
+	defer metrics.PeerConnected(dc.name, false)
+
+	request := pb.ExposedServicesMessages{
+		Name:    "Request from client",
+		Cluster: clusterName,
+		Delta:   true,
+	}
+	if err := stream.Send(&request); err != nil {
+		return err
+	}
+
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return errStreamClosed
+		}
+		if err != nil {
+			return err
+		}
+		healthy()
+		log.Infof("Received ESDS Discovery message: <%v>", in)
+
+		imported, collisions, err := dc.apply(in)
+		if err == nil {
+			dc.setVersion(in.GetVersionInfo())
+			log.Infof("Processed ESDS Discovery message version %s", in.GetVersionInfo())
+		} else {
+			log.Warnf("Failed to apply ESDS Discovery message version %s: %v", in.GetVersionInfo(), err)
+		}
+		dc.report(peerMessage(imported, collisions, err))
+		metrics.PushReceived(dc.name, err)
+		metrics.ImportedBindings(dc.name, imported)
+		if in.GetNonce() == "" {
+			// The server predates ACKs
+			continue
+		}
+		ack := pb.ExposedServicesMessages{
+			Name:        request.Name,
+			Cluster:     request.Cluster,
+			Delta:       request.Delta,
+			VersionInfo: dc.getVersion(),
+			Nonce:       in.GetNonce(),
+		}
+		if err != nil {
+			ack.ErrorDetail = err.Error()
+		}
+		if err := stream.Send(&ack); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	pb "github.com/istio-ecosystem/emcee/pkg/discovery/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// fakeESDS sends one response on each stream and closes it once the response is ACKed
+type fakeESDS struct {
+	mutex   sync.Mutex
+	streams int
+	acks    []string
+}
+
+func (f *fakeESDS) ExposedServicesDiscovery(stream pb.ESDS_ExposedServicesDiscoveryServer) error {
+	f.mutex.Lock()
+	f.streams++
+	f.mutex.Unlock()
+
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+	if err := stream.Send(&pb.ExposedServicesMessages{VersionInfo: "1", Nonce: "n1"}); err != nil {
+		return err
+	}
+	ack, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	f.acks = append(f.acks, ack.GetNonce())
+	f.mutex.Unlock()
+	return nil
+}
+
+func (f *fakeESDS) counts() (int, int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.streams, len(f.acks)
+}
+
+func testBackoff() wait.Backoff {
+	return wait.Backoff{Duration: time.Millisecond, Factor: 2, Jitter: backoffJitter, Steps: 5, Cap: 10 * time.Millisecond}
+}
+
+func startFakeESDS() (*fakeESDS, *bufconn.Listener, *grpc.Server) {
+	lis := bufconn.Listen(1 << 16)
+	s := grpc.NewServer()
+	fake := &fakeESDS{}
+	pb.RegisterESDSServer(s, fake)
+	go s.Serve(lis)
+	return fake, lis, s
+}
+
+func newTestClient(dialOptions func(context.Context) ([]grpc.DialOption, error)) (*discoveryClient, *int) {
+	var mutex sync.Mutex
+	applied := 0
+	dc := &discoveryClient{
+		name:        "test/peer",
+		address:     "bufnet",
+		dialOptions: dialOptions,
+		apply: func(in *pb.ExposedServicesMessages) (int, []mmv1.ImportCollision, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			applied++
+			return 0, nil, nil
+		},
+		report:  func(func(*mmv1.DiscoveryPeerStatus)) {},
+		backoff: testBackoff(),
+	}
+	return dc, &applied
+}
+
+func TestClientReconnectsClosedStream(t *testing.T) {
+	fake, lis, s := startFakeESDS()
+	defer s.Stop()
+	dc, _ := newTestClient(func(context.Context) ([]grpc.DialOption, error) {
+		return []grpc.DialOption{
+			grpc.WithInsecure(),
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+				return lis.Dial()
+			}),
+		}, nil
+	})
+
+	dc.start(context.Background())
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if streams, acks := fake.counts(); streams >= 3 && acks >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			streams, acks := fake.counts()
+			t.Fatalf("client did not reconnect: %d streams, %d ACKs", streams, acks)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	dc.stop()
+
+	if state := dc.getState(); state != clientStopped {
+		t.Errorf("state after stop is %v", state)
+	}
+	if version := dc.getVersion(); version != "1" {
+		t.Errorf("applied version is %q", version)
+	}
+}
+
+func TestClientStopsDuringBackoff(t *testing.T) {
+	attempts := make(chan struct{}, 100)
+	dc, applied := newTestClient(func(context.Context) ([]grpc.DialOption, error) {
+		attempts <- struct{}{}
+		return nil, errors.New("no credentials")
+	})
+	dc.backoff = wait.Backoff{Duration: time.Hour}
+
+	dc.start(context.Background())
+	<-attempts
+	stopped := make(chan struct{})
+	go func() {
+		dc.stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not stop during backoff")
+	}
+
+	if state := dc.getState(); state != clientStopped {
+		t.Errorf("state after stop is %v", state)
+	}
+	if len(attempts) != 0 || *applied != 0 {
+		t.Errorf("client kept running after the first attempt")
+	}
+}
+
+func TestBackoffIsCappedAndJittered(t *testing.T) {
+	b := defaultBackoff()
+	var last time.Duration
+	for i := 0; i < 20; i++ {
+		last = b.Step()
+	}
+	if last < maxBackoff || last > time.Duration(float64(maxBackoff)*(1+backoffJitter)) {
+		t.Errorf("backoff after 20 attempts is %v", last)
+	}
+}
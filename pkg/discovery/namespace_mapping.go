@@ -0,0 +1,64 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"strings"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// mapNamespace returns the local namespace of services in remote namespace ns of the named
+// peer.  The first matching rule applies, searching each list of rules in turn.
+func mapNamespace(ns, peer string, rules ...[]mmv1.NamespaceMappingRule) string {
+	expand := strings.NewReplacer("{peer}", peer, "{namespace}", ns).Replace
+	for _, list := range rules {
+		for _, rule := range list {
+			if !namespaceMatches(ns, rule.From) {
+				continue
+			}
+			if rule.To != "" {
+				return expand(rule.To)
+			}
+			return expand(rule.Prefix) + ns + expand(rule.Suffix)
+		}
+	}
+	return ns
+}
+
+// namespaceMatches is true if ns is from, or from is * or ends with * and prefixes ns
+func namespaceMatches(ns, from string) bool {
+	if strings.HasSuffix(from, "*") {
+		return strings.HasPrefix(ns, strings.TrimSuffix(from, "*"))
+	}
+	return ns == from
+}
+
+// meshFedConfigRules returns the namespace mapping rules of the MeshFedConfigs matching selector
+func meshFedConfigRules(mfcs []mmv1.MeshFedConfig, selector map[string]string) []mmv1.NamespaceMappingRule {
+	if len(selector) == 0 {
+		return nil
+	}
+	var rules []mmv1.NamespaceMappingRule
+	s := labels.SelectorFromSet(selector)
+	for _, mfc := range mfcs {
+		if s.Matches(labels.Set(mfc.GetLabels())) {
+			rules = append(rules, mfc.Spec.NamespaceMapping...)
+		}
+	}
+	return rules
+}
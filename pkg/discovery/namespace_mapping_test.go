@@ -0,0 +1,52 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"testing"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+)
+
+func TestMapNamespace(t *testing.T) {
+	peerRules := []mmv1.NamespaceMappingRule{
+		{From: "default", To: "cluster2-default"},
+		{From: "team-*", Prefix: "{peer}-"},
+		{From: "legacy", Suffix: "-old"},
+	}
+	mfcRules := []mmv1.NamespaceMappingRule{
+		{From: "default", To: "unused"},
+		{From: "*", To: "federated-{peer}"},
+	}
+	cases := []struct {
+		ns       string
+		rules    [][]mmv1.NamespaceMappingRule
+		expected string
+	}{
+		{ns: "default", expected: "default"},
+		{ns: "default", rules: [][]mmv1.NamespaceMappingRule{peerRules, mfcRules}, expected: "cluster2-default"},
+		{ns: "team-a", rules: [][]mmv1.NamespaceMappingRule{peerRules, mfcRules}, expected: "cluster2-team-a"},
+		{ns: "legacy", rules: [][]mmv1.NamespaceMappingRule{peerRules}, expected: "legacy-old"},
+		{ns: "other", rules: [][]mmv1.NamespaceMappingRule{peerRules}, expected: "other"},
+		{ns: "other", rules: [][]mmv1.NamespaceMappingRule{peerRules, mfcRules}, expected: "federated-cluster2"},
+		{ns: "shop", rules: [][]mmv1.NamespaceMappingRule{{{From: "shop", To: "{peer}-{namespace}"}}}, expected: "cluster2-shop"},
+	}
+	for _, c := range cases {
+		if actual := mapNamespace(c.ns, "cluster2", c.rules...); actual != c.expected {
+			t.Errorf("%q with rules %v mapped to %q, expected %q", c.ns, c.rules, actual, c.expected)
+		}
+	}
+}
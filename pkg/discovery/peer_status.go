@@ -0,0 +1,104 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"istio.io/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// updatePeerStatus applies update to the status of the DiscoveryPeer named namespace/name
+func updatePeerStatus(cli client.Client, name string, update func(*mmv1.DiscoveryPeerStatus)) {
+	ns, n, err := getNamespceAndName(name)
+	if err != nil {
+		log.Warnf("Not updating status of discovery peer %q: %v", name, err)
+		return
+	}
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var peer mmv1.DiscoveryPeer
+		if err := cli.Get(context.Background(), types.NamespacedName{Namespace: ns, Name: n}, &peer); err != nil {
+			return err
+		}
+		update(&peer.Status)
+		return cli.Status().Update(context.Background(), &peer)
+	})
+	if err != nil && !apierrs.IsNotFound(err) {
+		log.Warnf("Could not update status of discovery peer %s: %v", name, err)
+	}
+}
+
+// peerConnecting records that a connection is being established
+func peerConnecting(status *mmv1.DiscoveryPeerStatus) {
+	status.ConnectionState = mmv1.PeerConnecting
+}
+
+// peerConnected records an established connection
+func peerConnected(status *mmv1.DiscoveryPeerStatus) {
+	status.ConnectionState = mmv1.PeerConnected
+	status.LastError = ""
+	mmv1.SetCondition(&status.Conditions, mmv1.ConditionConnected, corev1.ConditionTrue, mmv1.ReasonConnected, "")
+	mmv1.SetCondition(&status.Conditions, mmv1.ConditionReady, corev1.ConditionTrue, mmv1.ReasonAllConditionsSatisfied, "")
+}
+
+// peerDisconnected returns an update recording a lost or failed connection
+func peerDisconnected(err error) func(*mmv1.DiscoveryPeerStatus) {
+	return func(status *mmv1.DiscoveryPeerStatus) {
+		message := ""
+		if err != nil {
+			message = err.Error()
+			status.LastError = message
+		}
+		status.ConnectionState = mmv1.PeerDisconnected
+		mmv1.SetCondition(&status.Conditions, mmv1.ConditionConnected, corev1.ConditionFalse, mmv1.ReasonConnectFailed, message)
+		mmv1.SetCondition(&status.Conditions, mmv1.ConditionReady, corev1.ConditionFalse, mmv1.ReasonDependenciesNotReady,
+			"not true: "+string(mmv1.ConditionConnected))
+	}
+}
+
+// peerMessage returns an update recording a received discovery message and its outcome
+func peerMessage(imported int, collisions []mmv1.ImportCollision, err error) func(*mmv1.DiscoveryPeerStatus) {
+	return func(status *mmv1.DiscoveryPeerStatus) {
+		now := metav1.Now()
+		status.LastMessageTime = &now
+		status.ImportedBindings = int32(imported)
+		setCollisions(status, collisions)
+		if err != nil {
+			status.LastError = err.Error()
+		} else {
+			status.LastError = ""
+		}
+	}
+}
+
+// setCollisions records the services of the peer whose ServiceBinding is held by something else
+func setCollisions(status *mmv1.DiscoveryPeerStatus, collisions []mmv1.ImportCollision) {
+	status.Collisions = collisions
+	if len(collisions) == 0 {
+		mmv1.SetCondition(&status.Conditions, mmv1.ConditionImportConflict, corev1.ConditionFalse, mmv1.ReasonNoCollisions, "")
+		return
+	}
+	mmv1.SetCondition(&status.Conditions, mmv1.ConditionImportConflict, corev1.ConditionTrue, mmv1.ReasonNameCollision,
+		fmt.Sprintf("%d services not imported; see collisions", len(collisions)))
+}
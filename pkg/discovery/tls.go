@@ -0,0 +1,311 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"istio.io/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const caCertKey = "ca.crt"
+
+// TLSOptions configures mutual TLS for the ESDS channel
+type TLSOptions struct {
+	// Enabled turns on mutual TLS for the server and all clients
+	Enabled bool
+	// CertFile, KeyFile and CAFile, if set, are used instead of a Secret
+	CertFile string
+	KeyFile  string
+	CAFile   string
+	// Secret, as namespace/name, holding tls.crt, tls.key and ca.crt.  If neither files
+	// nor Secret are set the Secret named by a MeshFedConfig's discovery_tls is used.
+	Secret string
+	// AllowedIdentities are the DNS SANs or SPIFFE IDs peers may present, in addition
+	// to those listed by MeshFedConfigs.  Peers are rejected if there are none; "*"
+	// accepts any peer with a certificate signed by the CA.
+	AllowedIdentities []string
+}
+
+// Credentials provides the TLS material for the ESDS server and clients.  The material
+// is read on every handshake so rotated certificates are picked up without a restart.
+type Credentials struct {
+	opts TLSOptions
+	cli  client.Client
+	// peer credentials do not use the MeshFedConfigs' discovery_tls
+	peer bool
+}
+
+// tlsMaterial is a certificate, the CA that signs peer certificates and the allowed peer identities
+type tlsMaterial struct {
+	cert    tls.Certificate
+	roots   *x509.CertPool
+	allowed []string
+}
+
+// NewCredentials creates ESDS credentials.  cli is used to read Secrets and MeshFedConfigs.
+func NewCredentials(opts TLSOptions, cli client.Client) *Credentials {
+	return &Credentials{
+		opts: opts,
+		cli:  cli,
+	}
+}
+
+// forPeer returns the credentials for a DiscoveryPeer in namespace with peerTLS, or c if peerTLS is nil
+func (c *Credentials) forPeer(namespace string, peerTLS *mmv1.DiscoveryTLS) *Credentials {
+	if peerTLS == nil {
+		return c
+	}
+	var cli client.Client
+	if c != nil {
+		cli = c.cli
+	}
+	return &Credentials{
+		opts: TLSOptions{
+			Enabled:           true,
+			Secret:            namespace + "/" + peerTLS.SecretName,
+			AllowedIdentities: peerTLS.AllowedIdentities,
+		},
+		cli:  cli,
+		peer: true,
+	}
+}
+
+func (c *Credentials) enabled() bool {
+	return c != nil && c.opts.Enabled
+}
+
+// serverOptions returns the options securing the ESDS server
+func (c *Credentials) serverOptions() []grpc.ServerOption {
+	if !c.enabled() {
+		log.Warnf("ESDS server is not using TLS")
+		return nil
+	}
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			m, err := c.material(context.Background())
+			if err != nil {
+				log.Warnf("ESDS: no TLS material for handshake: %v", err)
+				return nil, err
+			}
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{m.cert},
+				ClientCAs:    m.roots,
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				VerifyPeerCertificate: func(_ [][]byte, chains [][]*x509.Certificate) error {
+					if len(chains) == 0 || len(chains[0]) == 0 {
+						return errors.New("no verified client certificate")
+					}
+					return verifyIdentity(chains[0][0], m.allowed)
+				},
+			}, nil
+		},
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(config))}
+}
+
+// dialOption returns the option securing a connection to a remote ESDS server
+func (c *Credentials) dialOption(ctx context.Context) (grpc.DialOption, error) {
+	if !c.enabled() {
+		return grpc.WithInsecure(), nil
+	}
+	m, err := c.material(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{m.cert},
+		// Peers are dialed by address, so the standard host name check cannot apply.
+		// The chain and the peer identity are verified below instead.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyServer(rawCerts, m)
+		},
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(config)), nil
+}
+
+// material loads the certificate and CA from files, the flag's Secret or the MeshFedConfig's Secret
+func (c *Credentials) material(ctx context.Context) (*tlsMaterial, error) {
+	var certPEM, keyPEM, caPEM []byte
+	var err error
+
+	mfcSecret, mfcAllowed, err := c.meshFedConfigTLS(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c.opts.CertFile != "" || c.opts.KeyFile != "" || c.opts.CAFile != "":
+		if certPEM, err = ioutil.ReadFile(c.opts.CertFile); err != nil {
+			return nil, err
+		}
+		if keyPEM, err = ioutil.ReadFile(c.opts.KeyFile); err != nil {
+			return nil, err
+		}
+		if caPEM, err = ioutil.ReadFile(c.opts.CAFile); err != nil {
+			return nil, err
+		}
+	case c.opts.Secret != "":
+		ns, n, err := getNamespceAndName(c.opts.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("ESDS TLS secret %q is not namespace/name", c.opts.Secret)
+		}
+		if certPEM, keyPEM, caPEM, err = c.secretPEM(ctx, types.NamespacedName{Namespace: ns, Name: n}); err != nil {
+			return nil, err
+		}
+	case mfcSecret != nil:
+		if certPEM, keyPEM, caPEM, err = c.secretPEM(ctx, *mfcSecret); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("no ESDS TLS certificate configured by flags or MeshFedConfig")
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("no CA certificates found for ESDS TLS")
+	}
+	return &tlsMaterial{
+		cert:    cert,
+		roots:   roots,
+		allowed: append(append([]string{}, c.opts.AllowedIdentities...), mfcAllowed...),
+	}, nil
+}
+
+// meshFedConfigTLS returns the Secret and allowed identities given by MeshFedConfigs' discovery_tls
+func (c *Credentials) meshFedConfigTLS(ctx context.Context) (*types.NamespacedName, []string, error) {
+	if c.cli == nil || c.peer {
+		return nil, nil, nil
+	}
+	var list mmv1.MeshFedConfigList
+	if err := c.cli.List(ctx, &list); err != nil {
+		return nil, nil, err
+	}
+	var secret *types.NamespacedName
+	var allowed []string
+	for _, mfc := range list.Items {
+		if mfc.Spec.DiscoveryTLS == nil {
+			continue
+		}
+		nsn := types.NamespacedName{Namespace: mfc.GetNamespace(), Name: mfc.Spec.DiscoveryTLS.SecretName}
+		if secret != nil && *secret != nsn {
+			return nil, nil, fmt.Errorf("MeshFedConfigs name different ESDS TLS secrets %v and %v", *secret, nsn)
+		}
+		secret = &nsn
+		allowed = append(allowed, mfc.Spec.DiscoveryTLS.AllowedIdentities...)
+	}
+	return secret, allowed, nil
+}
+
+func (c *Credentials) secretPEM(ctx context.Context, nsn types.NamespacedName) ([]byte, []byte, []byte, error) {
+	if c.cli == nil {
+		return nil, nil, nil, errors.New("no client to read ESDS TLS secret")
+	}
+	var secret corev1.Secret
+	if err := c.cli.Get(ctx, nsn, &secret); err != nil {
+		return nil, nil, nil, err
+	}
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey, caCertKey} {
+		if len(secret.Data[key]) == 0 {
+			return nil, nil, nil, fmt.Errorf("ESDS TLS secret %v has no %s", nsn, key)
+		}
+	}
+	return secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey], secret.Data[caCertKey], nil
+}
+
+// verifyServer verifies a server's chain against the CA and its identity against the allowed list
+func verifyServer(rawCerts [][]byte, m *tlsMaterial) error {
+	if len(rawCerts) == 0 {
+		return errors.New("no server certificate")
+	}
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         m.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}); err != nil {
+		return err
+	}
+	return verifyIdentity(certs[0], m.allowed)
+}
+
+// verifyIdentity checks that one of the certificate's DNS SANs or URI SANs is allowed.  Any
+// certificate signed by the CA is only accepted if that is asked for with "*".
+func verifyIdentity(cert *x509.Certificate, allowed []string) error {
+	if len(allowed) == 0 {
+		return errors.New("no ESDS peer identities are allowed; list them, or * to allow any peer signed by the CA")
+	}
+	ids := certIdentities(cert)
+	for _, id := range ids {
+		if identityAllowed(id, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("peer identities %v are not allowed", ids)
+}
+
+// certIdentities returns the DNS SANs and URI SANs, such as SPIFFE IDs, of a certificate
+func certIdentities(cert *x509.Certificate) []string {
+	ids := append([]string{}, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		ids = append(ids, uri.String())
+	}
+	return ids
+}
+
+func identityAllowed(id string, allowed []string) bool {
+	for _, a := range allowed {
+		if strings.HasSuffix(a, "*") {
+			if strings.HasPrefix(id, strings.TrimSuffix(a, "*")) {
+				return true
+			}
+		} else if id == a {
+			return true
+		}
+	}
+	return false
+}
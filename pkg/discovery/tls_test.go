@@ -0,0 +1,248 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	pb "github.com/istio-ecosystem/emcee/pkg/discovery/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/test/bufconn"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testServerName = "esds.cluster1.example.com"
+	testClientID   = "spiffe://cluster2.example.com/ns/emcee-system/sa/emcee"
+)
+
+// testCA signs the certificates of a test
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+	// serial of the last issued certificate
+	serial int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "emcee test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{
+		cert:   cert,
+		key:    key,
+		pem:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		serial: 1,
+	}
+}
+
+// issue returns the PEM certificate and key of an identity, a DNS name or a URI
+func (ca *testCA) issue(t *testing.T, id string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca.serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      pkix.Name{CommonName: id},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	if u, err := url.Parse(id); err == nil && u.Scheme != "" {
+		template.URIs = []*url.URL{u}
+	} else {
+		template.DNSNames = []string{id}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// secret holds an issued certificate in the format of the ESDS TLS Secrets
+func (ca *testCA) secret(t *testing.T, name, id string, usage x509.ExtKeyUsage) *corev1.Secret {
+	certPEM, keyPEM := ca.issue(t, id, usage)
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "emcee-system"},
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+			caCertKey:               ca.pem,
+		},
+	}
+}
+
+func newTestTLSClient(t *testing.T, objs ...runtime.Object) client.Client {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	if err := mmv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	return fake.NewFakeClientWithScheme(scheme, objs...)
+}
+
+// exchange runs one ESDS request and response over a bufconn listener
+func exchange(t *testing.T, server []grpc.ServerOption, dialOption grpc.DialOption) error {
+	lis := bufconn.Listen(1 << 16)
+	s := grpc.NewServer(server...)
+	pb.RegisterESDSServer(s, &fakeESDS{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "bufnet", dialOption,
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stream, err := pb.NewESDSClient(conn).ExposedServicesDiscovery(ctx)
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(&pb.ExposedServicesMessages{}); err != nil {
+		return err
+	}
+	_, err = stream.Recv()
+	return err
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	other := newTestCA(t)
+	cli := newTestTLSClient(t,
+		ca.secret(t, "server", testServerName, x509.ExtKeyUsageServerAuth),
+		ca.secret(t, "client", testClientID, x509.ExtKeyUsageClientAuth),
+		ca.secret(t, "intruder", "spiffe://intruder.example.com/ns/default/sa/default", x509.ExtKeyUsageClientAuth),
+		other.secret(t, "untrusted", testClientID, x509.ExtKeyUsageClientAuth))
+
+	cases := []struct {
+		name          string
+		clientSecret  string
+		serverAllowed []string
+		clientAllowed []string
+		ok            bool
+	}{
+		{name: "allowed identity", clientSecret: "client", serverAllowed: []string{testClientID}, clientAllowed: []string{testServerName}, ok: true},
+		{name: "SPIFFE wildcard", clientSecret: "client", serverAllowed: []string{"spiffe://cluster2.example.com/ns/emcee-system/*"}, clientAllowed: []string{"esds.*"}, ok: true},
+		{name: "any identity", clientSecret: "client", serverAllowed: []string{"*"}, clientAllowed: []string{"*"}, ok: true},
+		{name: "rejected client identity", clientSecret: "intruder", serverAllowed: []string{testClientID}, clientAllowed: []string{testServerName}},
+		{name: "rejected server identity", clientSecret: "client", serverAllowed: []string{testClientID}, clientAllowed: []string{"esds.cluster3.example.com"}},
+		{name: "no identity allowed", clientSecret: "client", clientAllowed: []string{testServerName}},
+		{name: "untrusted CA", clientSecret: "untrusted", serverAllowed: []string{"*"}, clientAllowed: []string{"*"}},
+	}
+	for _, c := range cases {
+		server := NewCredentials(TLSOptions{Enabled: true, Secret: "emcee-system/server", AllowedIdentities: c.serverAllowed}, cli)
+		client := NewCredentials(TLSOptions{Enabled: true, Secret: "emcee-system/" + c.clientSecret, AllowedIdentities: c.clientAllowed}, cli)
+		dialOption, err := client.dialOption(context.Background())
+		if err != nil {
+			t.Fatalf("%s: no dial option: %v", c.name, err)
+		}
+		err = exchange(t, server.serverOptions(), dialOption)
+		if c.ok && err != nil {
+			t.Errorf("%s: rejected: %v", c.name, err)
+		}
+		if !c.ok && err == nil {
+			t.Errorf("%s: accepted", c.name)
+		}
+	}
+}
+
+func TestMutualTLSRequiresClientCert(t *testing.T) {
+	ca := newTestCA(t)
+	cli := newTestTLSClient(t, ca.secret(t, "server", testServerName, x509.ExtKeyUsageServerAuth))
+	server := NewCredentials(TLSOptions{Enabled: true, Secret: "emcee-system/server", AllowedIdentities: []string{"*"}}, cli)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	noCert := grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: roots, ServerName: testServerName}))
+	if err := exchange(t, server.serverOptions(), noCert); err == nil {
+		t.Errorf("client without a certificate accepted")
+	}
+}
+
+func TestIdentityAllowed(t *testing.T) {
+	cases := []struct {
+		id      string
+		allowed []string
+		ok      bool
+	}{
+		{id: testClientID, allowed: []string{testClientID}, ok: true},
+		{id: testClientID, allowed: []string{"spiffe://cluster2.example.com/*"}, ok: true},
+		{id: testClientID, allowed: []string{"*"}, ok: true},
+		{id: testClientID, allowed: []string{"spiffe://cluster2.example.com/ns/emcee-system/sa/emcee-other"}},
+		{id: testClientID, allowed: []string{"spiffe://cluster3.example.com/*"}},
+		{id: testClientID},
+		{id: testServerName, allowed: []string{"other.example.com", testServerName}, ok: true},
+	}
+	for _, c := range cases {
+		if actual := identityAllowed(c.id, c.allowed); actual != c.ok {
+			t.Errorf("%q allowed by %v: %v, expected %v", c.id, c.allowed, actual, c.ok)
+		}
+	}
+}
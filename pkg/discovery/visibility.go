@@ -0,0 +1,70 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// peerIdentities returns the verified certificate identities of a peer and whether
+// the peer connected with TLS at all
+func peerIdentities(p *peer.Peer) ([]string, bool) {
+	if p == nil {
+		return nil, false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil, false
+	}
+	chains := tlsInfo.State.VerifiedChains
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return nil, true
+	}
+	return certIdentities(chains[0][0]), true
+}
+
+// exposedTo is true if a peer with the given identities may discover the exposition.
+// The exposition's clusters take precedence over the peers of the MeshFedConfigs it selects.
+func exposedTo(se *mmv1.ServiceExposition, mfcs []mmv1.MeshFedConfig, ids []string) bool {
+	if len(se.Spec.Clusters) > 0 {
+		return anyIdentityAllowed(ids, se.Spec.Clusters)
+	}
+	if len(se.Spec.MeshFedConfigSelector) == 0 {
+		return true
+	}
+	selector := labels.SelectorFromSet(se.Spec.MeshFedConfigSelector)
+	for _, mfc := range mfcs {
+		if len(mfc.Spec.Peers) == 0 || !selector.Matches(labels.Set(mfc.GetLabels())) {
+			continue
+		}
+		if !anyIdentityAllowed(ids, mfc.Spec.Peers) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyIdentityAllowed(ids, allowed []string) bool {
+	for _, id := range ids {
+		if identityAllowed(id, allowed) {
+			return true
+		}
+	}
+	return false
+}
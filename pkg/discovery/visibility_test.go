@@ -0,0 +1,124 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"testing"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	pb "github.com/istio-ecosystem/emcee/pkg/discovery/api"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExposedTo(t *testing.T) {
+	limited := mmv1.MeshFedConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "limited", Labels: map[string]string{"mesh": "limited"}},
+		Spec:       mmv1.MeshFedConfigSpec{Peers: []string{"cluster2"}},
+	}
+	open := mmv1.MeshFedConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "open", Labels: map[string]string{"mesh": "open"}},
+	}
+	mfcs := []mmv1.MeshFedConfig{limited, open}
+	exposition := func(mesh string, clusters ...string) *mmv1.ServiceExposition {
+		se := &mmv1.ServiceExposition{Spec: mmv1.ServiceExpositionSpec{Name: "reviews", Clusters: clusters}}
+		if mesh != "" {
+			se.Spec.MeshFedConfigSelector = map[string]string{"mesh": mesh}
+		}
+		return se
+	}
+
+	cases := []struct {
+		name string
+		se   *mmv1.ServiceExposition
+		ids  []string
+		ok   bool
+	}{
+		{name: "clusters allow", se: exposition("limited", "cluster3"), ids: []string{"cluster3"}, ok: true},
+		{name: "clusters take precedence over peers", se: exposition("limited", "cluster3"), ids: []string{"cluster2"}},
+		{name: "clusters deny without identity", se: exposition("open", "cluster3")},
+		{name: "peers allow", se: exposition("limited"), ids: []string{"cluster2"}, ok: true},
+		{name: "peers deny", se: exposition("limited"), ids: []string{"cluster3"}},
+		{name: "peers deny without identity", se: exposition("limited")},
+		{name: "no peers allow everyone", se: exposition("open"), ids: []string{"cluster3"}, ok: true},
+		{name: "no peers allow anonymous", se: exposition("open"), ok: true},
+		{name: "no selector allows everyone", se: exposition(""), ids: []string{"cluster3"}, ok: true},
+	}
+	for _, c := range cases {
+		if actual := exposedTo(c.se, mfcs, c.ids); actual != c.ok {
+			t.Errorf("%s: exposed to %v: %v, expected %v", c.name, c.ids, actual, c.ok)
+		}
+	}
+}
+
+func TestPeerIdentities(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}
+	spiffe, _ := url.Parse(testClientID)
+	cert := &x509.Certificate{DNSNames: []string{"cluster2.example.com"}, URIs: []*url.URL{spiffe}}
+
+	cases := []struct {
+		name     string
+		peer     *peer.Peer
+		ids      []string
+		verified bool
+	}{
+		{name: "no peer"},
+		{name: "plaintext", peer: &peer.Peer{Addr: addr}},
+		{name: "TLS without client certificate", peer: &peer.Peer{Addr: addr, AuthInfo: credentials.TLSInfo{}}, verified: true},
+		{name: "TLS", peer: &peer.Peer{Addr: addr, AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}},
+		}}}, ids: []string{"cluster2.example.com", testClientID}, verified: true},
+	}
+	for _, c := range cases {
+		ids, verified := peerIdentities(c.peer)
+		if verified != c.verified {
+			t.Errorf("%s: verified %v, expected %v", c.name, verified, c.verified)
+		}
+		if len(ids) != len(c.ids) {
+			t.Errorf("%s: identities %v, expected %v", c.name, ids, c.ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != c.ids[i] {
+				t.Errorf("%s: identities %v, expected %v", c.name, ids, c.ids)
+			}
+		}
+	}
+}
+
+func TestClusterClaim(t *testing.T) {
+	// Without TLS the claimed cluster is the peer's identity, unverified
+	con := newEsdsConnection("10.0.0.1:1234", nil)
+	con.processRequest(&pb.ExposedServicesMessages{Cluster: "cluster2"})
+	if len(con.Identities) != 1 || con.Identities[0] != "cluster2" {
+		t.Errorf("plaintext peer identities %v, expected the claimed cluster", con.Identities)
+	}
+
+	// With TLS the claim never replaces the certificate identities, even if there are none
+	for _, ids := range [][]string{{testClientID}, nil} {
+		con = newEsdsConnection("10.0.0.1:1234", nil)
+		con.Identities, con.verified = ids, true
+		con.processRequest(&pb.ExposedServicesMessages{Cluster: "cluster2"})
+		if len(con.Identities) != len(ids) {
+			t.Errorf("TLS peer identities %v, expected %v", con.Identities, ids)
+		}
+	}
+}
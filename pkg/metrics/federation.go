@@ -0,0 +1,130 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"istio.io/pkg/log"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// listTimeout bounds the lists of a scrape
+const listTimeout = 10 * time.Second
+
+var (
+	federationLabels = []string{"mesh_fed_config", "namespace", "style", "ready"}
+
+	expositionsDesc = prometheus.NewDesc("emcee_service_expositions",
+		"ServiceExpositions of each MeshFedConfig and style, by Ready condition.", federationLabels, nil)
+	bindingsDesc = prometheus.NewDesc("emcee_service_bindings",
+		"ServiceBindings of each MeshFedConfig and style, by Ready condition.", federationLabels, nil)
+)
+
+// federationCollector counts the expositions and bindings of each MeshFedConfig when scraped
+type federationCollector struct {
+	cli client.Reader
+}
+
+// federationKey is the labels of a count.  An exposition or binding whose selector matches
+// no MeshFedConfig is counted with an empty mesh_fed_config.
+type federationKey struct {
+	mfc, namespace, style string
+	ready                 bool
+}
+
+// RegisterFederationCollector registers the counts of expositions and bindings read with cli
+func RegisterFederationCollector(cli client.Reader) error {
+	return ctrlmetrics.Registry.Register(&federationCollector{cli: cli})
+}
+
+// Describe implements prometheus.Collector
+func (c *federationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- expositionsDesc
+	ch <- bindingsDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *federationCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+
+	var mfcs mmv1.MeshFedConfigList
+	if err := c.cli.List(ctx, &mfcs); err != nil {
+		log.Warnf("Metrics: could not list MeshFedConfigs: %v", err)
+		return
+	}
+
+	var ses mmv1.ServiceExpositionList
+	if err := c.cli.List(ctx, &ses); err != nil {
+		log.Warnf("Metrics: could not list ServiceExpositions: %v", err)
+	} else {
+		counts := make(map[federationKey]int)
+		for i := range ses.Items {
+			se := &ses.Items[i]
+			key := keyOf(mfcs.Items, se.Spec.MeshFedConfigSelector)
+			key.ready = mmv1.IsConditionTrue(se.Status.Conditions, mmv1.ConditionReady)
+			counts[key]++
+		}
+		emit(ch, expositionsDesc, counts)
+	}
+
+	var sbs mmv1.ServiceBindingList
+	if err := c.cli.List(ctx, &sbs); err != nil {
+		log.Warnf("Metrics: could not list ServiceBindings: %v", err)
+	} else {
+		counts := make(map[federationKey]int)
+		for i := range sbs.Items {
+			sb := &sbs.Items[i]
+			key := keyOf(mfcs.Items, sb.Spec.MeshFedConfigSelector)
+			key.ready = mmv1.IsConditionTrue(sb.Status.Conditions, mmv1.ConditionReady)
+			counts[key]++
+		}
+		emit(ch, bindingsDesc, counts)
+	}
+}
+
+// keyOf returns the labels of the MeshFedConfig matching selector
+func keyOf(mfcs []mmv1.MeshFedConfig, selector map[string]string) federationKey {
+	if len(selector) == 0 {
+		return federationKey{}
+	}
+	s := labels.SelectorFromSet(selector)
+	for _, mfc := range mfcs {
+		if s.Matches(labels.Set(mfc.GetLabels())) {
+			return federationKey{
+				mfc:       mfc.GetName(),
+				namespace: mfc.GetNamespace(),
+				style:     strings.ToLower(mfc.Spec.Mode),
+			}
+		}
+	}
+	return federationKey{}
+}
+
+func emit(ch chan<- prometheus.Metric, desc *prometheus.Desc, counts map[federationKey]int) {
+	for key, n := range counts {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(n),
+			key.mfc, key.namespace, key.style, strconv.FormatBool(key.ready))
+	}
+}
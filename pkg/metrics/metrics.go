@@ -0,0 +1,147 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics registers the Prometheus metrics of emcee with the controller-runtime
+// registry, which the manager serves on its metrics endpoint
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// PushFull and PushDelta are the types of ESDS responses
+	PushFull  = "full"
+	PushDelta = "delta"
+
+	// OperationCreate and OperationUpdate are the writes of Istio objects
+	OperationCreate = "create"
+	OperationUpdate = "update"
+)
+
+var (
+	esdsConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "emcee_esds_server_connections",
+		Help: "Number of peers connected to the ESDS server.",
+	})
+	esdsPeerConnected = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "emcee_esds_peer_connected",
+		Help: "1 if the discovery client of a DiscoveryPeer has a stream to its ESDS server, else 0.",
+	}, []string{"peer"})
+	esdsPushesSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "emcee_esds_pushes_sent_total",
+		Help: "ESDS responses sent by the server, by type (full or delta).",
+	}, []string{"type"})
+	esdsPushesReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "emcee_esds_pushes_received_total",
+		Help: "ESDS responses received from each DiscoveryPeer, by result (applied or rejected).",
+	}, []string{"peer", "result"})
+	esdsPushLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "emcee_esds_push_duration_seconds",
+		Help:    "Time from sending an ESDS response until the peer ACKs or NACKs it.",
+		Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+	}, []string{"result"})
+	esdsConnectionErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "emcee_esds_connection_errors_total",
+		Help: "Failed or broken connections of the discovery client of each DiscoveryPeer.",
+	}, []string{"peer"})
+	importedBindings = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "emcee_imported_bindings",
+		Help: "ServiceBindings imported from each DiscoveryPeer.",
+	}, []string{"peer"})
+	istioObjectFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "emcee_istio_object_failures_total",
+		Help: "Failed creates and updates of Istio objects, by kind and operation.",
+	}, []string{"kind", "operation"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		esdsConnections,
+		esdsPeerConnected,
+		esdsPushesSent,
+		esdsPushesReceived,
+		esdsPushLatency,
+		esdsConnectionErrors,
+		importedBindings,
+		istioObjectFailures,
+	)
+}
+
+// ESDSConnections sets the number of peers connected to the ESDS server
+func ESDSConnections(n int) {
+	esdsConnections.Set(float64(n))
+}
+
+// PeerConnected records whether the client of peer has a stream to its server
+func PeerConnected(peer string, connected bool) {
+	v := 0.0
+	if connected {
+		v = 1
+	}
+	esdsPeerConnected.WithLabelValues(peer).Set(v)
+}
+
+// PushSent counts an ESDS response of type PushFull or PushDelta
+func PushSent(pushType string) {
+	esdsPushesSent.WithLabelValues(pushType).Inc()
+}
+
+// PushReceived counts a response from peer, rejected if err is not nil
+func PushReceived(peer string, err error) {
+	result := "applied"
+	if err != nil {
+		result = "rejected"
+	}
+	esdsPushesReceived.WithLabelValues(peer, result).Inc()
+}
+
+// PushAnswered observes the time since sent of a response the peer ACKed, or NACKed if nack
+func PushAnswered(sent time.Time, nack bool) {
+	result := "ack"
+	if nack {
+		result = "nack"
+	}
+	esdsPushLatency.WithLabelValues(result).Observe(time.Since(sent).Seconds())
+}
+
+// ConnectionError counts a failed or broken connection of the client of peer
+func ConnectionError(peer string) {
+	esdsConnectionErrors.WithLabelValues(peer).Inc()
+}
+
+// ImportedBindings sets the number of bindings imported from peer
+func ImportedBindings(peer string, n int) {
+	importedBindings.WithLabelValues(peer).Set(float64(n))
+}
+
+// ForgetPeer drops the series of a deleted DiscoveryPeer
+func ForgetPeer(peer string) {
+	esdsPeerConnected.DeleteLabelValues(peer)
+	esdsConnectionErrors.DeleteLabelValues(peer)
+	importedBindings.DeleteLabelValues(peer)
+	esdsPushesReceived.DeleteLabelValues(peer, "applied")
+	esdsPushesReceived.DeleteLabelValues(peer, "rejected")
+}
+
+// IstioWrite counts a failed OperationCreate or OperationUpdate of an Istio object of kind
+func IstioWrite(kind, operation string, err error) {
+	if err != nil {
+		istioObjectFailures.WithLabelValues(kind, operation).Inc()
+	}
+}
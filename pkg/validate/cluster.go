@@ -0,0 +1,316 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/style"
+
+	istiov1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Cluster validates emcee resources against the other resources of a cluster: the
+// MeshFedConfigs they select, the Services they expose or would shadow, and the subsets of
+// DestinationRules.  Its checks come on top of those of MeshConfig, ServiceExposition and
+// ServiceBinding.
+type Cluster struct {
+	// Client reads the resources of the cluster.  Its scheme must include the types of
+	// AddToScheme.
+	Client client.Reader
+}
+
+// AddToScheme adds the types Cluster reads to scheme
+func AddToScheme(scheme *runtime.Scheme) error {
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return err
+	}
+	if err := mmv1.AddToScheme(scheme); err != nil {
+		return err
+	}
+	return istiov1alpha3.AddToScheme(scheme)
+}
+
+// Validate validates a MeshFedConfig, ServiceExposition or ServiceBinding.  Other objects are
+// valid.
+func (c *Cluster) Validate(ctx context.Context, obj runtime.Object) error {
+	switch val := obj.(type) {
+	case *mmv1.MeshFedConfig:
+		return MeshConfig(val.GetName(), val.GetNamespace(), val.Spec)
+	case *mmv1.ServiceExposition:
+		return c.ServiceExposition(ctx, val)
+	case *mmv1.ServiceBinding:
+		return c.ServiceBinding(ctx, val)
+	}
+	return nil
+}
+
+// ServiceExposition validates se, which must select exactly one MeshFedConfig and expose the
+// ports of an existing Service and, if it names one, a subset of a DestinationRule
+func (c *Cluster) ServiceExposition(ctx context.Context, se *mmv1.ServiceExposition) error {
+	name, namespace := se.GetName(), se.GetNamespace()
+	retval := ServiceExposition(name, namespace, se.Spec)
+	if err := c.meshFedConfig(ctx, name, namespace, se.Spec.MeshFedConfigSelector); err != nil {
+		retval = multierror.Append(retval, err)
+	}
+
+	var svc corev1.Service
+	err := c.Client.Get(ctx, types.NamespacedName{Name: se.Spec.Name, Namespace: namespace}, &svc)
+	if apierrs.IsNotFound(err) {
+		retval = multierror.Append(retval, fmt.Errorf("%s/%s: Service %s/%s not found", namespace, name, namespace, se.Spec.Name))
+	} else if err != nil {
+		retval = multierror.Append(retval, err)
+	} else {
+		for _, p := range se.Spec.ServicePorts() {
+			if !servicePortExists(&svc, p.Number) {
+				retval = multierror.Append(retval, fmt.Errorf("%s/%s: Service %s/%s does not expose port %d", namespace, name, namespace, se.Spec.Name, p.Number))
+			}
+		}
+	}
+
+	if se.Spec.Subset != "" {
+		found, err := c.subsetExists(ctx, se.Spec.Name, namespace, se.Spec.Subset)
+		if err != nil {
+			retval = multierror.Append(retval, err)
+		} else if !found {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: subset %q of %s is not defined in a DestinationRule", namespace, name, se.Spec.Subset, se.Spec.Name))
+		}
+	}
+
+	if err := endpoints(name, namespace, se.Spec.Endpoints); err != nil {
+		retval = multierror.Append(retval, err)
+	}
+	return retval
+}
+
+// ServiceBinding validates sb, which must select exactly one MeshFedConfig and whose alias (or
+// name) must not be taken by a Service that was not generated for it
+func (c *Cluster) ServiceBinding(ctx context.Context, sb *mmv1.ServiceBinding) error {
+	name, namespace := sb.GetName(), sb.GetNamespace()
+	retval := ServiceBinding(name, namespace, sb.Spec)
+	if err := c.meshFedConfig(ctx, name, namespace, sb.Spec.MeshFedConfigSelector); err != nil {
+		retval = multierror.Append(retval, err)
+	}
+
+	local := sb.Spec.Alias
+	if local == "" {
+		local = sb.Spec.Name
+	}
+	if local != "" {
+		var svc corev1.Service
+		err := c.Client.Get(ctx, types.NamespacedName{Name: local, Namespace: namespace}, &svc)
+		if err == nil && !generatedFor(&svc, c.bindingUID(ctx, sb)) {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: %q collides with Service %s/%s", namespace, name, local, namespace, local))
+		} else if err != nil && !apierrs.IsNotFound(err) {
+			retval = multierror.Append(retval, err)
+		}
+	}
+
+	if err := endpoints(name, namespace, sb.Spec.Endpoints); err != nil {
+		retval = multierror.Append(retval, err)
+	}
+	return retval
+}
+
+// generatedFor is true if obj was generated for the emcee object with the given UID
+func generatedFor(obj metav1.Object, uid types.UID) bool {
+	owner := obj.GetLabels()[style.OwnerUIDLabel]
+	return owner != "" && owner == string(uid)
+}
+
+// bindingUID is the UID of sb or, if it has not been created yet, of the ServiceBinding it would
+// replace
+func (c *Cluster) bindingUID(ctx context.Context, sb *mmv1.ServiceBinding) types.UID {
+	if sb.GetUID() != "" {
+		return sb.GetUID()
+	}
+	var existing mmv1.ServiceBinding
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: sb.GetName(), Namespace: sb.GetNamespace()}, &existing); err != nil {
+		return ""
+	}
+	return existing.GetUID()
+}
+
+// meshFedConfig requires selector to match exactly one MeshFedConfig, as
+// controllers.GetMeshFedConfig does.  An empty selector is reported by the spec's validation.
+func (c *Cluster) meshFedConfig(ctx context.Context, name, namespace string, selector map[string]string) error {
+	if len(selector) == 0 {
+		return nil
+	}
+	var mfcs mmv1.MeshFedConfigList
+	if err := c.Client.List(ctx, &mfcs, client.MatchingLabels(selector)); err != nil {
+		return err
+	}
+	switch len(mfcs.Items) {
+	case 0:
+		return fmt.Errorf("%s/%s: mesh_fed_config_selector %v matches no MeshFedConfig", namespace, name, selector)
+	case 1:
+		return nil
+	}
+	matches := make([]string, 0, len(mfcs.Items))
+	for _, mfc := range mfcs.Items {
+		matches = append(matches, mfc.GetNamespace()+"/"+mfc.GetName())
+	}
+	sort.Strings(matches)
+	return fmt.Errorf("%s/%s: mesh_fed_config_selector %v matches %d MeshFedConfigs: %s",
+		namespace, name, selector, len(matches), strings.Join(matches, ", "))
+}
+
+// subsetExists looks for subset in the DestinationRules of namespace for the Service svcName
+func (c *Cluster) subsetExists(ctx context.Context, svcName, namespace, subset string) (bool, error) {
+	var drs istiov1alpha3.DestinationRuleList
+	if err := c.Client.List(ctx, &drs, client.InNamespace(namespace)); err != nil {
+		return false, err
+	}
+	for _, dr := range drs.Items {
+		if !hostMatches(dr.Spec.Host, svcName, namespace) {
+			continue
+		}
+		for _, s := range dr.Spec.Subsets {
+			if s != nil && s.Name == subset {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// hostMatches is true if host, as written in a DestinationRule of namespace, is the Service svcName
+func hostMatches(host, svcName, namespace string) bool {
+	switch host {
+	case svcName, svcName + "." + namespace, svcName + "." + namespace + ".svc", svcName + "." + namespace + ".svc.cluster.local":
+		return true
+	}
+	return false
+}
+
+func servicePortExists(svc *corev1.Service, port uint32) bool {
+	for _, p := range svc.Spec.Ports {
+		if uint32(p.Port) == port {
+			return true
+		}
+	}
+	return false
+}
+
+// endpoints requires each endpoint to be an IPv4 or IPv6 address and a port, for example
+// 192.0.2.1:15443 or [2001:db8::1]:15443
+func endpoints(name, namespace string, eps []string) error {
+	var retval error
+	for _, ep := range eps {
+		host, port, err := net.SplitHostPort(ep)
+		if err != nil {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: invalid endpoint %q: %v", namespace, name, ep, err))
+			continue
+		}
+		if net.ParseIP(host) == nil {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: endpoint %q is not an IPv4 or IPv6 address", namespace, name, ep))
+		}
+		if n, err := strconv.ParseUint(port, 10, 32); err != nil || n == 0 || n > maxPort {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: endpoint %q has invalid port %q", namespace, name, ep, port))
+		}
+	}
+	return retval
+}
+
+// Bundle validates the resources of a multi-document YAML stream against each other, as
+// Cluster would against a cluster holding them.  The stream is read with Decode.
+func Bundle(ctx context.Context, in io.Reader) error {
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		return err
+	}
+	objs, err := Decode(scheme, in)
+	if err != nil {
+		return err
+	}
+
+	c := Cluster{Client: fake.NewFakeClientWithScheme(scheme, objs...)}
+	var retval error
+	for _, obj := range objs {
+		if err := c.Validate(ctx, obj); err != nil {
+			retval = multierror.Append(retval, err)
+		}
+	}
+	return retval
+}
+
+// Decode decodes the objects of a multi-document YAML stream into the types scheme knows.  Objects
+// without a namespace are put in the default namespace, and those of other kinds are skipped.
+func Decode(scheme *runtime.Scheme, in io.Reader) ([]runtime.Object, error) {
+	var retval []runtime.Object
+	decoder := kubeyaml.NewYAMLOrJSONDecoder(in, 512*1024)
+	for {
+		var u unstructured.Unstructured
+		err := decoder.Decode(&u.Object)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse: %v", err)
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+
+		obj, err := scheme.New(u.GroupVersionKind())
+		if runtime.IsNotRegisteredError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Re-encode, so that the Istio types decode their specs with jsonpb
+		str, err := json.Marshal(u.Object)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(str, obj); err != nil {
+			return nil, fmt.Errorf("cannot decode %s %s: %v", u.GetKind(), u.GetName(), err)
+		}
+
+		om, ok := obj.(metav1.Object)
+		if !ok {
+			continue
+		}
+		if _, isNamespace := obj.(*corev1.Namespace); !isNamespace && om.GetNamespace() == "" {
+			om.SetNamespace(metav1.NamespaceDefault)
+		}
+		om.SetResourceVersion("")
+		retval = append(retval, obj)
+	}
+	return retval, nil
+}
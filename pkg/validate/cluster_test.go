@@ -0,0 +1,282 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/style"
+	istioapi "istio.io/api/networking/v1alpha3"
+	istiov1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	// The styles whose validators the tests run
+	_ "github.com/istio-ecosystem/emcee/style/builtin"
+)
+
+var testSelector = map[string]string{"fed-config": "fed"}
+
+func newTestCluster(t *testing.T, objs ...runtime.Object) *Cluster {
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	objs = append(objs,
+		&mmv1.MeshFedConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "fed", Namespace: "mesh-system", Labels: testSelector},
+			Spec:       mmv1.MeshFedConfigSpec{Mode: "PASSTHROUGH", UseIngressGateway: true},
+		},
+		&mmv1.MeshFedConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "fed-a", Namespace: "mesh-system", Labels: map[string]string{"fed-config": "dup"}},
+			Spec:       mmv1.MeshFedConfigSpec{Mode: "PASSTHROUGH"},
+		},
+		&mmv1.MeshFedConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "fed-b", Namespace: "mesh-system", Labels: map[string]string{"fed-config": "dup"}},
+			Spec:       mmv1.MeshFedConfigSpec{Mode: "PASSTHROUGH"},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "helloworld", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 5000}}},
+		},
+		&istiov1alpha3.DestinationRule{
+			ObjectMeta: metav1.ObjectMeta{Name: "helloworld", Namespace: "shop"},
+			Spec: istioapi.DestinationRule{
+				Host:    "helloworld.shop.svc.cluster.local",
+				Subsets: []*istioapi.Subset{{Name: "v1"}},
+			},
+		})
+	return &Cluster{Client: fake.NewFakeClientWithScheme(scheme, objs...)}
+}
+
+// expectErrors checks that err mentions each of want, or is nil if there is none
+func expectErrors(t *testing.T, name string, err error, want ...string) {
+	t.Helper()
+	if len(want) == 0 {
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		return
+	}
+	if err == nil {
+		t.Errorf("%s: no error, expected %q", name, want)
+		return
+	}
+	for _, w := range want {
+		if !strings.Contains(err.Error(), w) {
+			t.Errorf("%s: error %q does not mention %q", name, err, w)
+		}
+	}
+}
+
+func TestClusterServiceExposition(t *testing.T) {
+	c := newTestCluster(t)
+	cases := []struct {
+		name string
+		spec mmv1.ServiceExpositionSpec
+		want []string
+	}{
+		{
+			name: "valid",
+			spec: mmv1.ServiceExpositionSpec{Name: "helloworld", Port: 5000, Subset: "v1", MeshFedConfigSelector: testSelector},
+		},
+		{
+			name: "IPv6 endpoint",
+			spec: mmv1.ServiceExpositionSpec{Name: "helloworld", Port: 5000, MeshFedConfigSelector: testSelector,
+				Endpoints: []string{"[2001:db8::20]:15443"}},
+		},
+		{
+			name: "IPv6 endpoint without brackets",
+			spec: mmv1.ServiceExpositionSpec{Name: "helloworld", Port: 5000, MeshFedConfigSelector: testSelector,
+				Endpoints: []string{"2001:db8::20:15443"}},
+			want: []string{"invalid endpoint"},
+		},
+		{
+			name: "missing service",
+			spec: mmv1.ServiceExpositionSpec{Name: "goodbye", Port: 5000, MeshFedConfigSelector: testSelector},
+			want: []string{"Service shop/goodbye not found"},
+		},
+		{
+			name: "port not exposed",
+			spec: mmv1.ServiceExpositionSpec{Name: "helloworld", Port: 6000, MeshFedConfigSelector: testSelector},
+			want: []string{"does not expose port 6000"},
+		},
+		{
+			name: "no MeshFedConfig",
+			spec: mmv1.ServiceExpositionSpec{Name: "helloworld", Port: 5000, MeshFedConfigSelector: map[string]string{"fed-config": "none"}},
+			want: []string{"matches no MeshFedConfig"},
+		},
+		{
+			name: "several MeshFedConfigs",
+			spec: mmv1.ServiceExpositionSpec{Name: "helloworld", Port: 5000, MeshFedConfigSelector: map[string]string{"fed-config": "dup"}},
+			want: []string{"matches 2 MeshFedConfigs: mesh-system/fed-a, mesh-system/fed-b"},
+		},
+		{
+			name: "undefined subset",
+			spec: mmv1.ServiceExpositionSpec{Name: "helloworld", Port: 5000, Subset: "v2", MeshFedConfigSelector: testSelector},
+			want: []string{`subset "v2" of helloworld is not defined`},
+		},
+	}
+	for _, tc := range cases {
+		se := &mmv1.ServiceExposition{
+			ObjectMeta: metav1.ObjectMeta{Name: "helloworld", Namespace: "shop"},
+			Spec:       tc.spec,
+		}
+		expectErrors(t, tc.name, c.ServiceExposition(context.Background(), se), tc.want...)
+	}
+}
+
+func TestClusterServiceBinding(t *testing.T) {
+	generated := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "generated",
+			Namespace: "shop",
+			Labels:    map[string]string{style.OwnerUIDLabel: "sb-uid"},
+		},
+	}
+	c := newTestCluster(t, generated)
+	cases := []struct {
+		name string
+		uid  string
+		spec mmv1.ServiceBindingSpec
+		want []string
+	}{
+		{
+			name: "valid",
+			spec: mmv1.ServiceBindingSpec{Name: "remote", Port: 5000, MeshFedConfigSelector: testSelector},
+		},
+		{
+			name: "name collides",
+			spec: mmv1.ServiceBindingSpec{Name: "helloworld", Port: 5000, MeshFedConfigSelector: testSelector},
+			want: []string{`"helloworld" collides with Service shop/helloworld`},
+		},
+		{
+			name: "alias collides",
+			spec: mmv1.ServiceBindingSpec{Name: "remote", Alias: "helloworld", Port: 5000, MeshFedConfigSelector: testSelector},
+			want: []string{`"helloworld" collides with Service shop/helloworld`},
+		},
+		{
+			name: "alias of the Service generated for the binding",
+			uid:  "sb-uid",
+			spec: mmv1.ServiceBindingSpec{Name: "remote", Alias: "generated", Port: 5000, MeshFedConfigSelector: testSelector},
+		},
+		{
+			name: "alias of a Service generated for another binding",
+			uid:  "other-uid",
+			spec: mmv1.ServiceBindingSpec{Name: "remote", Alias: "generated", Port: 5000, MeshFedConfigSelector: testSelector},
+			want: []string{`"generated" collides`},
+		},
+	}
+	for _, tc := range cases {
+		sb := &mmv1.ServiceBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "remote", Namespace: "shop", UID: types.UID(tc.uid)},
+			Spec:       tc.spec,
+		}
+		expectErrors(t, tc.name, c.ServiceBinding(context.Background(), sb), tc.want...)
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	cases := []struct {
+		ep   string
+		want string
+	}{
+		{ep: "192.0.2.1:15443"},
+		{ep: "[2001:db8::1]:15443"},
+		{ep: "2001:db8::1:15443", want: "invalid endpoint"},
+		{ep: "ingress.example.com:15443", want: "not an IPv4 or IPv6 address"},
+		{ep: "192.0.2.1", want: "invalid endpoint"},
+		{ep: "192.0.2.1:0", want: "invalid port"},
+		{ep: "192.0.2.1:70000", want: "invalid port"},
+	}
+	for _, tc := range cases {
+		err := endpoints("remote", "shop", []string{tc.ep})
+		if tc.want == "" {
+			expectErrors(t, tc.ep, err)
+		} else {
+			expectErrors(t, tc.ep, err, tc.want)
+		}
+	}
+}
+
+const testBundle = `
+apiVersion: mm.ibm.istio.io/v1
+kind: MeshFedConfig
+metadata:
+  name: fed
+  namespace: mesh-system
+  labels:
+    fed-config: fed
+spec:
+  mode: PASSTHROUGH
+  use_ingress_gateway: true
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: helloworld
+spec:
+  ports:
+  - name: http
+    port: 5000
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: helloworld
+spec:
+  host: helloworld
+  subsets:
+  - name: v1
+---
+apiVersion: mm.ibm.istio.io/v1
+kind: ServiceExposition
+metadata:
+  name: helloworld
+spec:
+  name: helloworld
+  subset: v1
+  port: 5000
+  mesh_fed_config_selector:
+    fed-config: fed
+  endpoints:
+  - "[2001:db8::1]:15443"
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: skipped
+`
+
+func TestBundle(t *testing.T) {
+	expectErrors(t, "valid bundle", Bundle(context.Background(), strings.NewReader(testBundle)))
+
+	invalid := strings.Replace(testBundle, "subset: v1\n  port: 5000", "subset: v2\n  port: 6000", 1)
+	expectErrors(t, "invalid bundle", Bundle(context.Background(), strings.NewReader(invalid)),
+		"does not expose port 6000", `subset "v2"`)
+
+	// The exposition's MeshFedConfig is missing
+	partial := testBundle[strings.Index(testBundle, "---"):]
+	expectErrors(t, "partial bundle", Bundle(context.Background(), strings.NewReader(partial)), "matches no MeshFedConfig")
+
+	expectErrors(t, "unparsable bundle", Bundle(context.Background(), strings.NewReader("kind: [")), "cannot parse")
+}
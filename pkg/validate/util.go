@@ -23,11 +23,12 @@ import (
 	"github.com/hashicorp/go-multierror"
 
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
-	"github.com/istio-ecosystem/emcee/controllers"
+	"github.com/istio-ecosystem/emcee/style"
 )
 
 const (
 	dNS1123LabelMaxLength = 63
+	maxPort               = 65535
 	dns1123LabelFmt       = "[a-zA-Z0-9](?:[-a-z-A-Z0-9]*[a-zA-Z0-9])?"
 )
 
@@ -35,60 +36,20 @@ var (
 	dns1123LabelRegexp = regexp.MustCompile("^" + dns1123LabelFmt + "$")
 )
 
-// MeshConfig validates a MeshFedConfigSpec
+// MeshConfig validates a MeshFedConfigSpec with the validator of the style its mode selects
 func MeshConfig(name, namespace string, mfc mmv1.MeshFedConfigSpec) error {
-	var retval error
-	if !strings.EqualFold(mfc.Mode, controllers.ModeBoundary) && !strings.EqualFold(mfc.Mode, controllers.ModePassthrough) {
-		retval = multierror.Append(retval, fmt.Errorf("%s/%s: Unknown Mode %q", namespace, name, mfc.Mode))
+	s, ok := style.Lookup(mfc.Mode)
+	if !ok {
+		return multierror.Append(nil, fmt.Errorf("%s/%s: Unknown Mode %q; use one of %s",
+			namespace, name, mfc.Mode, strings.Join(style.Modes(), ", ")))
 	}
-
-	if strings.EqualFold(mfc.Mode, controllers.ModeBoundary) {
-		if len(mfc.TlsContextSelector) == 0 {
-			retval = multierror.Append(retval, fmt.Errorf("%s/%s: %q requires tls_context_selector", namespace, name, strings.ToUpper(mfc.Mode)))
-		}
-
-		if !mfc.UseEgressGateway {
-			if len(mfc.EgressGatewaySelector) != 0 {
-				retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify egress, but selects one", namespace, name))
-			}
-
-			if mfc.EgressGatewayPort != 0 {
-				retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify egress, but specifies port %v", namespace, name, mfc.EgressGatewayPort))
-			}
-		}
-
-		if !mfc.UseIngressGateway {
-			if len(mfc.IngressGatewaySelector) != 0 {
-				retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify ingress, but selects one", namespace, name))
-			}
-
-			if mfc.IngressGatewayPort != 0 {
-				retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify ingress, but specifies port %v", namespace, name, mfc.IngressGatewayPort))
-			}
-		}
-	} else if strings.EqualFold(mfc.Mode, controllers.ModePassthrough) {
-		if !mfc.UseEgressGateway {
-			if len(mfc.EgressGatewaySelector) != 0 {
-				retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify egress, but selects one", namespace, name))
-			}
-
-			if mfc.EgressGatewayPort != 0 {
-				retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify egress, but specifies port %v", namespace, name, mfc.EgressGatewayPort))
-			}
-		}
-
-		if !mfc.UseIngressGateway {
-			if len(mfc.IngressGatewaySelector) != 0 {
-				retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify ingress, but selects one", namespace, name))
-			}
-
-			if mfc.IngressGatewayPort != 0 {
-				retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify ingress, but specifies port %v", namespace, name, mfc.IngressGatewayPort))
-			}
-		}
+	if s.Validate == nil {
+		return nil
 	}
-
-	return retval
+	if err := s.Validate(name, namespace, mfc); err != nil {
+		return multierror.Append(nil, err)
+	}
+	return nil
 }
 
 // ServiceExposition validates a ServiceExpositionSpec
@@ -100,6 +61,12 @@ func ServiceExposition(name, namespace string, se mmv1.ServiceExpositionSpec) er
 	if !isDNSLabel(se.Name) {
 		retval = multierror.Append(retval, fmt.Errorf("%s/%s: invalid name %q", namespace, name, se.Name))
 	}
+	if se.Port == 0 && len(se.Ports) == 0 {
+		retval = multierror.Append(retval, fmt.Errorf("%s/%s: requires ports", namespace, name))
+	}
+	if err := servicePorts(name, namespace, se.Port, se.Ports); err != nil {
+		retval = multierror.Append(retval, err)
+	}
 
 	return retval
 }
@@ -116,12 +83,60 @@ func ServiceBinding(name, namespace string, sb mmv1.ServiceBindingSpec) error {
 	if sb.Alias != "" && !isDNSLabel(sb.Alias) {
 		retval = multierror.Append(retval, fmt.Errorf("%s/%s: invalid alias %q", namespace, name, sb.Alias))
 	}
+	if err := servicePorts(name, namespace, sb.Port, sb.Ports); err != nil {
+		retval = multierror.Append(retval, err)
+	}
 	// Note that we allow no endpoints, because of the scenario where we create
 	// with no endpoints and Service Discovery patches the binding to add them.
 
 	return retval
 }
 
+// servicePorts validates the deprecated port and the ports of an exposition or binding
+func servicePorts(name, namespace string, port uint32, ports []mmv1.ServicePort) error {
+	var retval error
+	if port != 0 && len(ports) > 0 {
+		retval = multierror.Append(retval, fmt.Errorf("%s/%s: specifies both port and ports", namespace, name))
+	}
+	if port > maxPort {
+		retval = multierror.Append(retval, fmt.Errorf("%s/%s: invalid port %d", namespace, name, port))
+	}
+
+	names := map[string]bool{}
+	numbers := map[uint32]bool{}
+	for _, p := range ports {
+		if !isDNSLabel(p.Name) {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: invalid port name %q", namespace, name, p.Name))
+		} else if names[p.Name] {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: duplicate port name %q", namespace, name, p.Name))
+		}
+		names[p.Name] = true
+		if p.Number == 0 || p.Number > maxPort {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: port %q has invalid number %d", namespace, name, p.Name, p.Number))
+		} else if numbers[p.Number] {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: duplicate port number %d", namespace, name, p.Number))
+		}
+		numbers[p.Number] = true
+		if p.TargetPort > maxPort {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: port %q has invalid target_port %d", namespace, name, p.Name, p.TargetPort))
+		}
+		if p.Protocol != "" && !isProtocol(p.Protocol) {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: port %q has unknown protocol %q", namespace, name, p.Name, p.Protocol))
+		}
+	}
+
+	return retval
+}
+
+func isProtocol(value mmv1.Protocol) bool {
+	for _, protocol := range mmv1.Protocols {
+		if value == protocol {
+			return true
+		}
+	}
+	return false
+}
+
 func isDNSLabel(value string) bool {
 	return len(value) <= dNS1123LabelMaxLength && dns1123LabelRegexp.MatchString(value)
 }
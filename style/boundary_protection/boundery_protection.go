@@ -20,9 +20,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strconv"
-	"strings"
 
+	multierror "github.com/hashicorp/go-multierror"
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
 	"github.com/istio-ecosystem/emcee/style"
 	mfutil "github.com/istio-ecosystem/emcee/util"
@@ -36,6 +35,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
@@ -45,6 +45,7 @@ import (
 type boundaryProtection struct {
 	client.Client
 	istioclient.Interface
+	recorder record.EventRecorder
 }
 
 var (
@@ -54,31 +55,108 @@ var (
 	_ style.ServiceExposer = &boundaryProtection{}
 )
 
+// Mode selects the boundary protection style
+const Mode = "BOUNDARY"
+
+func init() {
+	style.Register(style.Style{
+		Mode:              Mode,
+		NewMeshFedConfig:  NewBoundaryProtectionMeshFedConfig,
+		NewServiceExposer: NewBoundaryProtectionServiceExposer,
+		NewServiceBinder:  NewBoundaryProtectionServiceBinder,
+		Validate:          validateMeshFedConfig,
+		Default:           defaultMeshFedConfig,
+		IngressAddresses:  ingressAddresses,
+		IngressScheme:     "https",
+	})
+}
+
+// ingressEndpoints returns the address:port of the ingress Service of a MeshFedConfig
+func ingressEndpoints(ctx context.Context, cli client.Client, mfc *mmv1.MeshFedConfig) ([]string, error) {
+	return mfutil.GetIngressEndpoints(ctx, cli, mfc.GetName(), mfc.GetNamespace(), ingressPort(mfc))
+}
+
+// ingressAddresses returns the host:port clients reach the ingress Service of a MeshFedConfig at
+func ingressAddresses(ctx context.Context, cli client.Client, mfc *mmv1.MeshFedConfig) ([]string, error) {
+	return mfutil.GetIngressAddresses(ctx, cli, ingressServiceName(mfc.GetName(), ingressPort(mfc)),
+		mfc.GetNamespace(), ingressPort(mfc))
+}
+
+// ingressPort is the port of the ingress gateway of a MeshFedConfig, which the defaulting
+// webhook and the controllers set when it is left out
+func ingressPort(mfc *mmv1.MeshFedConfig) uint32 {
+	if mfc.Spec.IngressGatewayPort == 0 {
+		return defaultGatewayPort
+	}
+	return mfc.Spec.IngressGatewayPort
+}
+
+// ingressServiceName is the name of the ingress Service of MeshFedConfig name
+func ingressServiceName(name string, port uint32) string {
+	return fmt.Sprintf("istio-%s-ingress-%d", name, port)
+}
+
+// validateMeshFedConfig requires the secret of the gateways' certificates
+func validateMeshFedConfig(name, namespace string, mfc mmv1.MeshFedConfigSpec) error {
+	var retval error
+	if len(mfc.TlsContextSelector) == 0 {
+		retval = multierror.Append(retval, fmt.Errorf("%s/%s: %q requires tls_context_selector", namespace, name, Mode))
+	}
+	if err := style.ValidateGateways(name, namespace, mfc); err != nil {
+		retval = multierror.Append(retval, err)
+	}
+	return retval
+}
+
+// defaultMeshFedConfig selects the egress and ingress workloads EffectMeshFedConfig deploys,
+// on the ports of their Services, for the gateways the config uses
+func defaultMeshFedConfig(mfc *mmv1.MeshFedConfigSpec) {
+	if mfc.UseEgressGateway {
+		if len(mfc.EgressGatewaySelector) == 0 {
+			mfc.EgressGatewaySelector = defaultEgressGatewaySelector
+		}
+		if mfc.EgressGatewayPort == 0 {
+			mfc.EgressGatewayPort = defaultEgressGatewayPort
+		}
+	}
+	if mfc.UseIngressGateway {
+		if len(mfc.IngressGatewaySelector) == 0 {
+			mfc.IngressGatewaySelector = defaultIngressGatewaySelector
+		}
+		if mfc.IngressGatewayPort == 0 {
+			mfc.IngressGatewayPort = defaultGatewayPort
+		}
+	}
+}
+
 const (
 	defaultPrefix = ".svc.cluster.local"
 )
 
 // NewBoundaryProtectionMeshFedConfig creates a "Boundary Protection" style implementation for handling MeshFedConfig
-func NewBoundaryProtectionMeshFedConfig(cli client.Client, istioCli istioclient.Interface) style.MeshFedConfig {
+func NewBoundaryProtectionMeshFedConfig(cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) style.MeshFedConfig {
 	return &boundaryProtection{
 		cli,
 		istioCli,
+		recorder,
 	}
 }
 
 // NewBoundaryProtectionServiceExposer creates a "Boundary Protection" style implementation for handling ServiceExposure
-func NewBoundaryProtectionServiceExposer(cli client.Client, istioCli istioclient.Interface) style.ServiceExposer {
+func NewBoundaryProtectionServiceExposer(cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) style.ServiceExposer {
 	return &boundaryProtection{
 		cli,
 		istioCli,
+		recorder,
 	}
 }
 
 // NewBoundaryProtectionServiceBinder creates a "Boundary Protection" style implementation for handling ServiceBinding
-func NewBoundaryProtectionServiceBinder(cli client.Client, istioCli istioclient.Interface) style.ServiceBinder {
+func NewBoundaryProtectionServiceBinder(cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) style.ServiceBinder {
 	return &boundaryProtection{
 		cli,
 		istioCli,
+		recorder,
 	}
 }
 
@@ -86,16 +164,16 @@ func NewBoundaryProtectionServiceBinder(cli client.Client, istioCli istioclient.
 // *** EffectMeshFedConfig ***
 // ***************************
 func (bp *boundaryProtection) EffectMeshFedConfig(ctx context.Context, mfc *mmv1.MeshFedConfig) error {
-	// If the MeshFedConfig changes we may need to re-create all of the Istio
-	// things for every ServiceBinding and ServiceExposition.  TODO Trigger
-	// re-reconcile of every ServiceBinding and ServiceExposition.
+	// If the MeshFedConfig changes we may need to re-create all of the Istio
+	// things for every ServiceBinding and ServiceExposition.  Their reconcilers
+	// watch MeshFedConfigs and re-reconcile the ones that select this one.
 
 	targetNamespace := mfc.GetNamespace()
 
 	secret, err := getSecretName(ctx, mfc, bp.Client)
 	if err != nil {
 		log.Infof("Could not get secret name from MeshFedConfig: %v", err)
-		return err
+		return style.Failed(&mfc.Status.Conditions, mmv1.ReasonSecretNotFound, err)
 	}
 
 	// Create Egress Service
@@ -109,31 +187,32 @@ func (bp *boundaryProtection) EffectMeshFedConfig(ctx context.Context, mfc *mmv1
 	if err != nil && !mfutil.ErrorAlreadyExists(err) {
 		log.Infof("Failed to create Egress Service %s.%s: %v",
 			egressSvc.GetName(), egressSvc.GetNamespace(), err)
-		return err
+		return style.Failed(&mfc.Status.Conditions, mmv1.ReasonServiceFailed, err)
 	}
 	if err == nil {
 		log.Infof("Created Egress Service %s.%s", egressSvc.GetName(), egressSvc.GetNamespace())
+		style.Recorded(bp.recorder, mfc, controllerutil.OperationResultCreated, "Service", &egressSvc)
 	}
+	mmv1.AddGeneratedObject(&mfc.Status.GeneratedObjects, style.Generated("v1", "Service", &egressSvc))
 
-	// If mfc.Spec.EgressGatewaySelector is empty, default it
+	// If mfc.Spec.EgressGatewaySelector is empty, default it.  The defaulting webhook persists
+	// this; configs created without it are only defaulted here.
 	if len(mfc.Spec.EgressGatewaySelector) == 0 {
-		mfc.Spec.EgressGatewaySelector = map[string]string{
-			style.ProjectID: "egressgateway",
-		}
-		log.Infof("MeshFedConfig did not specify an egress workload, using %v", mfc.Spec.EgressGatewaySelector)
-		// TODO?: persist this change
+		mfc.Spec.EgressGatewaySelector = defaultEgressGatewaySelector
+		style.Normal(bp.recorder, mfc, style.EventDefaultedWorkload,
+			"MeshFedConfig did not specify an egress workload, using %v", mfc.Spec.EgressGatewaySelector)
 	}
 
 	nEgressPod, err := bp.workloadMatches(ctx, targetNamespace, labels.SelectorFromSet(mfc.Spec.EgressGatewaySelector))
 	if err != nil {
 		log.Infof("Failed to list existing Egress pods: %v", err)
-		return err
+		return style.Failed(&mfc.Status.Conditions, mmv1.ReasonDeploymentFailed, err)
 	}
 	if nEgressPod == 0 {
 		err = bp.createEgressDeployment(ctx, mfc, targetNamespace, secret)
 		if err != nil {
 			log.Infof("Could not create Egress deployment: %v", err)
-			return err
+			return style.Failed(&mfc.Status.Conditions, mmv1.ReasonDeploymentFailed, err)
 		}
 	}
 
@@ -141,43 +220,46 @@ func (bp *boundaryProtection) EffectMeshFedConfig(ctx context.Context, mfc *mmv1
 	// TODO ServicePort.Port is a uint32, IngressGatewayPort should be too
 	ingressSvc := boundaryProtectionIngressService(mfc.GetName(),
 		targetNamespace,
-		int32(mfc.Spec.IngressGatewayPort),
+		int32(ingressPort(mfc)),
 		mfc.Spec.IngressGatewaySelector, mfc)
 	err = bp.Client.Create(ctx, &ingressSvc)
 	if err != nil && !mfutil.ErrorAlreadyExists(err) {
 		log.Infof("Failed to create Ingress Service %s.%s: %v",
 			ingressSvc.GetName(), ingressSvc.GetNamespace(), err)
-		return err
+		return style.Failed(&mfc.Status.Conditions, mmv1.ReasonServiceFailed, err)
 	}
 	if err == nil {
 		log.Infof("Created Ingress Service %s.%s", ingressSvc.GetName(), ingressSvc.GetNamespace())
+		style.Recorded(bp.recorder, mfc, controllerutil.OperationResultCreated, "Service", &ingressSvc)
 	}
+	mmv1.AddGeneratedObject(&mfc.Status.GeneratedObjects, style.Generated("v1", "Service", &ingressSvc))
 
-	// If mfc.Spec.IngressGatewaySelector is empty, default it
+	// If mfc.Spec.IngressGatewaySelector is empty, default it, as for the egress
 	if len(mfc.Spec.IngressGatewaySelector) == 0 {
 		mfc.Spec.IngressGatewaySelector = defaultIngressGatewaySelector
-		log.Infof("MeshFedConfig did not specify an ingress workload, using %v", mfc.Spec.IngressGatewaySelector)
-		// TODO?: persist this change
+		style.Normal(bp.recorder, mfc, style.EventDefaultedWorkload,
+			"MeshFedConfig did not specify an ingress workload, using %v", mfc.Spec.IngressGatewaySelector)
 	}
 
 	nIngressPod, err := bp.workloadMatches(ctx, targetNamespace, labels.SelectorFromSet(mfc.Spec.IngressGatewaySelector))
 	if err != nil {
 		log.Infof("Failed to list existing Ingress pods: %v", err)
-		return err
+		return style.Failed(&mfc.Status.Conditions, mmv1.ReasonDeploymentFailed, err)
 	}
 	if nIngressPod == 0 {
 		err = bp.createIngressDeployment(ctx, mfc, targetNamespace, secret)
 		if err != nil {
 			log.Infof("Could not create Ingress deployment: %v", err)
-			return err
+			return style.Failed(&mfc.Status.Conditions, mmv1.ReasonDeploymentFailed, err)
 		}
 	}
 
 	return nil
 }
 
+// RemoveMeshFedConfig deletes the egress and ingress gateways generated for the MeshFedConfig
 func (bp *boundaryProtection) RemoveMeshFedConfig(ctx context.Context, mfc *mmv1.MeshFedConfig) error {
-	return nil
+	return style.RemoveGenerated(ctx, bp.Client, bp.Interface, style.KindMeshFedConfig, mfc)
 }
 
 // *****************************
@@ -188,32 +270,40 @@ func (bp *boundaryProtection) EffectServiceExposure(ctx context.Context, se *mmv
 	gw, vs, err := boundaryProtectionExposingGatewayAndVs(mfc, se)
 	if err != nil {
 		log.Warnf("could not model gateway %v %v", gw, err)
-		return err
+		return style.Failed(&se.Status.Conditions, mmv1.ReasonGatewayFailed, err)
 	}
 
 	_, err = createGateway(bp.Interface, mfc.GetNamespace(), gw)
 
 	if err != nil {
 		log.Warnf("could not create gateway %v %v", gw, err)
-		return err
+		return style.Failed(&se.Status.Conditions, mmv1.ReasonGatewayFailed, err)
 	}
+	mmv1.AddGeneratedObject(&se.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "Gateway", gw))
 	_, err = createVirtualService(bp.Interface, mfc.GetNamespace(), vs)
 	if err != nil {
 		log.Warnf("could not create virtual service %v %v", vs, err)
-		return err
+		return style.Failed(&se.Status.Conditions, mmv1.ReasonVirtualServiceFailed, err)
 	}
+	mmv1.AddGeneratedObject(&se.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "VirtualService", vs))
 
 	// get the endpoints
-	eps, err := mfutil.GetIngressEndpoints(ctx, bp.Client, mfc.GetName(), mfc.GetNamespace(), defaultGatewayPort)
+	eps, err := ingressEndpoints(ctx, bp.Client, mfc)
 	if err != nil {
 		log.Warnf("could not get endpoints %v %v", eps, err)
-		return err
+		style.EndpointsUnresolved(&se.Status.Conditions, err)
+		return style.Failed(&se.Status.Conditions, mmv1.ReasonEndpointsUnavailable, err)
 	}
 	se.Spec.Endpoints = eps
-	se.Status.Ready = true
+	style.EndpointsResolved(&se.Status.Conditions, eps)
+	style.ExposedTopology(ctx, bp.Client, bp.recorder, se, mfc, ingressServiceName(mfc.GetName(), ingressPort(mfc)), mfc.GetNamespace())
+
+	// Update() returns the stored status; keep the one we are building for the controller
+	status := se.Status.DeepCopy()
 	if err := bp.Client.Update(ctx, se); err != nil {
 		return err
 	}
+	se.Status = *status
 	return nil
 }
 
@@ -221,12 +311,16 @@ func boundaryProtectionExposingGatewayAndVs(mfc *mmv1.MeshFedConfig, se *mmv1.Se
 	if !mfc.Spec.UseIngressGateway {
 		return nil, nil, fmt.Errorf("Boundry Protection requires Ingress Gateway")
 	}
+	ports := se.Spec.ServicePorts()
+	if len(ports) == 0 {
+		return nil, nil, fmt.Errorf("Boundary Protection requires the ports of %s", se.Spec.Name)
+	}
+	if err := httpPorts(ports); err != nil {
+		return nil, nil, err
+	}
 
 	// build an Istio gateway
-	ingressGatewayPort := mfc.Spec.IngressGatewayPort
-	if ingressGatewayPort == 0 {
-		ingressGatewayPort = defaultGatewayPort
-	}
+	ingressGatewayPort := ingressPort(mfc)
 
 	ingressSelector := defaultIngressGatewaySelector
 	if len(mfc.Spec.IngressGatewaySelector) != 0 {
@@ -255,60 +349,61 @@ func boundaryProtectionExposingGatewayAndVs(mfc *mmv1.MeshFedConfig, se *mmv1.Se
 
 	name := se.GetName()
 	namespace := mfc.GetNamespace()
-	uid := se.GetUID()
 	gw := &v1alpha3.Gateway{
 		TypeMeta: metav1.TypeMeta{
 			Kind: "gateway",
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: namespace,
+			Labels:    style.OwnerLabels(style.KindServiceExposition, se, nil),
 		},
 		Spec: gateway,
 	}
 
 	gw.ObjectMeta.Name = name
-	if uid != "" {
-		gw.ObjectMeta.OwnerReferences = ownerReference(se.APIVersion, se.Kind, se.ObjectMeta)
-	}
+	gw.ObjectMeta.OwnerReferences = style.OwnerReferences(style.KindServiceExposition, se, namespace)
 
 	// create vs
 	namespace = se.GetNamespace()
 	serviceName := se.Spec.Name
 	fullname := serviceName + "." + namespace + defaultPrefix
-	virtualService := istiov1alpha3.VirtualService{
-		Hosts: []string{
-			"*",
-		},
-		Gateways: []string{
-			name,
-		},
-		Http: []*istiov1alpha3.HTTPRoute{
-			{
-				Name: ("route-" + name),
-				Match: []*istiov1alpha3.HTTPMatchRequest{
-					{
-						Uri: &istiov1alpha3.StringMatch{
-							MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: servicePathExposure(se)},
-						},
+	// Each port has its own path prefix at the ingress
+	var routes []*istiov1alpha3.HTTPRoute
+	for _, p := range ports {
+		routes = append(routes, &istiov1alpha3.HTTPRoute{
+			Name: ("route-" + name + portSuffix(p, ports)),
+			Match: []*istiov1alpha3.HTTPMatchRequest{
+				{
+					Uri: &istiov1alpha3.StringMatch{
+						MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: ExposedPathPrefix(se, p)},
 					},
 				},
-				Rewrite: &istiov1alpha3.HTTPRewrite{
-					Uri:       "/",
-					Authority: fullname,
-				},
-				Route: []*istiov1alpha3.HTTPRouteDestination{
-					{
-						Destination: &istiov1alpha3.Destination{
-							Host:   fullname,
-							Subset: se.Spec.Subset,
-							Port: &istiov1alpha3.PortSelector{
-								Number: se.Spec.Port,
-							},
+			},
+			Rewrite: &istiov1alpha3.HTTPRewrite{
+				Uri:       "/",
+				Authority: fullname,
+			},
+			Route: []*istiov1alpha3.HTTPRouteDestination{
+				{
+					Destination: &istiov1alpha3.Destination{
+						Host:   fullname,
+						Subset: se.Spec.Subset,
+						Port: &istiov1alpha3.PortSelector{
+							Number: p.Number,
 						},
 					},
 				},
 			},
+		})
+	}
+	virtualService := istiov1alpha3.VirtualService{
+		Hosts: []string{
+			"*",
 		},
+		Gateways: []string{
+			name,
+		},
+		Http: routes,
 	}
 
 	// CreateIstioVirtualService(bp.istioCli, name, mfc.GetNamespace(), vs, se.GetUID())
@@ -320,19 +415,45 @@ func boundaryProtectionExposingGatewayAndVs(mfc *mmv1.MeshFedConfig, se *mmv1.Se
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: namespace,
+			Labels:    style.OwnerLabels(style.KindServiceExposition, se, nil),
 		},
 		Spec: virtualService,
 	}
 	vs.ObjectMeta.Name = name
-	if uid != "" {
-		vs.ObjectMeta.OwnerReferences = ownerReference(se.APIVersion, se.Kind, se.ObjectMeta)
-	}
+	vs.ObjectMeta.OwnerReferences = style.OwnerReferences(style.KindServiceExposition, se, namespace)
 	return gw, vs, nil
 }
 
+// RemoveServiceExposure deletes the Gateway and VirtualService generated for the exposure
 func (bp *boundaryProtection) RemoveServiceExposure(ctx context.Context, se *mmv1.ServiceExposition, mfc *mmv1.MeshFedConfig) error {
+	return style.RemoveGenerated(ctx, bp.Client, bp.Interface, style.KindServiceExposition, se)
+}
+
+// deleteLegacyRemoteIngressService deletes the ExternalName Service of the same name as the
+// ServiceEntry through which earlier versions reached a single remote ingress.  Only the
+// Service generated for this binding is deleted.
+func (bp *boundaryProtection) deleteLegacyRemoteIngressService(ctx context.Context, sb *mmv1.ServiceBinding, se *v1alpha3.ServiceEntry) error {
+	var legacySvc corev1.Service
+	err := bp.Client.Get(ctx, client.ObjectKey{Namespace: se.GetNamespace(), Name: se.GetName()}, &legacySvc)
+	if err != nil {
+		if mfutil.ErrorNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if legacySvc.Spec.Type != corev1.ServiceTypeExternalName ||
+		legacySvc.GetLabels()[style.OwnerUIDLabel] != string(sb.GetUID()) {
+		return nil
+	}
+	if err := bp.Client.Delete(ctx, &legacySvc); err != nil {
+		if mfutil.ErrorNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	style.Normal(bp.recorder, sb, style.EventDeleted, "Deleted the ExternalName Service %s/%s replaced by a ServiceEntry",
+		legacySvc.GetNamespace(), legacySvc.GetName())
 	return nil
-	// return fmt.Errorf("Unimplemented - service exposure delete")
 }
 
 // ****************************
@@ -345,42 +466,36 @@ func (bp *boundaryProtection) EffectServiceBinding(ctx context.Context, sb *mmv1
 	targetNamespace := mfc.GetNamespace()
 	localNamespace := sb.GetNamespace()
 
-	// Create a Kubernetes service for the remote Ingress, if needed
-	goalSvcRemoteCluster, err := boundaryProtectionRemoteIngressService(targetNamespace, sb, mfc)
+	if err := httpPorts(boundLocalPorts(sb)); err != nil {
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonServiceFailed, err)
+	}
+
+	// Create an Istio service entry for the remote Ingresses, if needed
+	seRemoteCluster, err := boundaryProtectionRemoteIngressServiceEntry(targetNamespace, sb, mfc)
 	if err != nil {
-		log.Infof("Could not generate Remote Cluster ingress Service")
-		return err
+		log.Infof("Could not generate Remote Cluster ingress Service Entry")
+		style.EndpointsUnresolved(&sb.Status.Conditions, err)
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonEndpointsUnavailable, err)
 	}
-	svcRemoteCluster := &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      goalSvcRemoteCluster.GetName(),
-			Namespace: goalSvcRemoteCluster.GetNamespace(),
-		},
+	style.EndpointsResolved(&sb.Status.Conditions, sb.Spec.Endpoints)
+	if err := bp.deleteLegacyRemoteIngressService(ctx, sb, seRemoteCluster); err != nil {
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonServiceFailed, err)
 	}
-	or, err := controllerutil.CreateOrUpdate(ctx, bp.Client, svcRemoteCluster, func() error {
-		svcRemoteCluster.ObjectMeta.Labels = goalSvcRemoteCluster.Labels
-		svcRemoteCluster.ObjectMeta.OwnerReferences = goalSvcRemoteCluster.ObjectMeta.OwnerReferences
-		// Update the Spec fields WITHOUT clearing .Spec.ClusterIP
-		svcRemoteCluster.Spec.Ports = goalSvcRemoteCluster.Spec.Ports
-		svcRemoteCluster.Spec.SessionAffinity = goalSvcRemoteCluster.Spec.SessionAffinity
-		svcRemoteCluster.Spec.Type = goalSvcRemoteCluster.Spec.Type
-		svcRemoteCluster.Spec.ExternalName = goalSvcRemoteCluster.Spec.ExternalName
-		return nil
-	})
+	_, err = createServiceEntry(bp.Interface, targetNamespace, seRemoteCluster)
 	if err != nil {
-		return err
+		log.Warnf("Failed creating/updating Istio service entry %v: %v", seRemoteCluster.GetName(), err)
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonServiceEntryFailed, err)
 	}
-	log.Infof("%s %s %s", or,
-		"Remote Cluster ingress Service",
-		renderName(&svcRemoteCluster.ObjectMeta))
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "ServiceEntry", seRemoteCluster))
 
 	// Create an Istio destination rule for the remote Ingress, if needed
 	drRemoteCluster := boundaryProtectionRemoteDestinationRule(targetNamespace, mfc, sb)
 	_, err = createDestinationRule(bp.Interface, targetNamespace, &drRemoteCluster)
 	if err != nil {
 		log.Warnf("Failed creating/updating Istio destination rule %v: %v", drRemoteCluster.GetName(), err)
-		return err
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonDestinationRuleFailed, err)
 	}
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "DestinationRule", &drRemoteCluster))
 
 	goalSvcLocalFacade := boundaryProtectionLocalServiceFacade(localNamespace, sb, mfc)
 	svcLocalFacade := &corev1.Service{
@@ -389,7 +504,7 @@ func (bp *boundaryProtection) EffectServiceBinding(ctx context.Context, sb *mmv1
 			Namespace: goalSvcLocalFacade.GetNamespace(),
 		},
 	}
-	or, err = controllerutil.CreateOrUpdate(ctx, bp.Client, svcLocalFacade, func() error {
+	or, err := controllerutil.CreateOrUpdate(ctx, bp.Client, svcLocalFacade, func() error {
 		svcLocalFacade.ObjectMeta.Labels = goalSvcLocalFacade.Labels
 		svcLocalFacade.ObjectMeta.OwnerReferences = goalSvcLocalFacade.ObjectMeta.OwnerReferences
 		// Update the Spec fields WITHOUT clearing .Spec.ClusterIP
@@ -399,8 +514,10 @@ func (bp *boundaryProtection) EffectServiceBinding(ctx context.Context, sb *mmv1
 		return nil
 	})
 	if err != nil {
-		return err
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonServiceFailed, err)
 	}
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated("v1", "Service", svcLocalFacade))
+	style.Recorded(bp.recorder, sb, or, "Service", svcLocalFacade)
 	log.Infof("%s %s %s", or,
 		"Local Service facade Service",
 		renderName(&svcLocalFacade.ObjectMeta))
@@ -423,8 +540,10 @@ func (bp *boundaryProtection) EffectServiceBinding(ctx context.Context, sb *mmv1
 		return nil
 	})
 	if err != nil {
-		return err
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonServiceFailed, err)
 	}
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated("v1", "Service", svcLocalEgress))
+	style.Recorded(bp.recorder, sb, or, "Service", svcLocalEgress)
 	log.Infof("%s %s %s", or,
 		"Local Service egress Service",
 		renderName(&svcLocalEgress.ObjectMeta))
@@ -433,37 +552,41 @@ func (bp *boundaryProtection) EffectServiceBinding(ctx context.Context, sb *mmv1
 	_, err = createGateway(bp.Interface, targetNamespace, &svcLocalGateway)
 	if err != nil {
 		log.Warnf("Failed creating/updating Istio gateway %v: %v", svcLocalGateway.GetName(), err)
-		return err
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonGatewayFailed, err)
 	}
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "Gateway", &svcLocalGateway))
 
 	svcLocalDR := boundaryProtectionLocalServiceDestinationRule(comboName, targetNamespace, sb, mfc)
 	_, err = createDestinationRule(bp.Interface, targetNamespace, &svcLocalDR)
 	if err != nil {
 		log.Warnf("Failed creating/updating Istio destination rule %v: %v", svcLocalDR.GetName(), err)
-		return err
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonDestinationRuleFailed, err)
 	}
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "DestinationRule", &svcLocalDR))
 
 	vsEgressExternal := boundaryProtectionEgressExternalVirtualService(comboName, targetNamespace, sb, mfc)
 	_, err = createVirtualService(bp.Interface, targetNamespace, &vsEgressExternal)
 	if err != nil {
 		log.Warnf("Failed creating/updating Istio virtual service %v: %v", vsEgressExternal.GetName(), err)
-		return err
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonVirtualServiceFailed, err)
 	}
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "VirtualService", &vsEgressExternal))
 
 	vsLocalToEgress := boundaryProtectionLocalToEgressVirtualService(comboName, sb, mfc)
 	_, err = createVirtualService(bp.Interface, localNamespace, &vsLocalToEgress)
 	if err != nil {
 		log.Warnf("Failed creating/updating Istio virtual service %v: %v", vsLocalToEgress.GetName(), err)
-		return err
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonVirtualServiceFailed, err)
 	}
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "VirtualService", &vsLocalToEgress))
 
 	log.Infof("Successfully reconciled ServiceBinding %s/%s", sb.GetNamespace(), sb.GetName())
 	return nil
 }
 
+// RemoveServiceBinding deletes the Services, Gateway, DestinationRules and VirtualServices generated for the binding
 func (bp *boundaryProtection) RemoveServiceBinding(ctx context.Context, sb *mmv1.ServiceBinding, mfc *mmv1.MeshFedConfig) error {
-	return nil
-	// return fmt.Errorf("Unimplemented - service binding delete")
+	return style.RemoveGenerated(ctx, bp.Client, bp.Interface, style.KindServiceBinding, sb)
 }
 
 // TODO We currently hard-code this Service rather than using Istio Operator to create
@@ -477,11 +600,11 @@ func boundaryProtectionEgressService(name, namespace string, port int32, selecto
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("istio-%s-egress-%d", name, port),
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindMeshFedConfig, owner, map[string]string{
 				"mesh": name,
 				"role": "egress-svc",
-			},
-			OwnerReferences: ownerReference(owner.APIVersion, owner.Kind, owner.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindMeshFedConfig, owner, namespace),
 		},
 		Spec: corev1.ServiceSpec{
 			Ports: []corev1.ServicePort{
@@ -506,13 +629,13 @@ func boundaryProtectionIngressService(name, namespace string, port int32, select
 			Kind: "Service",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("istio-%s-ingress-%d", name, port),
+			Name:      ingressServiceName(name, uint32(port)),
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindMeshFedConfig, owner, map[string]string{
 				"mesh": name,
 				"role": "ingress-svc",
-			},
-			OwnerReferences: ownerReference(owner.APIVersion, owner.Kind, owner.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindMeshFedConfig, owner, namespace),
 		},
 		Spec: corev1.ServiceSpec{
 			Type: corev1.ServiceTypeLoadBalancer,
@@ -569,10 +692,10 @@ func boundaryProtectionXServiceAccount(name, namespace string, mfc *mmv1.MeshFed
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindMeshFedConfig, mfc, map[string]string{
 				"mesh": mfc.GetName(),
-			},
-			OwnerReferences: ownerReference(mfc.APIVersion, mfc.Kind, mfc.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindMeshFedConfig, mfc, namespace),
 		},
 	}
 }
@@ -589,8 +712,8 @@ func boundaryProtectionEgressDeployment(name, namespace string, labels map[strin
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            name,
 			Namespace:       namespace,
-			Labels:          labels,
-			OwnerReferences: ownerReference(owner.APIVersion, owner.Kind, owner.ObjectMeta),
+			Labels:          style.OwnerLabels(style.KindMeshFedConfig, owner, labels),
+			OwnerReferences: style.OwnerReferences(style.KindMeshFedConfig, owner, namespace),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Selector: &metav1.LabelSelector{
@@ -666,8 +789,8 @@ func boundaryProtectionIngressDeployment(name, namespace string, labels map[stri
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            name,
 			Namespace:       namespace,
-			Labels:          labels,
-			OwnerReferences: ownerReference(owner.APIVersion, owner.Kind, owner.ObjectMeta),
+			Labels:          style.OwnerLabels(style.KindMeshFedConfig, owner, labels),
+			OwnerReferences: style.OwnerReferences(style.KindMeshFedConfig, owner, namespace),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Selector: &metav1.LabelSelector{
@@ -845,17 +968,6 @@ func getSecretName(ctx context.Context, mfc *mmv1.MeshFedConfig, cli client.Read
 	return matches.Items[0].GetName(), nil
 }
 
-func ownerReference(apiVersion, kind string, owner metav1.ObjectMeta) []metav1.OwnerReference {
-	return []metav1.OwnerReference{
-		{
-			APIVersion: apiVersion,
-			Kind:       kind,
-			Name:       owner.GetName(),
-			UID:        owner.GetUID(),
-		},
-	}
-}
-
 func (bp *boundaryProtection) workloadMatches(ctx context.Context, namespace string, selector labels.Selector) (int, error) {
 	var matches corev1.PodList
 	err := bp.Client.List(ctx, &matches, &client.ListOptions{
@@ -880,7 +992,9 @@ func (bp *boundaryProtection) createEgressDeployment(ctx context.Context, mfc *m
 	}
 	if err == nil {
 		log.Infof("Created Egress Service Account %s.%s", egressSA.GetName(), egressSA.GetNamespace())
+		style.Recorded(bp.recorder, mfc, controllerutil.OperationResultCreated, "ServiceAccount", &egressSA)
 	}
+	mmv1.AddGeneratedObject(&mfc.Status.GeneratedObjects, style.Generated("v1", "ServiceAccount", &egressSA))
 
 	egressDeployment := boundaryProtectionEgressDeployment(mfc.GetName()+"-egressgateway",
 		targetNamespace, mfc.Spec.EgressGatewaySelector, &egressSA, secret, mfc)
@@ -892,8 +1006,10 @@ func (bp *boundaryProtection) createEgressDeployment(ctx context.Context, mfc *m
 	}
 	if err == nil {
 		log.Infof("Created Egress Deployment %s.%s", egressDeployment.GetName(), egressDeployment.GetNamespace())
+		style.Recorded(bp.recorder, mfc, controllerutil.OperationResultCreated, "Deployment", &egressDeployment)
 	}
-	return err
+	mmv1.AddGeneratedObject(&mfc.Status.GeneratedObjects, style.Generated(appsv1.SchemeGroupVersion.String(), "Deployment", &egressDeployment))
+	return nil
 }
 
 func (bp *boundaryProtection) createIngressDeployment(ctx context.Context, mfc *mmv1.MeshFedConfig, targetNamespace, secret string) error {
@@ -907,7 +1023,9 @@ func (bp *boundaryProtection) createIngressDeployment(ctx context.Context, mfc *
 	}
 	if err == nil {
 		log.Infof("Created Ingress Service Account %q", ingressSA.GetName())
+		style.Recorded(bp.recorder, mfc, controllerutil.OperationResultCreated, "ServiceAccount", &ingressSA)
 	}
+	mmv1.AddGeneratedObject(&mfc.Status.GeneratedObjects, style.Generated("v1", "ServiceAccount", &ingressSA))
 
 	ingressDeployment := boundaryProtectionIngressDeployment(mfc.GetName()+"-ingressgateway",
 		targetNamespace, mfc.Spec.IngressGatewaySelector, &ingressSA, secret, mfc)
@@ -919,56 +1037,51 @@ func (bp *boundaryProtection) createIngressDeployment(ctx context.Context, mfc *
 	}
 	if err == nil {
 		log.Infof("Created Ingress Deployment %q", ingressDeployment.GetName())
+		style.Recorded(bp.recorder, mfc, controllerutil.OperationResultCreated, "Deployment", &ingressDeployment)
 	}
-	return err
+	mmv1.AddGeneratedObject(&mfc.Status.GeneratedObjects, style.Generated(appsv1.SchemeGroupVersion.String(), "Deployment", &ingressDeployment))
+	return nil
 }
 
-func boundaryProtectionRemoteIngressService(namespace string, sb *mmv1.ServiceBinding, mfc *mmv1.MeshFedConfig) (*corev1.Service, error) {
-
-	SingleAddressPort := 0 // TODO(mb) what if there are more? if not possible, refactor the for loop
-	SingleAddressIP := ""
-
-	for _, endpoint := range sb.Spec.Endpoints {
-		parts := strings.Split(endpoint, ":")
-		numparts := len(parts)
-		if numparts != 2 {
-			return nil, fmt.Errorf("Address %q not in form ip:port", endpoint)
-		}
-		port, err := strconv.Atoi(parts[1])
-		if err != nil {
-			return nil, err
-		}
-		// TODO Verify parts[0] is an IPv4 or ipv6 address
-		SingleAddressPort = port
-		SingleAddressIP = parts[0]
+// boundaryProtectionRemoteIngressServiceEntry reaches every remote ingress exporting the
+// service, each in its own locality and network
+func boundaryProtectionRemoteIngressServiceEntry(namespace string, sb *mmv1.ServiceBinding, mfc *mmv1.MeshFedConfig) (*v1alpha3.ServiceEntry, error) {
+	eps, err := style.BindingEndpoints(sb)
+	if err != nil {
+		return nil, err
+	}
+	if len(eps) == 0 {
+		return nil, fmt.Errorf("no endpoints found for service binding %v", sb.GetName())
 	}
 
-	svc := corev1.Service{
+	return &v1alpha3.ServiceEntry{
 		TypeMeta: metav1.TypeMeta{
-			Kind: "Service",
+			Kind: "ServiceEntry",
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceRemoteName(mfc, sb),
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
 				"mesh": mfc.GetName(),
 				"role": "remote-ingress-svc",
-			},
-			OwnerReferences: ownerReference(sb.APIVersion, sb.Kind, sb.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, namespace),
 		},
-		Spec: corev1.ServiceSpec{
-			Type:         corev1.ServiceTypeExternalName,
-			ExternalName: SingleAddressIP,
-			Ports: []corev1.ServicePort{
+		Spec: istiov1alpha3.ServiceEntry{
+			Hosts:    []string{fmt.Sprintf("%s.%s.svc.cluster.local", serviceRemoteName(mfc, sb), namespace)},
+			ExportTo: []string{"."},
+			Ports: []*istiov1alpha3.Port{
 				{
-					Name: "tls-for-cross-cluster-communication",
-					Port: int32(SingleAddressPort),
+					Name:     "tls-for-cross-cluster-communication",
+					Number:   defaultGatewayPort,
+					Protocol: "TLS",
 				},
 			},
+			Resolution: style.Resolution(eps),
+			Location:   istiov1alpha3.ServiceEntry_MESH_EXTERNAL,
+			Endpoints:  style.WorkloadEntries(eps, "tls-for-cross-cluster-communication"),
 		},
-	}
-
-	return &svc, nil
+	}, nil
 }
 
 func serviceRemoteName(mfc *mmv1.MeshFedConfig, sb *mmv1.ServiceBinding) string {
@@ -989,15 +1102,18 @@ func boundaryProtectionRemoteDestinationRule(namespace string, mfc *mmv1.MeshFed
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceRemoteName(mfc, sb),
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
 				"mesh": mfc.GetName(),
-			},
-			OwnerReferences: ownerReference(mfc.APIVersion, mfc.Kind, mfc.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, namespace),
 		},
 		Spec: istiov1alpha3.DestinationRule{
 			Host:     serviceRemoteName(mfc, sb),
 			ExportTo: []string{"."},
+			// Spread across the remote ingresses, failing over between them
 			TrafficPolicy: &istiov1alpha3.TrafficPolicy{
+				LoadBalancer:     style.FailoverLoadBalancer(),
+				OutlierDetection: style.FailoverOutlierDetection(),
 				PortLevelSettings: []*istiov1alpha3.TrafficPolicy_PortTrafficPolicy{
 					&istiov1alpha3.TrafficPolicy_PortTrafficPolicy{
 						Port: &istiov1alpha3.PortSelector{
@@ -1018,6 +1134,14 @@ func boundaryProtectionRemoteDestinationRule(namespace string, mfc *mmv1.MeshFed
 }
 
 func boundaryProtectionLocalServiceFacade(namespace string, sb *mmv1.ServiceBinding, mfc *mmv1.MeshFedConfig) corev1.Service {
+	var svcPorts []corev1.ServicePort
+	for _, p := range boundLocalPorts(sb) {
+		svcPorts = append(svcPorts, corev1.ServicePort{
+			Name:       p.Name,
+			Port:       int32(p.Number),
+			TargetPort: intstr.FromInt(int(p.Number)),
+		})
+	}
 	return corev1.Service{
 		TypeMeta: metav1.TypeMeta{
 			Kind: "Service",
@@ -1025,20 +1149,14 @@ func boundaryProtectionLocalServiceFacade(namespace string, sb *mmv1.ServiceBind
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      boundLocalName(sb),
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
 				"mesh": mfc.GetName(),
 				"role": "local-facade",
-			},
-			OwnerReferences: ownerReference(sb.APIVersion, sb.Kind, sb.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, namespace),
 		},
 		Spec: corev1.ServiceSpec{
-			Ports: []corev1.ServicePort{
-				{
-					Name:       "http",
-					Port:       int32(boundLocalPort(sb)),
-					TargetPort: intstr.FromInt(int(boundLocalPort(sb))),
-				},
-			},
+			Ports: svcPorts,
 		},
 	}
 }
@@ -1051,11 +1169,11 @@ func boundaryProtectionLocalServiceEgress(gwSvcName, namespace string, sb *mmv1.
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      gwSvcName,
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
 				"mesh": mfc.GetName(),
 				"role": "local-service-egress",
-			},
-			OwnerReferences: ownerReference(sb.APIVersion, sb.Kind, sb.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, namespace),
 		},
 		Spec: corev1.ServiceSpec{
 			Ports: []corev1.ServicePort{
@@ -1076,10 +1194,10 @@ func boundaryProtectionLocalServiceGateway(gwSvcName, namespace string, sb *mmv1
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("istio-%s-%s", mfc.GetName(), gwSvcName),
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
 				"mesh": mfc.GetName(),
-			},
-			OwnerReferences: ownerReference(sb.APIVersion, sb.Kind, sb.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, namespace),
 		},
 		Spec: istiov1alpha3.Gateway{
 			Selector: mfc.Spec.EgressGatewaySelector, // TODO Handle the case where we defaulted this
@@ -1113,10 +1231,10 @@ func boundaryProtectionLocalServiceDestinationRule(gwSvcName, namespace string,
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("istio-%s", mfc.GetName()),
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
 				"mesh": mfc.GetName(),
-			},
-			OwnerReferences: ownerReference(sb.APIVersion, sb.Kind, sb.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, namespace),
 		},
 		Spec: istiov1alpha3.DestinationRule{
 			Host:     fmt.Sprintf("istio-%s-egress-%d.%s.svc.cluster.local", mfc.GetName(), int32(mfc.Spec.EgressGatewayPort), namespace),
@@ -1155,11 +1273,11 @@ func boundaryProtectionEgressExternalVirtualService(gwSvcName, namespace string,
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      gwSvcName,
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
 				"mesh": mfc.GetName(),
 				"role": "external",
-			},
-			OwnerReferences: ownerReference(sb.APIVersion, sb.Kind, sb.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, namespace),
 		},
 		Spec: istiov1alpha3.VirtualService{
 			Hosts:    []string{fmt.Sprintf("%s.%s.svc.cluster.local", sb.Spec.Name, sb.Spec.Namespace)},
@@ -1195,15 +1313,73 @@ func boundLocalName(sb *mmv1.ServiceBinding) string {
 	return sb.Spec.Name
 }
 
-func boundLocalPort(sb *mmv1.ServiceBinding) uint32 {
-	if sb.Spec.Port != 0 {
-		return sb.Spec.Port
+func boundLocalPorts(sb *mmv1.ServiceBinding) []mmv1.ServicePort {
+	if ports := sb.Spec.ServicePorts(); len(ports) > 0 {
+		return ports
 	}
-	return 5000 // TODO Is port mandatory?  If so, check it before calling this method
+	return mmv1.EffectivePorts(5000, nil) // TODO Are ports mandatory?  If so, check them before calling this method
+}
+
+// httpPorts fails unless every port can be routed on its path at the ingress
+func httpPorts(ports []mmv1.ServicePort) error {
+	for _, p := range ports {
+		if !p.Protocol.IsHTTP() {
+			return fmt.Errorf("Boundary Protection supports HTTP, HTTP2 and GRPC ports, not %s port %q", p.Protocol, p.Name)
+		}
+	}
+	return nil
+}
+
+// portPath is appended to the service path to select port p.  A service with a
+// single port keeps the service path, as before ports were introduced.
+func portPath(p mmv1.ServicePort, ports []mmv1.ServicePort) string {
+	if len(ports) <= 1 {
+		return ""
+	}
+	return p.Name + "/"
+}
+
+func portSuffix(p mmv1.ServicePort, ports []mmv1.ServicePort) string {
+	if len(ports) <= 1 {
+		return ""
+	}
+	return "-" + p.Name
 }
 
 func boundaryProtectionLocalToEgressVirtualService(gwSvcName string, sb *mmv1.ServiceBinding, mfc *mmv1.MeshFedConfig) v1alpha3.VirtualService {
 
+	ports := boundLocalPorts(sb)
+	var routes []*istiov1alpha3.HTTPRoute
+	for _, p := range ports {
+		routes = append(routes, &istiov1alpha3.HTTPRoute{
+			Match: []*istiov1alpha3.HTTPMatchRequest{
+				{
+					Port: p.Number,
+					Uri: &istiov1alpha3.StringMatch{
+						MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: "/"},
+					},
+				},
+			},
+			Rewrite: &istiov1alpha3.HTTPRewrite{
+				// See https://istio.io/docs/reference/config/networking/v1alpha3/virtual-service/#HTTPRewrite
+				// This MUST match the ServiceExposition
+				Uri: servicePathBinding(sb) + portPath(p, ports),
+			},
+			Route: []*istiov1alpha3.HTTPRouteDestination{
+				{
+					Destination: &istiov1alpha3.Destination{
+						Host:   fmt.Sprintf("istio-%s-egress-%d.%s.svc.cluster.local", mfc.GetName(), int32(mfc.Spec.EgressGatewayPort), mfc.GetNamespace()),
+						Subset: gwSvcName,
+						Port: &istiov1alpha3.PortSelector{
+							Number: 443,
+						},
+						// Skip weight, it should default to 100 if left blank
+					},
+				},
+			},
+		})
+	}
+
 	return v1alpha3.VirtualService{
 		TypeMeta: metav1.TypeMeta{
 			Kind: "VirtualService",
@@ -1211,44 +1387,16 @@ func boundaryProtectionLocalToEgressVirtualService(gwSvcName string, sb *mmv1.Se
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      boundLocalName(sb),
 			Namespace: sb.GetNamespace(),
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
 				"mesh": mfc.GetName(),
 				"role": "local-to-egress",
-			},
-			OwnerReferences: ownerReference(sb.APIVersion, sb.Kind, sb.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, sb.GetNamespace()),
 		},
 		Spec: istiov1alpha3.VirtualService{
 			Hosts:    []string{boundLocalName(sb)},
 			ExportTo: []string{"."},
-			Http: []*istiov1alpha3.HTTPRoute{
-				{
-					Match: []*istiov1alpha3.HTTPMatchRequest{
-						{
-							Port: boundLocalPort(sb),
-							Uri: &istiov1alpha3.StringMatch{
-								MatchType: &istiov1alpha3.StringMatch_Prefix{Prefix: "/"},
-							},
-						},
-					},
-					Rewrite: &istiov1alpha3.HTTPRewrite{
-						// See https://istio.io/docs/reference/config/networking/v1alpha3/virtual-service/#HTTPRewrite
-						// This MUST match the ServiceExposition
-						Uri: servicePathBinding(sb),
-					},
-					Route: []*istiov1alpha3.HTTPRouteDestination{
-						{
-							Destination: &istiov1alpha3.Destination{
-								Host:   fmt.Sprintf("istio-%s-egress-%d.%s.svc.cluster.local", mfc.GetName(), int32(mfc.Spec.EgressGatewayPort), mfc.GetNamespace()),
-								Subset: gwSvcName,
-								Port: &istiov1alpha3.PortSelector{
-									Number: 443,
-								},
-								// Skip weight, it should default to 100 if left blank
-							},
-						},
-					},
-				},
-			},
+			Http:     routes,
 		},
 	}
 }
@@ -1257,6 +1405,12 @@ func servicePathBinding(sb *mmv1.ServiceBinding) string {
 	return fmt.Sprintf("/%s/%s/", sb.GetNamespace(), sb.GetName())
 }
 
+// ExposedPathPrefix is the path prefix under which the ingress routes port p of an exposed
+// service.  The ingress rewrites the prefix to / before forwarding the request.
+func ExposedPathPrefix(se *mmv1.ServiceExposition, p mmv1.ServicePort) string {
+	return servicePathExposure(se) + portPath(p, se.Spec.ServicePorts())
+}
+
 func servicePathExposure(se *mmv1.ServiceExposition) string {
 	if se.Spec.Alias != "" {
 		return fmt.Sprintf("/%s/%s/", se.GetNamespace(), se.Spec.Alias)
@@ -0,0 +1,72 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boundary_protection
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// The ingress is looked up on the port of the MeshFedConfig, or the default one
+func TestIngressPort(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	ingress := func(name, ip string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "limited-trust"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: ip}}},
+			},
+		}
+	}
+	cli := fake.NewFakeClientWithScheme(scheme,
+		ingress("istio-c1-ingress-15443", "192.0.2.10"),
+		ingress("istio-c2-ingress-16443", "2001:db8::10"))
+
+	cases := []struct {
+		name     string
+		port     uint32
+		expected []string
+	}{
+		{name: "c1", expected: []string{"192.0.2.10:15443"}},
+		{name: "c2", port: 16443, expected: []string{"[2001:db8::10]:16443"}},
+	}
+	for _, c := range cases {
+		mfc := &mmv1.MeshFedConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: c.name, Namespace: "limited-trust"},
+			Spec:       mmv1.MeshFedConfigSpec{Mode: Mode, UseIngressGateway: true, IngressGatewayPort: c.port},
+		}
+		eps, err := ingressEndpoints(context.Background(), cli, mfc)
+		if err != nil || !reflect.DeepEqual(eps, c.expected) {
+			t.Errorf("%s: endpoints %v, %v, expected %v", c.name, eps, err, c.expected)
+		}
+		addresses, err := ingressAddresses(context.Background(), cli, mfc)
+		if err != nil || !reflect.DeepEqual(addresses, c.expected) {
+			t.Errorf("%s: addresses %v, %v, expected %v", c.name, addresses, err, c.expected)
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
 
+	"github.com/istio-ecosystem/emcee/pkg/metrics"
 	"github.com/istio-ecosystem/emcee/style"
 	mfutil "github.com/istio-ecosystem/emcee/util"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,11 +30,15 @@ import (
 )
 
 const (
-	certificatesDir    = "/etc/istio/mesh/certs/"
-	defaultGatewayPort = uint32(15443)
+	certificatesDir          = "/etc/istio/mesh/certs/"
+	defaultGatewayPort       = uint32(15443)
+	defaultEgressGatewayPort = uint32(443)
 )
 
 var (
+	defaultEgressGatewaySelector = map[string]string{
+		style.ProjectID: "egressgateway",
+	}
 	defaultIngressGatewaySelector = map[string]string{
 		style.ProjectID: "ingressgateway",
 	}
@@ -45,13 +50,18 @@ func createGateway(r istioclient.Interface, namespace string, gateway *v1alpha3.
 	if mfutil.ErrorAlreadyExists(err) {
 		updatedGateway, err := r.NetworkingV1alpha3().Gateways(namespace).Get(context.TODO(), gateway.GetName(), metav1.GetOptions{})
 		if err != nil {
+			metrics.IstioWrite("Gateway", metrics.OperationUpdate, err)
 			log.Warnf("Failed updating Istio gateway %v: %v", gateway.GetName(), err)
 			return updatedGateway, err
 		}
 		updatedGateway.Spec = gateway.Spec
+		updatedGateway.Labels = gateway.Labels
+		updatedGateway.OwnerReferences = gateway.OwnerReferences
 		updatedGateway, err = r.NetworkingV1alpha3().Gateways(namespace).Update(context.TODO(), updatedGateway, metav1.UpdateOptions{})
+		metrics.IstioWrite("Gateway", metrics.OperationUpdate, err)
 		return updatedGateway, err
 	}
+	metrics.IstioWrite("Gateway", metrics.OperationCreate, err)
 	return createdGateway, err
 }
 
@@ -64,14 +74,19 @@ func createVirtualService(r istioclient.Interface, namespace string, vs *v1alpha
 	if mfutil.ErrorAlreadyExists(err) {
 		updatedVirtualService, err := r.NetworkingV1alpha3().VirtualServices(namespace).Get(context.TODO(), vs.GetName(), metav1.GetOptions{})
 		if err != nil {
+			metrics.IstioWrite("VirtualService", metrics.OperationUpdate, err)
 			log.Warnf("Failed updating Istio virtual service %v: %v", vs.GetName(), err)
 			return updatedVirtualService, err
 		}
 		log.Warnf("Updated Istio virtual service %s/%s", vs.GetNamespace(), vs.GetName())
 		updatedVirtualService.Spec = vs.Spec
+		updatedVirtualService.Labels = vs.Labels
+		updatedVirtualService.OwnerReferences = vs.OwnerReferences
 		updatedVirtualService, err = r.NetworkingV1alpha3().VirtualServices(namespace).Update(context.TODO(), updatedVirtualService, metav1.UpdateOptions{})
+		metrics.IstioWrite("VirtualService", metrics.OperationUpdate, err)
 		return updatedVirtualService, err
 	}
+	metrics.IstioWrite("VirtualService", metrics.OperationCreate, err)
 	return createdVirtualService, err
 }
 
@@ -81,12 +96,37 @@ func createDestinationRule(r istioclient.Interface, namespace string, dr *v1alph
 	if mfutil.ErrorAlreadyExists(err) {
 		updatedDestinationRule, err := r.NetworkingV1alpha3().DestinationRules(namespace).Get(context.TODO(), dr.GetName(), metav1.GetOptions{})
 		if err != nil {
+			metrics.IstioWrite("DestinationRule", metrics.OperationUpdate, err)
 			log.Warnf("Failed updating Istio gateway %v: %v", dr.GetName(), err)
 			return updatedDestinationRule, err
 		}
 		updatedDestinationRule.Spec = dr.Spec
+		updatedDestinationRule.Labels = dr.Labels
+		updatedDestinationRule.OwnerReferences = dr.OwnerReferences
 		updatedDestinationRule, err = r.NetworkingV1alpha3().DestinationRules(namespace).Update(context.TODO(), updatedDestinationRule, metav1.UpdateOptions{})
+		metrics.IstioWrite("DestinationRule", metrics.OperationUpdate, err)
 		return updatedDestinationRule, err
 	}
+	metrics.IstioWrite("DestinationRule", metrics.OperationCreate, err)
 	return createdDestinationRule, err
 }
+
+func createServiceEntry(r istioclient.Interface, namespace string, se *v1alpha3.ServiceEntry) (*v1alpha3.ServiceEntry, error) {
+	createdServiceEntry, err := r.NetworkingV1alpha3().ServiceEntries(namespace).Create(context.TODO(), se, metav1.CreateOptions{})
+	if mfutil.ErrorAlreadyExists(err) {
+		updatedServiceEntry, err := r.NetworkingV1alpha3().ServiceEntries(namespace).Get(context.TODO(), se.GetName(), metav1.GetOptions{})
+		if err != nil {
+			metrics.IstioWrite("ServiceEntry", metrics.OperationUpdate, err)
+			log.Warnf("Failed updating Istio service entry %v: %v", se.GetName(), err)
+			return updatedServiceEntry, err
+		}
+		updatedServiceEntry.Spec = se.Spec
+		updatedServiceEntry.Labels = se.Labels
+		updatedServiceEntry.OwnerReferences = se.OwnerReferences
+		updatedServiceEntry, err = r.NetworkingV1alpha3().ServiceEntries(namespace).Update(context.TODO(), updatedServiceEntry, metav1.UpdateOptions{})
+		metrics.IstioWrite("ServiceEntry", metrics.OperationUpdate, err)
+		return updatedServiceEntry, err
+	}
+	metrics.IstioWrite("ServiceEntry", metrics.OperationCreate, err)
+	return createdServiceEntry, err
+}
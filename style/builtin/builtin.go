@@ -0,0 +1,24 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package builtin registers the styles that come with emcee.  Import it for its side effects.
+package builtin
+
+import (
+	// Each style registers itself in its init function
+	_ "github.com/istio-ecosystem/emcee/style/boundary_protection"
+	_ "github.com/istio-ecosystem/emcee/style/gateway_api"
+	_ "github.com/istio-ecosystem/emcee/style/passthrough"
+)
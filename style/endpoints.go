@@ -0,0 +1,151 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package style
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/gogo/protobuf/types"
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	mfutil "github.com/istio-ecosystem/emcee/util"
+
+	istiov1alpha3 "istio.io/api/networking/v1alpha3"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// clusterLabel carries the cluster ID of an endpoint
+const clusterLabel = "topology.istio.io/cluster"
+
+// Endpoint is an endpoint of a binding, usually the ingress of a remote mesh
+type Endpoint struct {
+	Address   string
+	Port      uint32
+	Locality  string
+	Network   string
+	ClusterID string
+}
+
+// BindingEndpoints parses the ip:port endpoints of a binding and places them in its topology
+func BindingEndpoints(sb *mmv1.ServiceBinding) ([]Endpoint, error) {
+	topology := map[string]mmv1.EndpointTopology{}
+	for _, t := range sb.Spec.Topology {
+		topology[t.Endpoint] = t
+	}
+	var eps []Endpoint
+	for _, ep := range sb.Spec.Endpoints {
+		host, port, err := net.SplitHostPort(ep)
+		if err != nil {
+			return nil, fmt.Errorf("Address %q not in form ip:port", ep)
+		}
+		number, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("Address %q has an invalid port: %v", ep, err)
+		}
+		eps = append(eps, Endpoint{
+			Address:   host,
+			Port:      uint32(number),
+			Locality:  topology[ep].Locality,
+			Network:   topology[ep].Network,
+			ClusterID: topology[ep].ClusterID,
+		})
+	}
+	return eps, nil
+}
+
+// WorkloadEntries returns an entry for each endpoint, each port name reaching the endpoint's port
+func WorkloadEntries(eps []Endpoint, portNames ...string) []*istiov1alpha3.WorkloadEntry {
+	var entries []*istiov1alpha3.WorkloadEntry
+	for _, ep := range eps {
+		ports := map[string]uint32{}
+		for _, name := range portNames {
+			ports[name] = ep.Port
+		}
+		var lbls map[string]string
+		if ep.ClusterID != "" {
+			lbls = map[string]string{clusterLabel: ep.ClusterID}
+		}
+		entries = append(entries, &istiov1alpha3.WorkloadEntry{
+			Address:  ep.Address,
+			Ports:    ports,
+			Labels:   lbls,
+			Locality: ep.Locality,
+			Network:  ep.Network,
+		})
+	}
+	return entries
+}
+
+// ExposedTopology places the endpoints of an exposition where mfc says, by default where the
+// node of a pod of the named ingress service is
+func ExposedTopology(ctx context.Context, cli client.Client, recorder record.EventRecorder, se *mmv1.ServiceExposition,
+	mfc *mmv1.MeshFedConfig, ingress, namespace string) {
+	se.Spec.Locality = mfc.Spec.Locality
+	se.Spec.Network = mfc.Spec.Network
+	se.Spec.ClusterID = mfc.Spec.ClusterID
+	if se.Spec.Locality != "" && se.Spec.Network != "" {
+		return
+	}
+	locality, network, err := mfutil.GetIngressTopology(ctx, cli, ingress, namespace)
+	if err != nil {
+		Warn(recorder, se, EventTopologyUnknown, "Could not find the topology of ingress %s.%s: %v", ingress, namespace, err)
+		return
+	}
+	if se.Spec.Locality == "" {
+		se.Spec.Locality = locality
+	}
+	if se.Spec.Network == "" {
+		se.Spec.Network = network
+	}
+}
+
+// Resolution is STATIC if every endpoint is an IP address, else DNS
+func Resolution(eps []Endpoint) istiov1alpha3.ServiceEntry_Resolution {
+	for _, ep := range eps {
+		if net.ParseIP(ep.Address) == nil {
+			return istiov1alpha3.ServiceEntry_DNS
+		}
+	}
+	return istiov1alpha3.ServiceEntry_STATIC
+}
+
+// FailoverLoadBalancer prefers endpoints in the client's locality, failing over to other
+// localities, and so other meshes, when outlier detection ejects the local ones
+func FailoverLoadBalancer() *istiov1alpha3.LoadBalancerSettings {
+	return &istiov1alpha3.LoadBalancerSettings{
+		LocalityLbSetting: &istiov1alpha3.LocalityLoadBalancerSetting{
+			Enabled: &types.BoolValue{Value: true},
+		},
+	}
+}
+
+// FailoverOutlierDetection ejects failing endpoints.  Istio only fails over between
+// localities when outlier detection is configured.
+func FailoverOutlierDetection() *istiov1alpha3.OutlierDetection {
+	return &istiov1alpha3.OutlierDetection{
+		BaseEjectionTime: &types.Duration{
+			Seconds: 20,
+		},
+		ConsecutiveErrors: 2,
+		Interval: &types.Duration{
+			Seconds: 5,
+		},
+		MaxEjectionPercent: 75,
+	}
+}
@@ -0,0 +1,68 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package style
+
+import (
+	"fmt"
+
+	"istio.io/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// The reasons of the events the styles record on the objects they reconcile
+const (
+	EventCreated           = "Created"
+	EventUpdated           = "Updated"
+	EventDeleted           = "Deleted"
+	EventTopologyUnknown   = "TopologyUnknown"
+	EventDefaultedWorkload = "DefaultedWorkload"
+)
+
+// Recorded records a Normal event on owner when obj, of kind, was created or updated for it.
+// A nil recorder records nothing.
+func Recorded(recorder record.EventRecorder, owner runtime.Object, result controllerutil.OperationResult, kind string, obj metav1.Object) {
+	if recorder == nil {
+		return
+	}
+	switch result {
+	case controllerutil.OperationResultCreated:
+		recorder.Eventf(owner, corev1.EventTypeNormal, EventCreated, "Created %s %s/%s", kind, obj.GetNamespace(), obj.GetName())
+	case controllerutil.OperationResultUpdated:
+		recorder.Eventf(owner, corev1.EventTypeNormal, EventUpdated, "Updated %s %s/%s", kind, obj.GetNamespace(), obj.GetName())
+	}
+}
+
+// Warn logs a problem that does not stop the reconcile and records it as a Warning event on owner
+func Warn(recorder record.EventRecorder, owner runtime.Object, reason, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Warnf("%s", message)
+	if recorder != nil {
+		recorder.Event(owner, corev1.EventTypeWarning, reason, message)
+	}
+}
+
+// Normal logs what a style did and records it as a Normal event on owner
+func Normal(recorder record.EventRecorder, owner runtime.Object, reason, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Infof("%s", message)
+	if recorder != nil {
+		recorder.Event(owner, corev1.EventTypeNormal, reason, message)
+	}
+}
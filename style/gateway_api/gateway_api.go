@@ -0,0 +1,679 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway_api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	multierror "github.com/hashicorp/go-multierror"
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/style"
+	"istio.io/pkg/log"
+
+	istioclient "istio.io/client-go/pkg/clientset/versioned"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// GatewayAPI implements federation with Kubernetes Gateway API resources instead of Istio's
+// networking v1alpha3.  The MeshFedConfig's ingress is a Gateway with a TLS passthrough listener.
+// Exposed services are routed to by SNI with TLSRoutes, and bound services are local Services
+// whose HTTPRoutes or TLSRoutes lead to a Service with the remote ingresses as endpoints.  The
+// remote ingress only accepts TLS, so a BackendTLSPolicy per port originates TLS to that Service
+// with the SNI the exposing side routes by.
+type GatewayAPI struct {
+	client.Client
+	istioclient.Interface
+	recorder record.EventRecorder
+}
+
+var (
+	// (compile-time check that we implement the interface)
+	_ style.MeshFedConfig  = &GatewayAPI{}
+	_ style.ServiceBinder  = &GatewayAPI{}
+	_ style.ServiceExposer = &GatewayAPI{}
+)
+
+// Mode selects the Gateway API style
+const Mode = "GATEWAY_API"
+
+func init() {
+	style.Register(style.Style{
+		Mode:              Mode,
+		NewMeshFedConfig:  NewGatewayAPIMeshFedConfig,
+		NewServiceExposer: NewGatewayAPIServiceExposer,
+		NewServiceBinder:  NewGatewayAPIServiceBinder,
+		Validate:          validateMeshFedConfig,
+		Default:           defaultMeshFedConfig,
+		IngressAddresses: func(ctx context.Context, cli client.Client, mfc *mmv1.MeshFedConfig) ([]string, error) {
+			return (&GatewayAPI{Client: cli}).ingressAddresses(ctx, mfc, true)
+		},
+		IngressScheme: "https",
+	})
+}
+
+// validateMeshFedConfig rejects the gateway settings the Gateway API does not use.  It deploys
+// the ingress of its Gateway, and there is no egress.
+func validateMeshFedConfig(name, namespace string, mfc mmv1.MeshFedConfigSpec) error {
+	var retval error
+	if mfc.UseEgressGateway || len(mfc.EgressGatewaySelector) != 0 || mfc.EgressGatewayPort != 0 {
+		retval = multierror.Append(retval, fmt.Errorf("%s/%s: %q does not use an egress gateway", namespace, name, Mode))
+	}
+
+	if len(mfc.IngressGatewaySelector) != 0 {
+		retval = multierror.Append(retval, fmt.Errorf("%s/%s: %q deploys its own ingress, but selects one", namespace, name, Mode))
+	}
+
+	if !mfc.UseIngressGateway && mfc.IngressGatewayPort != 0 {
+		retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify ingress, but specifies port %v", namespace, name, mfc.IngressGatewayPort))
+	}
+	return retval
+}
+
+// defaultMeshFedConfig sets the port of the ingress Gateway's listener
+func defaultMeshFedConfig(mfc *mmv1.MeshFedConfigSpec) {
+	if mfc.UseIngressGateway && mfc.IngressGatewayPort == 0 {
+		mfc.IngressGatewayPort = defaultIngressPort
+	}
+}
+
+const (
+	// gatewayClassName is the GatewayClass Istio installs
+	gatewayClassName = "istio"
+	// listenerName is the name of the ingress Gateway's listener
+	listenerName = "tls-passthrough"
+	// defaultIngressPort is the port of the listener if the MeshFedConfig does not set one
+	defaultIngressPort = 15443
+	// rootCertConfigMap is the ConfigMap Istio fills with the mesh root certificate in every
+	// namespace
+	rootCertConfigMap = "istio-ca-root-cert"
+)
+
+// NewGatewayAPIMeshFedConfig creates a "Gateway API" style implementation for handling MeshFedConfig
+func NewGatewayAPIMeshFedConfig(cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) style.MeshFedConfig {
+	return &GatewayAPI{
+		cli,
+		istioCli,
+		recorder,
+	}
+}
+
+// NewGatewayAPIServiceExposer creates a "Gateway API" style implementation for handling ServiceExposure
+func NewGatewayAPIServiceExposer(cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) style.ServiceExposer {
+	return &GatewayAPI{
+		cli,
+		istioCli,
+		recorder,
+	}
+}
+
+// NewGatewayAPIServiceBinder creates a "Gateway API" style implementation for handling ServiceBinding
+func NewGatewayAPIServiceBinder(cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) style.ServiceBinder {
+	return &GatewayAPI{
+		cli,
+		istioCli,
+		recorder,
+	}
+}
+
+// ***************************
+// *** EffectMeshFedConfig ***
+// ***************************
+
+// EffectMeshFedConfig creates the ingress Gateway if the MeshFedConfig uses an ingress
+func (ga *GatewayAPI) EffectMeshFedConfig(ctx context.Context, mfc *mmv1.MeshFedConfig) error {
+	if !mfc.Spec.UseIngressGateway {
+		return nil
+	}
+	gw := ingressGateway(mfc)
+	or, err := createOrUpdate(ctx, ga.Client, gw)
+	if err != nil {
+		log.Warnf("Could not create the Gateway %s/%s: %v", gw.GetNamespace(), gw.GetName(), err)
+		return style.Failed(&mfc.Status.Conditions, mmv1.ReasonGatewayFailed, err)
+	}
+	mmv1.AddGeneratedObject(&mfc.Status.GeneratedObjects, style.Generated(style.GatewayGVK.GroupVersion().String(), "Gateway", gw))
+	style.Recorded(ga.recorder, mfc, or, "Gateway", gw)
+	return nil
+}
+
+// RemoveMeshFedConfig deletes the Gateway generated for the MeshFedConfig
+func (ga *GatewayAPI) RemoveMeshFedConfig(ctx context.Context, mfc *mmv1.MeshFedConfig) error {
+	return style.RemoveGenerated(ctx, ga.Client, ga.Interface, style.KindMeshFedConfig, mfc)
+}
+
+// *****************************
+// *** EffectServiceExposure ***
+// *****************************
+
+// EffectServiceExposure routes each port of the exposed service from the ingress Gateway.  The
+// routes live with the Gateway, and a ReferenceGrant lets them reach the service.
+func (ga *GatewayAPI) EffectServiceExposure(ctx context.Context, se *mmv1.ServiceExposition, mfc *mmv1.MeshFedConfig) error {
+	if !mfc.Spec.UseIngressGateway {
+		return style.Failed(&se.Status.Conditions, mmv1.ReasonGatewayFailed,
+			fmt.Errorf("the Gateway API style requires use_ingress_gateway to expose services"))
+	}
+	ports := se.Spec.ServicePorts()
+	if len(ports) == 0 {
+		return style.Failed(&se.Status.Conditions, mmv1.ReasonRouteFailed,
+			fmt.Errorf("the Gateway API style requires the ports of %s", se.Spec.Name))
+	}
+
+	eps, err := ga.ingressAddresses(ctx, mfc, false)
+	if err != nil {
+		log.Warnf("could not get endpoints %v %v", eps, err)
+		style.EndpointsUnresolved(&se.Status.Conditions, err)
+		return style.Failed(&se.Status.Conditions, mmv1.ReasonEndpointsUnavailable, err)
+	}
+	se.Spec.Endpoints = eps
+	style.EndpointsResolved(&se.Status.Conditions, eps)
+	// Istio deploys the Gateway as a Service named after it and its class
+	style.ExposedTopology(ctx, ga.Client, ga.recorder, se, mfc, gatewayName(mfc)+"-"+gatewayClassName, mfc.GetNamespace())
+
+	if se.GetNamespace() != mfc.GetNamespace() {
+		rg := exposingReferenceGrant(mfc, se)
+		or, err := createOrUpdate(ctx, ga.Client, rg)
+		if err != nil {
+			log.Warnf("Could not create the ReferenceGrant %s/%s: %v", rg.GetNamespace(), rg.GetName(), err)
+			return style.Failed(&se.Status.Conditions, mmv1.ReasonReferenceGrantFailed, err)
+		}
+		mmv1.AddGeneratedObject(&se.Status.GeneratedObjects, style.Generated(style.ReferenceGrantGVK.GroupVersion().String(), "ReferenceGrant", rg))
+		style.Recorded(ga.recorder, se, or, "ReferenceGrant", rg)
+	}
+
+	for _, p := range ports {
+		route := exposingTLSRoute(mfc, se, p, ports)
+		or, err := createOrUpdate(ctx, ga.Client, route)
+		if err != nil {
+			log.Warnf("Could not create the TLSRoute %s/%s: %v", route.GetNamespace(), route.GetName(), err)
+			return style.Failed(&se.Status.Conditions, mmv1.ReasonRouteFailed, err)
+		}
+		mmv1.AddGeneratedObject(&se.Status.GeneratedObjects, style.Generated(style.TLSRouteGVK.GroupVersion().String(), "TLSRoute", route))
+		style.Recorded(ga.recorder, se, or, "TLSRoute", route)
+	}
+
+	// Update() returns the stored status; keep the one we are building for the controller
+	status := se.Status.DeepCopy()
+	if err := ga.Client.Update(ctx, se); err != nil {
+		return err
+	}
+	se.Status = *status
+
+	return nil
+}
+
+// RemoveServiceExposure deletes the TLSRoutes and ReferenceGrant generated for the exposure
+func (ga *GatewayAPI) RemoveServiceExposure(ctx context.Context, se *mmv1.ServiceExposition, mfc *mmv1.MeshFedConfig) error {
+	return style.RemoveGenerated(ctx, ga.Client, ga.Interface, style.KindServiceExposition, se)
+}
+
+// ingressAddresses returns the address:port of the ingress Gateway from its status.  Only
+// IP addresses are endpoints remote meshes bind to; hostnames adds the addresses of type
+// Hostname for clients that resolve them.
+func (ga *GatewayAPI) ingressAddresses(ctx context.Context, mfc *mmv1.MeshFedConfig, hostnames bool) ([]string, error) {
+	gw := &unstructured.Unstructured{}
+	gw.SetGroupVersionKind(style.GatewayGVK)
+	nsn := types.NamespacedName{Namespace: mfc.GetNamespace(), Name: gatewayName(mfc)}
+	if err := ga.Client.Get(ctx, nsn, gw); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, fmt.Errorf("Gateway %v not created yet", nsn)
+		}
+		return nil, err
+	}
+	addresses := gatewayAddresses(gw, hostnames)
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("Gateway %v has no addresses yet", nsn)
+	}
+	port := strconv.Itoa(int(ingressPort(mfc)))
+	var eps []string
+	for _, a := range addresses {
+		eps = append(eps, net.JoinHostPort(a, port))
+	}
+	return eps, nil
+}
+
+// ****************************
+// *** EffectServiceBinding ***
+// ****************************
+
+// EffectServiceBinding creates a Service whose endpoints are the remote ingresses, reached over
+// mutual TLS, and the local Service clients call, routed to it port by port
+func (ga *GatewayAPI) EffectServiceBinding(ctx context.Context, sb *mmv1.ServiceBinding, mfc *mmv1.MeshFedConfig) error {
+	style.EndpointsResolved(&sb.Status.Conditions, sb.Spec.Endpoints)
+	if len(sb.Spec.Endpoints) == 0 {
+		// Discovery has not filled in the endpoints yet
+		return nil
+	}
+	eps, err := style.BindingEndpoints(sb)
+	if err == nil {
+		for _, ep := range eps {
+			if net.ParseIP(ep.Address) == nil {
+				err = fmt.Errorf("the Gateway API style requires ip:port endpoints, not %q", ep.Address)
+				break
+			}
+		}
+	}
+	if err != nil {
+		style.EndpointsUnresolved(&sb.Status.Conditions, err)
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonEndpointsUnavailable, err)
+	}
+	ports := boundLocalPorts(sb)
+
+	// The remote Service has no selector; its Endpoints list the remote ingresses
+	remote := bindingRemoteService(mfc, sb, ports)
+	if err := ga.createOrUpdateService(ctx, sb, remote); err != nil {
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonServiceFailed, err)
+	}
+	goalEndpoints := bindingRemoteEndpoints(remote, eps, ports)
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      goalEndpoints.GetName(),
+			Namespace: goalEndpoints.GetNamespace(),
+		},
+	}
+	or, err := controllerutil.CreateOrUpdate(ctx, ga.Client, endpoints, func() error {
+		endpoints.ObjectMeta.Labels = goalEndpoints.Labels
+		endpoints.ObjectMeta.OwnerReferences = goalEndpoints.OwnerReferences
+		endpoints.Subsets = goalEndpoints.Subsets
+		return nil
+	})
+	if err != nil {
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonServiceFailed, err)
+	}
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated("v1", "Endpoints", endpoints))
+	style.Recorded(ga.recorder, sb, or, "Endpoints", endpoints)
+
+	// The remote ingress passes TLS through by SNI; the mesh originates it
+	for _, p := range ports {
+		policy := bindingBackendTLSPolicy(sb, remote, p, ports)
+		or, err := createOrUpdate(ctx, ga.Client, policy)
+		if err != nil {
+			log.Warnf("Could not create the BackendTLSPolicy %s/%s: %v", policy.GetNamespace(), policy.GetName(), err)
+			return style.Failed(&sb.Status.Conditions, mmv1.ReasonBackendTLSPolicyFailed, err)
+		}
+		mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated(style.BackendTLSPolicyGVK.GroupVersion().String(), "BackendTLSPolicy", policy))
+		style.Recorded(ga.recorder, sb, or, "BackendTLSPolicy", policy)
+	}
+
+	local := bindingLocalService(sb, ports)
+	if err := ga.createOrUpdateService(ctx, sb, local); err != nil {
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonServiceFailed, err)
+	}
+
+	for _, p := range ports {
+		route := bindingRoute(mfc, sb, p, local.GetName(), remote.GetName())
+		or, err := createOrUpdate(ctx, ga.Client, route)
+		if err != nil {
+			log.Warnf("Could not create the %s %s/%s: %v", route.GetKind(), route.GetNamespace(), route.GetName(), err)
+			return style.Failed(&sb.Status.Conditions, mmv1.ReasonRouteFailed, err)
+		}
+		mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated(route.GetAPIVersion(), route.GetKind(), route))
+		style.Recorded(ga.recorder, sb, or, route.GetKind(), route)
+	}
+
+	return nil
+}
+
+// RemoveServiceBinding deletes the Services, Endpoints, routes and BackendTLSPolicies generated for the binding
+func (ga *GatewayAPI) RemoveServiceBinding(ctx context.Context, sb *mmv1.ServiceBinding, mfc *mmv1.MeshFedConfig) error {
+	return style.RemoveGenerated(ctx, ga.Client, ga.Interface, style.KindServiceBinding, sb)
+}
+
+// createOrUpdateService writes goal without clearing its ClusterIP
+func (ga *GatewayAPI) createOrUpdateService(ctx context.Context, sb *mmv1.ServiceBinding, goal *corev1.Service) error {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      goal.GetName(),
+			Namespace: goal.GetNamespace(),
+		},
+	}
+	or, err := controllerutil.CreateOrUpdate(ctx, ga.Client, svc, func() error {
+		svc.ObjectMeta.Labels = goal.Labels
+		svc.ObjectMeta.OwnerReferences = goal.ObjectMeta.OwnerReferences
+		svc.Spec.Ports = goal.Spec.Ports
+		return nil
+	})
+	if err != nil {
+		log.Warnf("Could not create the Service %s/%s: %v", goal.GetNamespace(), goal.GetName(), err)
+		return err
+	}
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated("v1", "Service", svc))
+	style.Recorded(ga.recorder, sb, or, "Service", svc)
+	return nil
+}
+
+// *****************************
+// *****************************
+// *****************************
+
+func ingressGateway(mfc *mmv1.MeshFedConfig) *unstructured.Unstructured {
+	return newObject(style.GatewayGVK, mfc.GetNamespace(), gatewayName(mfc),
+		style.OwnerLabels(style.KindMeshFedConfig, mfc, map[string]string{
+			"mesh": mfc.GetName(),
+		}),
+		style.OwnerReferences(style.KindMeshFedConfig, mfc, mfc.GetNamespace()),
+		map[string]interface{}{
+			"gatewayClassName": gatewayClassName,
+			"listeners": []interface{}{
+				map[string]interface{}{
+					"name":     listenerName,
+					"port":     int64(ingressPort(mfc)),
+					"protocol": "TLS",
+					"tls": map[string]interface{}{
+						"mode": "Passthrough",
+					},
+					"allowedRoutes": map[string]interface{}{
+						"namespaces": map[string]interface{}{
+							"from": "Same",
+						},
+						"kinds": []interface{}{
+							map[string]interface{}{
+								"kind": style.TLSRouteGVK.Kind,
+							},
+						},
+					},
+				},
+			},
+		})
+}
+
+// exposingReferenceGrant lets the TLSRoutes in the namespace of mfc reach the exposed service
+func exposingReferenceGrant(mfc *mmv1.MeshFedConfig, se *mmv1.ServiceExposition) *unstructured.Unstructured {
+	return newObject(style.ReferenceGrantGVK, se.GetNamespace(), serviceExposeName(mfc.GetName(), se.GetName()),
+		style.OwnerLabels(style.KindServiceExposition, se, map[string]string{
+			"mesh": mfc.GetName(),
+		}),
+		style.OwnerReferences(style.KindServiceExposition, se, se.GetNamespace()),
+		map[string]interface{}{
+			"from": []interface{}{
+				map[string]interface{}{
+					"group":     style.GatewayAPIGroup,
+					"kind":      style.TLSRouteGVK.Kind,
+					"namespace": mfc.GetNamespace(),
+				},
+			},
+			"to": []interface{}{
+				map[string]interface{}{
+					"group": "",
+					"kind":  "Service",
+					"name":  se.Spec.Name,
+				},
+			},
+		})
+}
+
+// exposingTLSRoute routes port p of the exposed service from the ingress Gateway by SNI.  The
+// routes are in the namespace of the Gateway, whose listener only admits routes from there.
+func exposingTLSRoute(mfc *mmv1.MeshFedConfig, se *mmv1.ServiceExposition, p mmv1.ServicePort, ports []mmv1.ServicePort) *unstructured.Unstructured {
+	exposedHost := fmt.Sprintf("%s.%s.svc.cluster.local", exposedLocalName(se), se.GetNamespace())
+	return newObject(style.TLSRouteGVK, mfc.GetNamespace(),
+		fmt.Sprintf("exposition-%s-%s-%s", se.GetNamespace(), se.GetName(), p.Name),
+		style.OwnerLabels(style.KindServiceExposition, se, map[string]string{
+			"mesh": mfc.GetName(),
+		}),
+		style.OwnerReferences(style.KindServiceExposition, se, mfc.GetNamespace()),
+		map[string]interface{}{
+			"parentRefs": []interface{}{
+				map[string]interface{}{
+					"name":        gatewayName(mfc),
+					"sectionName": listenerName,
+				},
+			},
+			"hostnames": []interface{}{
+				portHost(exposedHost, p, ports),
+			},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"backendRefs": []interface{}{
+						map[string]interface{}{
+							"name":      se.Spec.Name,
+							"namespace": se.GetNamespace(),
+							"port":      int64(p.Number),
+						},
+					},
+				},
+			},
+		})
+}
+
+// bindingRemoteService is the Service whose endpoints are the remote ingresses
+func bindingRemoteService(mfc *mmv1.MeshFedConfig, sb *mmv1.ServiceBinding, ports []mmv1.ServicePort) *corev1.Service {
+	namespace := sb.GetNamespace()
+	var svcPorts []corev1.ServicePort
+	for _, p := range ports {
+		svcPorts = append(svcPorts, corev1.ServicePort{
+			Name: p.Name,
+			Port: int32(p.Number),
+		})
+	}
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceRemoteName(mfc.GetName(), sb.GetName()),
+			Namespace: namespace,
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
+				"mesh": mfc.GetName(),
+				"role": "remote-ingress",
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, namespace),
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: svcPorts,
+		},
+	}
+}
+
+// bindingRemoteEndpoints lists the remote ingresses as the endpoints of svc.  Every port of the
+// service reaches each ingress on the endpoint's port.
+func bindingRemoteEndpoints(svc *corev1.Service, eps []style.Endpoint, ports []mmv1.ServicePort) *corev1.Endpoints {
+	byPort := map[uint32][]corev1.EndpointAddress{}
+	for _, ep := range eps {
+		byPort[ep.Port] = append(byPort[ep.Port], corev1.EndpointAddress{IP: ep.Address})
+	}
+	var numbers []uint32
+	for n := range byPort {
+		numbers = append(numbers, n)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	var subsets []corev1.EndpointSubset
+	for _, n := range numbers {
+		subset := corev1.EndpointSubset{Addresses: byPort[n]}
+		for _, p := range ports {
+			subset.Ports = append(subset.Ports, corev1.EndpointPort{
+				Name:     p.Name,
+				Port:     int32(n),
+				Protocol: corev1.ProtocolTCP,
+			})
+		}
+		subsets = append(subsets, subset)
+	}
+	return &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            svc.GetName(),
+			Namespace:       svc.GetNamespace(),
+			Labels:          svc.Labels,
+			OwnerReferences: svc.OwnerReferences,
+		},
+		Subsets: subsets,
+	}
+}
+
+// bindingBackendTLSPolicy originates TLS to port p of the remote ingresses of svc with the SNI
+// of the same port of the exposed service, which the exposing TLSRoutes match.  The exposed
+// service is validated against the mesh root certificate, which federated meshes share.
+func bindingBackendTLSPolicy(sb *mmv1.ServiceBinding, svc *corev1.Service, p mmv1.ServicePort, ports []mmv1.ServicePort) *unstructured.Unstructured {
+	exposedHost := fmt.Sprintf("%s.%s.svc.cluster.local", sb.Spec.Name, boundNamespace(sb))
+	return newObject(style.BackendTLSPolicyGVK, svc.GetNamespace(),
+		fmt.Sprintf("%s-%s", svc.GetName(), p.Name),
+		svc.Labels,
+		svc.OwnerReferences,
+		map[string]interface{}{
+			"targetRefs": []interface{}{
+				map[string]interface{}{
+					"group":       "",
+					"kind":        "Service",
+					"name":        svc.GetName(),
+					"sectionName": p.Name,
+				},
+			},
+			"validation": map[string]interface{}{
+				"hostname": portHost(exposedHost, p, ports),
+				"caCertificateRefs": []interface{}{
+					map[string]interface{}{
+						"group": "",
+						"kind":  "ConfigMap",
+						"name":  rootCertConfigMap,
+					},
+				},
+			},
+		})
+}
+
+// bindingLocalService is the Service clients of the bound service call
+func bindingLocalService(sb *mmv1.ServiceBinding, ports []mmv1.ServicePort) *corev1.Service {
+	namespace := sb.GetNamespace()
+	var svcPorts []corev1.ServicePort
+	for _, p := range ports {
+		svcPorts = append(svcPorts, corev1.ServicePort{
+			Name: p.Name,
+			Port: int32(p.Number),
+		})
+	}
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      boundLocalName(sb),
+			Namespace: namespace,
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
+				"mesh": sb.Spec.Name,
+				"role": "ingress-svc",
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, namespace),
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: svcPorts,
+		},
+	}
+}
+
+// bindingRoute routes port p of the local Service to the remote Service, with an HTTPRoute for
+// HTTP protocols and a TLSRoute for the others
+func bindingRoute(mfc *mmv1.MeshFedConfig, sb *mmv1.ServiceBinding, p mmv1.ServicePort, local, remote string) *unstructured.Unstructured {
+	namespace := sb.GetNamespace()
+	gvk := style.TLSRouteGVK
+	if p.Protocol.IsHTTP() {
+		gvk = style.HTTPRouteGVK
+	}
+	return newObject(gvk, namespace,
+		fmt.Sprintf("binding-%s-%s-%s", mfc.GetName(), sb.GetName(), p.Name),
+		style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
+			"mesh": mfc.GetName(),
+		}),
+		style.OwnerReferences(style.KindServiceBinding, sb, namespace),
+		map[string]interface{}{
+			// A Service parent attaches the route to the mesh traffic to that Service
+			"parentRefs": []interface{}{
+				map[string]interface{}{
+					"group": "",
+					"kind":  "Service",
+					"name":  local,
+					"port":  int64(p.Number),
+				},
+			},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"backendRefs": []interface{}{
+						map[string]interface{}{
+							"name": remote,
+							"port": int64(p.Number),
+						},
+					},
+				},
+			},
+		})
+}
+
+func ingressPort(mfc *mmv1.MeshFedConfig) uint32 {
+	if mfc.Spec.IngressGatewayPort == 0 {
+		return defaultIngressPort
+	}
+	return mfc.Spec.IngressGatewayPort
+}
+
+func gatewayName(mfc *mmv1.MeshFedConfig) string {
+	return fmt.Sprintf("%s-%s-ingress", style.ProjectID, mfc.GetName())
+}
+
+func serviceRemoteName(mfcName, svcName string) string {
+	return fmt.Sprintf("binding-%s-%s-intermesh", mfcName, svcName)
+}
+
+func serviceExposeName(mfcName, svcName string) string {
+	return fmt.Sprintf("exposition-%s-%s-intermesh", mfcName, svcName)
+}
+
+// portHost is the SNI of port p of host.  A service with a single port keeps the plain host
+// name; the others prefix it with the port name, as Gateway API host names are DNS names.
+func portHost(host string, p mmv1.ServicePort, ports []mmv1.ServicePort) string {
+	if len(ports) <= 1 {
+		return host
+	}
+	return p.Name + "." + host
+}
+
+func boundLocalPorts(sb *mmv1.ServiceBinding) []mmv1.ServicePort {
+	if ports := sb.Spec.ServicePorts(); len(ports) > 0 {
+		return ports
+	}
+	return mmv1.EffectivePorts(80, nil)
+}
+
+// boundNamespace is the namespace of the bound service in the exposing mesh
+func boundNamespace(sb *mmv1.ServiceBinding) string {
+	if sb.Spec.Namespace != "" {
+		return sb.Spec.Namespace
+	}
+	return sb.GetNamespace()
+}
+
+func boundLocalName(sb *mmv1.ServiceBinding) string {
+	if sb.Spec.Alias != "" {
+		return sb.Spec.Alias
+	}
+	return sb.Spec.Name
+}
+
+func exposedLocalName(se *mmv1.ServiceExposition) string {
+	if se.Spec.Alias != "" {
+		return se.Spec.Alias
+	}
+	return se.Spec.Name
+}
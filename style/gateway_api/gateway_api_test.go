@@ -0,0 +1,222 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway_api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/style"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var testPorts = []mmv1.ServicePort{
+	{Name: "http", Number: 5000, TargetPort: 5000, Protocol: mmv1.ProtocolHTTP},
+	{Name: "grpc", Number: 6000, TargetPort: 6000, Protocol: mmv1.ProtocolGRPC},
+}
+
+func testMeshFedConfig() *mmv1.MeshFedConfig {
+	return &mmv1.MeshFedConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "gwapi", Namespace: "mesh-system", UID: "mfc-uid"},
+		Spec: mmv1.MeshFedConfigSpec{
+			Mode:               Mode,
+			UseIngressGateway:  true,
+			IngressGatewayPort: 15443,
+		},
+	}
+}
+
+func newTestGatewayAPI(t *testing.T, objs ...runtime.Object) *GatewayAPI {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	for _, gvk := range []schema.GroupVersionKind{style.GatewayGVK, style.HTTPRouteGVK, style.TLSRouteGVK, style.ReferenceGrantGVK, style.BackendTLSPolicyGVK} {
+		scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+	}
+	return &GatewayAPI{
+		Client:    fake.NewFakeClientWithScheme(scheme, objs...),
+		Interface: istiofake.NewSimpleClientset(),
+	}
+}
+
+// The SNI the binding side originates is the one the exposing side routes by, also when the
+// binding was imported into a mapped namespace
+func TestBindingSNIMatchesExposedRoutes(t *testing.T) {
+	mfc := testMeshFedConfig()
+	for _, ports := range [][]mmv1.ServicePort{testPorts[:1], testPorts} {
+		se := &mmv1.ServiceExposition{
+			ObjectMeta: metav1.ObjectMeta{Name: "helloworld", Namespace: "shop"},
+			Spec:       mmv1.ServiceExpositionSpec{Name: "helloworld", Ports: ports},
+		}
+		sb := &mmv1.ServiceBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "helloworld", Namespace: "cluster2-shop"},
+			Spec:       mmv1.ServiceBindingSpec{Name: "helloworld", Namespace: "shop", Ports: ports},
+		}
+		remote := bindingRemoteService(mfc, sb, ports)
+		if remote.GetNamespace() != "cluster2-shop" {
+			t.Errorf("remote Service in %q, expected the binding's namespace", remote.GetNamespace())
+		}
+		for _, p := range ports {
+			policy := bindingBackendTLSPolicy(sb, remote, p, ports)
+			targets, _, _ := unstructured.NestedSlice(policy.Object, "spec", "targetRefs")
+			expected := []interface{}{map[string]interface{}{"group": "", "kind": "Service", "name": remote.GetName(), "sectionName": p.Name}}
+			if !reflect.DeepEqual(targets, expected) {
+				t.Errorf("port %s policy targets %v", p.Name, targets)
+			}
+			sni, _, _ := unstructured.NestedString(policy.Object, "spec", "validation", "hostname")
+			route := exposingTLSRoute(mfc, se, p, ports)
+			hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+			if sni == "" || !reflect.DeepEqual(hostnames, []string{sni}) {
+				t.Errorf("port %s originates TLS with SNI %q, the exposing route matches %v", p.Name, sni, hostnames)
+			}
+		}
+	}
+}
+
+func TestEffectServiceBinding(t *testing.T) {
+	ga := newTestGatewayAPI(t)
+	mfc := testMeshFedConfig()
+	sb := &mmv1.ServiceBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "helloworld", Namespace: "shop", UID: "sb-uid"},
+		Spec: mmv1.ServiceBindingSpec{
+			Name:      "helloworld",
+			Namespace: "shop",
+			Ports:     testPorts,
+			Endpoints: []string{"10.0.0.1:15443", "10.0.0.2:15443"},
+		},
+	}
+	ctx := context.Background()
+	if err := ga.EffectServiceBinding(ctx, sb, mfc); err != nil {
+		t.Fatalf("EffectServiceBinding failed: %v", err)
+	}
+
+	var endpoints corev1.Endpoints
+	if err := ga.Client.Get(ctx, types.NamespacedName{Namespace: "shop", Name: "binding-gwapi-helloworld-intermesh"}, &endpoints); err != nil {
+		t.Fatalf("remote Endpoints not created: %v", err)
+	}
+	if len(endpoints.Subsets) != 1 || len(endpoints.Subsets[0].Addresses) != 2 || len(endpoints.Subsets[0].Ports) != 2 {
+		t.Errorf("unexpected remote endpoints: %v", endpoints.Subsets)
+	}
+	if err := ga.Client.Get(ctx, types.NamespacedName{Namespace: "shop", Name: "helloworld"}, &corev1.Service{}); err != nil {
+		t.Errorf("local Service not created: %v", err)
+	}
+	for _, p := range testPorts {
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(style.HTTPRouteGVK)
+		if err := ga.Client.Get(ctx, types.NamespacedName{Namespace: "shop", Name: "binding-gwapi-helloworld-" + p.Name}, route); err != nil {
+			t.Errorf("HTTPRoute for port %s not created: %v", p.Name, err)
+		}
+	}
+	for _, p := range testPorts {
+		policy := &unstructured.Unstructured{}
+		policy.SetGroupVersionKind(style.BackendTLSPolicyGVK)
+		if err := ga.Client.Get(ctx, types.NamespacedName{Namespace: "shop", Name: "binding-gwapi-helloworld-intermesh-" + p.Name}, policy); err != nil {
+			t.Fatalf("BackendTLSPolicy for port %s not created: %v", p.Name, err)
+		}
+		if policy.GetLabels()[style.OwnerUIDLabel] != "sb-uid" {
+			t.Errorf("BackendTLSPolicy not labeled for removal: %v", policy.GetLabels())
+		}
+	}
+	// The style generates no Istio networking objects
+	if drs, err := ga.Interface.NetworkingV1alpha3().DestinationRules("shop").List(ctx, metav1.ListOptions{}); err != nil || len(drs.Items) != 0 {
+		t.Errorf("unexpected DestinationRules %v, %v", drs, err)
+	}
+
+	// Reconciling again updates the same objects
+	if err := ga.EffectServiceBinding(ctx, sb, mfc); err != nil {
+		t.Fatalf("EffectServiceBinding failed again: %v", err)
+	}
+}
+
+func TestEffectServiceBindingRejectsHostnames(t *testing.T) {
+	ga := newTestGatewayAPI(t)
+	sb := &mmv1.ServiceBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "helloworld", Namespace: "shop"},
+		Spec: mmv1.ServiceBindingSpec{
+			Name:      "helloworld",
+			Namespace: "shop",
+			Ports:     testPorts,
+			Endpoints: []string{"ingress.example.com:15443"},
+		},
+	}
+	if err := ga.EffectServiceBinding(context.Background(), sb, testMeshFedConfig()); err == nil {
+		t.Errorf("hostname endpoint accepted")
+	}
+}
+
+func TestIngressAddresses(t *testing.T) {
+	mfc := testMeshFedConfig()
+	gw := ingressGateway(mfc)
+	if err := unstructured.SetNestedSlice(gw.Object, []interface{}{
+		map[string]interface{}{"type": "IPAddress", "value": "10.0.0.1"},
+		map[string]interface{}{"type": "IPAddress", "value": "fd00::1"},
+		map[string]interface{}{"type": "Hostname", "value": "ingress.example.com"},
+	}, "status", "addresses"); err != nil {
+		t.Fatal(err)
+	}
+	ga := newTestGatewayAPI(t, gw)
+
+	eps, err := ga.ingressAddresses(context.Background(), mfc, false)
+	if err != nil {
+		t.Fatalf("ingressAddresses failed: %v", err)
+	}
+	if expected := []string{"10.0.0.1:15443", "[fd00::1]:15443"}; !reflect.DeepEqual(eps, expected) {
+		t.Errorf("endpoints %v, expected %v", eps, expected)
+	}
+	addresses, err := ga.ingressAddresses(context.Background(), mfc, true)
+	if err != nil {
+		t.Fatalf("ingressAddresses failed: %v", err)
+	}
+	if expected := []string{"10.0.0.1:15443", "[fd00::1]:15443", "ingress.example.com:15443"}; !reflect.DeepEqual(addresses, expected) {
+		t.Errorf("addresses %v, expected %v", addresses, expected)
+	}
+}
+
+func TestValidateMeshFedConfig(t *testing.T) {
+	cases := []struct {
+		spec  mmv1.MeshFedConfigSpec
+		valid bool
+	}{
+		{spec: mmv1.MeshFedConfigSpec{Mode: Mode, UseIngressGateway: true}, valid: true},
+		{spec: mmv1.MeshFedConfigSpec{Mode: Mode, UseIngressGateway: true, IngressGatewayPort: 443}, valid: true},
+		{spec: mmv1.MeshFedConfigSpec{Mode: Mode, IngressGatewayPort: 443}},
+		{spec: mmv1.MeshFedConfigSpec{Mode: Mode, UseIngressGateway: true, IngressGatewaySelector: map[string]string{"istio": "ingressgateway"}}},
+		{spec: mmv1.MeshFedConfigSpec{Mode: Mode, UseEgressGateway: true}},
+	}
+	for _, c := range cases {
+		if err := validateMeshFedConfig("gwapi", "mesh-system", c.spec); (err == nil) != c.valid {
+			t.Errorf("%+v: valid is %v, error %v", c.spec, c.valid, err)
+		}
+	}
+
+	spec := mmv1.MeshFedConfigSpec{Mode: Mode, UseIngressGateway: true}
+	defaultMeshFedConfig(&spec)
+	if spec.IngressGatewayPort != defaultIngressPort {
+		t.Errorf("ingress port defaulted to %d", spec.IngressGatewayPort)
+	}
+}
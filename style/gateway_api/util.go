@@ -0,0 +1,110 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway_api
+
+import (
+	"context"
+	"net"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// newObject returns a Gateway API object.  The spec may only hold JSON values: maps of
+// string to interface{}, []interface{}, string, int64 and bool.
+func newObject(gvk schema.GroupVersionKind, namespace, name string, lbls map[string]string,
+	owners []metav1.OwnerReference, spec map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	u.SetGroupVersionKind(gvk)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetLabels(lbls)
+	u.SetOwnerReferences(owners)
+	return u
+}
+
+// createOrUpdate writes goal.  The stored spec is kept if it already has every field of goal's,
+// so that the fields defaulted by the API server do not cause an update on every reconcile.
+func createOrUpdate(ctx context.Context, cli client.Client, goal *unstructured.Unstructured) (controllerutil.OperationResult, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(goal.GroupVersionKind())
+	obj.SetNamespace(goal.GetNamespace())
+	obj.SetName(goal.GetName())
+	return controllerutil.CreateOrUpdate(ctx, cli, obj, func() error {
+		obj.SetLabels(goal.GetLabels())
+		obj.SetOwnerReferences(goal.GetOwnerReferences())
+		if !contains(obj.Object["spec"], goal.Object["spec"]) {
+			obj.Object["spec"] = goal.Object["spec"]
+		}
+		return nil
+	})
+}
+
+// contains is true if have has every field of want with the same value
+func contains(have, want interface{}) bool {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		h, ok := have.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range w {
+			if !contains(h[k], v) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		h, ok := have.([]interface{})
+		if !ok || len(h) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !contains(h[i], w[i]) {
+				return false
+			}
+		}
+		return true
+	case int64:
+		// Numbers read from the API server may be float64
+		switch h := have.(type) {
+		case int64:
+			return h == w
+		case float64:
+			return h == float64(w)
+		}
+		return false
+	}
+	return have == want
+}
+
+// gatewayAddresses returns the IP addresses in the status of a Gateway, and its hostnames if
+// hostnames is set.  Remote meshes bind to the ingress through Endpoints, which only hold IPs.
+func gatewayAddresses(gw *unstructured.Unstructured, hostnames bool) []string {
+	addresses, _, _ := unstructured.NestedSlice(gw.Object, "status", "addresses")
+	var retval []string
+	for _, a := range addresses {
+		if m, ok := a.(map[string]interface{}); ok {
+			if v, ok := m["value"].(string); ok && v != "" && (hostnames || net.ParseIP(v) != nil) {
+				retval = append(retval, v)
+			}
+		}
+	}
+	return retval
+}
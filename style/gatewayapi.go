@@ -0,0 +1,36 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package style
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GatewayAPIGroup is the API group of the Kubernetes Gateway API
+const GatewayAPIGroup = "gateway.networking.k8s.io"
+
+// The Gateway API kinds a style may generate.  They are handled as unstructured objects, as
+// the Gateway API has no Go types for the Kubernetes version emcee is built with.
+var (
+	GatewayGVK        = schema.GroupVersionKind{Group: GatewayAPIGroup, Version: "v1", Kind: "Gateway"}
+	HTTPRouteGVK      = schema.GroupVersionKind{Group: GatewayAPIGroup, Version: "v1", Kind: "HTTPRoute"}
+	TLSRouteGVK       = schema.GroupVersionKind{Group: GatewayAPIGroup, Version: "v1alpha2", Kind: "TLSRoute"}
+	ReferenceGrantGVK = schema.GroupVersionKind{Group: GatewayAPIGroup, Version: "v1beta1", Kind: "ReferenceGrant"}
+	// BackendTLSPolicyGVK originates TLS from the mesh to a Service
+	BackendTLSPolicyGVK = schema.GroupVersionKind{Group: GatewayAPIGroup, Version: "v1alpha3", Kind: "BackendTLSPolicy"}
+
+	gatewayAPIKinds = []schema.GroupVersionKind{GatewayGVK, HTTPRouteGVK, TLSRouteGVK, ReferenceGrantGVK, BackendTLSPolicyGVK}
+)
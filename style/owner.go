@@ -0,0 +1,215 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package style
+
+import (
+	"context"
+	"fmt"
+
+	multierror "github.com/hashicorp/go-multierror"
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+
+	istioclient "istio.io/client-go/pkg/clientset/versioned"
+	"istio.io/pkg/log"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// OwnerKindLabel is set on every generated object to the Kind of the emcee object it implements
+	OwnerKindLabel = ProjectID + ".io/owner-kind"
+	// OwnerUIDLabel is set on every generated object to the UID of the emcee object it implements
+	OwnerUIDLabel = ProjectID + ".io/owner-uid"
+
+	// KindMeshFedConfig is the Kind of mmv1.MeshFedConfig
+	KindMeshFedConfig = "MeshFedConfig"
+	// KindServiceExposition is the Kind of mmv1.ServiceExposition
+	KindServiceExposition = "ServiceExposition"
+	// KindServiceBinding is the Kind of mmv1.ServiceBinding
+	KindServiceBinding = "ServiceBinding"
+)
+
+// OwnerLabels returns lbls plus the labels that identify objects generated for owner
+func OwnerLabels(kind string, owner metav1.Object, lbls map[string]string) map[string]string {
+	retval := make(map[string]string, len(lbls)+2)
+	for k, v := range lbls {
+		retval[k] = v
+	}
+	retval[OwnerKindLabel] = kind
+	retval[OwnerUIDLabel] = string(owner.GetUID())
+	return retval
+}
+
+// OwnerReferences returns an owner reference to owner for a dependent in namespace.  Owner
+// references may not cross namespaces, so in that case there is none and removal relies
+// on the OwnerLabels.
+func OwnerReferences(kind string, owner metav1.Object, namespace string) []metav1.OwnerReference {
+	if owner.GetUID() == "" || owner.GetNamespace() != namespace {
+		return nil
+	}
+	return []metav1.OwnerReference{
+		{
+			APIVersion: mmv1.GroupVersion.String(),
+			Kind:       kind,
+			Name:       owner.GetName(),
+			UID:        owner.GetUID(),
+		},
+	}
+}
+
+// apiReader lists the generated objects to remove
+var apiReader client.Reader
+
+// SetAPIReader makes RemoveGenerated list objects with r, usually the uncached reader of the
+// manager.  Listing through the cached client would start informers on every Deployment,
+// Service, Endpoints and ServiceAccount of the cluster.  Without it the client is used.
+func SetAPIReader(r client.Reader) {
+	apiReader = r
+}
+
+// generatedKind lists the objects of a kind generated for an owner, and deletes one of them
+type generatedKind struct {
+	kind   string
+	list   func() (runtime.Object, error)
+	delete func(o runtime.Object, om metav1.Object) error
+}
+
+// RemoveGenerated deletes, in every namespace, the Gateways, VirtualServices, DestinationRules,
+// ServiceEntries, Services, Endpoints, ServiceAccounts and Deployments labeled as generated for
+// owner, and the Gateway API objects if the Gateway API is installed.  It is idempotent.  The returned error lists every object that could not be deleted.
+func RemoveGenerated(ctx context.Context, cli client.Client, istioCli istioclient.Interface, kind string, owner metav1.Object) error {
+	if owner.GetUID() == "" {
+		return nil
+	}
+	sel := labels.SelectorFromSet(map[string]string{OwnerUIDLabel: string(owner.GetUID())})
+	listOpts := metav1.ListOptions{LabelSelector: sel.String()}
+	matching := client.MatchingLabelsSelector{Selector: sel}
+	reader := apiReader
+	if reader == nil {
+		reader = cli
+	}
+
+	networking := istioCli.NetworkingV1alpha3()
+	kinds := []generatedKind{
+		{
+			kind: "Gateway",
+			list: func() (runtime.Object, error) { return networking.Gateways(metav1.NamespaceAll).List(ctx, listOpts) },
+			delete: func(_ runtime.Object, om metav1.Object) error {
+				return networking.Gateways(om.GetNamespace()).Delete(ctx, om.GetName(), metav1.DeleteOptions{})
+			},
+		},
+		{
+			kind: "VirtualService",
+			list: func() (runtime.Object, error) {
+				return networking.VirtualServices(metav1.NamespaceAll).List(ctx, listOpts)
+			},
+			delete: func(_ runtime.Object, om metav1.Object) error {
+				return networking.VirtualServices(om.GetNamespace()).Delete(ctx, om.GetName(), metav1.DeleteOptions{})
+			},
+		},
+		{
+			kind: "DestinationRule",
+			list: func() (runtime.Object, error) {
+				return networking.DestinationRules(metav1.NamespaceAll).List(ctx, listOpts)
+			},
+			delete: func(_ runtime.Object, om metav1.Object) error {
+				return networking.DestinationRules(om.GetNamespace()).Delete(ctx, om.GetName(), metav1.DeleteOptions{})
+			},
+		},
+		{
+			kind: "ServiceEntry",
+			list: func() (runtime.Object, error) {
+				return networking.ServiceEntries(metav1.NamespaceAll).List(ctx, listOpts)
+			},
+			delete: func(_ runtime.Object, om metav1.Object) error {
+				return networking.ServiceEntries(om.GetNamespace()).Delete(ctx, om.GetName(), metav1.DeleteOptions{})
+			},
+		},
+	}
+	generated := func(kind string, list runtime.Object, opts ...client.DeleteOption) generatedKind {
+		return generatedKind{
+			kind: kind,
+			list: func() (runtime.Object, error) { return list, reader.List(ctx, list, matching) },
+			delete: func(o runtime.Object, _ metav1.Object) error {
+				return cli.Delete(ctx, o, opts...)
+			},
+		}
+	}
+	kinds = append(kinds,
+		generated("Deployment", &appsv1.DeploymentList{}, client.PropagationPolicy(metav1.DeletePropagationBackground)),
+		generated("Service", &corev1.ServiceList{}),
+		generated("Endpoints", &corev1.EndpointsList{}))
+	for _, gvk := range gatewayAPIKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		kinds = append(kinds, generated(gvk.Kind, list))
+	}
+	kinds = append(kinds, generated("ServiceAccount", &corev1.ServiceAccountList{}))
+
+	var retval error
+	for _, k := range kinds {
+		if err := removeGeneratedKind(k, kind, owner); err != nil {
+			retval = multierror.Append(retval, err)
+		}
+	}
+	return retval
+}
+
+// removeGeneratedKind deletes the objects of k generated for owner, an object of kind ownerKind
+func removeGeneratedKind(k generatedKind, ownerKind string, owner metav1.Object) error {
+	var retval error
+	failed := func(what string, err error) {
+		if err != nil && !apierrs.IsNotFound(err) {
+			retval = multierror.Append(retval, fmt.Errorf("could not remove %s: %v", what, err))
+		}
+	}
+
+	list, err := k.list()
+	if meta.IsNoMatchError(err) {
+		// The kind is not installed, as the Gateway API may not be
+		return nil
+	}
+	if err != nil {
+		failed(k.kind+"s", err)
+		return retval
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		failed(k.kind+"s", err)
+		return retval
+	}
+	for _, o := range items {
+		om, err := meta.Accessor(o)
+		if err != nil {
+			failed(k.kind, err)
+			continue
+		}
+		err = k.delete(o, om)
+		failed(fmt.Sprintf("%s %s/%s", k.kind, om.GetNamespace(), om.GetName()), err)
+		if err == nil {
+			log.Infof("Removed %s %s/%s generated for %s %s/%s", k.kind, om.GetNamespace(), om.GetName(),
+				ownerKind, owner.GetNamespace(), owner.GetName())
+		}
+	}
+	return retval
+}
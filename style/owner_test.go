@@ -0,0 +1,101 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package style
+
+import (
+	"context"
+	"testing"
+
+	istiov1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+)
+
+func TestRemoveGenerated(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	// The Gateway API is installed, with none of its objects
+	for _, gvk := range gatewayAPIKinds {
+		scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+	}
+	owner := &mmv1.ServiceBinding{ObjectMeta: metav1.ObjectMeta{Name: "helloworld", Namespace: "bound", UID: "uid-1"}}
+	other := &mmv1.ServiceBinding{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "bound", UID: "uid-2"}}
+	meta := func(name, namespace string, owner metav1.Object) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: OwnerLabels(KindServiceBinding, owner, nil)}
+	}
+
+	cli := fake.NewFakeClientWithScheme(scheme,
+		&corev1.Service{ObjectMeta: meta("helloworld", "bound", owner)},
+		&corev1.Endpoints{ObjectMeta: meta("helloworld", "bound", owner)},
+		// Generated objects may be in the namespace of the MeshFedConfig
+		&appsv1.Deployment{ObjectMeta: meta("egress", "mesh-system", owner)},
+		&corev1.ServiceAccount{ObjectMeta: meta("egress", "mesh-system", owner)},
+		&corev1.Service{ObjectMeta: meta("other", "bound", other)},
+	)
+	istioCli := istiofake.NewSimpleClientset(
+		&istiov1alpha3.ServiceEntry{ObjectMeta: meta("helloworld", "mesh-system", owner)},
+		&istiov1alpha3.DestinationRule{ObjectMeta: meta("other", "mesh-system", other)},
+	)
+
+	ctx := context.Background()
+	if err := RemoveGenerated(ctx, cli, istioCli, KindServiceBinding, owner); err != nil {
+		t.Fatalf("RemoveGenerated failed: %v", err)
+	}
+
+	removed := []struct {
+		obj runtime.Object
+		key types.NamespacedName
+	}{
+		{&corev1.Service{}, types.NamespacedName{Namespace: "bound", Name: "helloworld"}},
+		{&corev1.Endpoints{}, types.NamespacedName{Namespace: "bound", Name: "helloworld"}},
+		{&appsv1.Deployment{}, types.NamespacedName{Namespace: "mesh-system", Name: "egress"}},
+		{&corev1.ServiceAccount{}, types.NamespacedName{Namespace: "mesh-system", Name: "egress"}},
+	}
+	for _, r := range removed {
+		if err := cli.Get(ctx, r.key, r.obj); !apierrs.IsNotFound(err) {
+			t.Errorf("%T %v not removed: %v", r.obj, r.key, err)
+		}
+	}
+	if _, err := istioCli.NetworkingV1alpha3().ServiceEntries("mesh-system").Get(ctx, "helloworld", metav1.GetOptions{}); !apierrs.IsNotFound(err) {
+		t.Errorf("ServiceEntry not removed: %v", err)
+	}
+
+	// The objects of other owners are kept
+	if err := cli.Get(ctx, types.NamespacedName{Namespace: "bound", Name: "other"}, &corev1.Service{}); err != nil {
+		t.Errorf("Service of another owner removed: %v", err)
+	}
+	if _, err := istioCli.NetworkingV1alpha3().DestinationRules("mesh-system").Get(ctx, "other", metav1.GetOptions{}); err != nil {
+		t.Errorf("DestinationRule of another owner removed: %v", err)
+	}
+
+	// Removal is idempotent
+	if err := RemoveGenerated(ctx, cli, istioCli, KindServiceBinding, owner); err != nil {
+		t.Errorf("RemoveGenerated failed again: %v", err)
+	}
+}
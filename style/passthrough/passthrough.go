@@ -18,12 +18,12 @@ package passthrough
 import (
 	"context"
 	"fmt"
+	"net"
 	"strconv"
-	"strings"
 
-	"github.com/gogo/protobuf/types"
 	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
 	"github.com/istio-ecosystem/emcee/style"
+	mfutil "github.com/istio-ecosystem/emcee/util"
 	"istio.io/pkg/log"
 
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
@@ -32,14 +32,16 @@ import (
 	istiov1alpha3 "istio.io/api/networking/v1alpha3"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-// Passthrough has clients for k8s and Istio
+// Passthrough has clients for k8s and Istio, and records events on the objects it reconciles
 type Passthrough struct {
 	client.Client
 	istioclient.Interface
+	recorder record.EventRecorder
 }
 
 var (
@@ -49,32 +51,71 @@ var (
 	_ style.ServiceExposer = &Passthrough{}
 )
 
+// Mode selects the passthrough style
+const Mode = "PASSTHROUGH"
+
+func init() {
+	style.Register(style.Style{
+		Mode:              Mode,
+		NewMeshFedConfig:  NewPassthroughMeshFedConfig,
+		NewServiceExposer: NewPassthroughServiceExposer,
+		NewServiceBinder:  NewPassthroughServiceBinder,
+		Validate:          style.ValidateGateways,
+		Default:           defaultMeshFedConfig,
+		IngressAddresses:  ingressAddresses,
+		IngressScheme:     "https",
+	})
+}
+
+// ingressEndpoints returns the address:port of Istio's ingress gateway, which passes the TLS
+// of exposed services through
+func ingressEndpoints(ctx context.Context, cli client.Client, mfc *mmv1.MeshFedConfig) ([]string, error) {
+	return GetIngressEndpointsNoPort(ctx, cli, "istio-ingressgateway", "istio-system", defaultIngressPort)
+}
+
+// ingressAddresses returns the host:port clients reach Istio's ingress gateway at
+func ingressAddresses(ctx context.Context, cli client.Client, mfc *mmv1.MeshFedConfig) ([]string, error) {
+	return mfutil.GetIngressAddresses(ctx, cli, "istio-ingressgateway", "istio-system", defaultIngressPort)
+}
+
+// defaultMeshFedConfig selects Istio's ingress gateway if the config uses an ingress
+func defaultMeshFedConfig(mfc *mmv1.MeshFedConfigSpec) {
+	if mfc.UseIngressGateway && len(mfc.IngressGatewaySelector) == 0 {
+		mfc.IngressGatewaySelector = map[string]string{
+			"istio": "ingressgateway",
+		}
+	}
+}
+
 const (
 	//	defaultPrefix      = ".svc.cluster.local"
 	defaultIngressPort = 443 // the port used at the Ingress // TODO use CONSTANT
 )
 
 // NewPassthroughMeshFedConfig creates a "Passthrough" style implementation for handling MeshFedConfig
-func NewPassthroughMeshFedConfig(cli client.Client, istioCli istioclient.Interface) style.MeshFedConfig {
+func NewPassthroughMeshFedConfig(cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) style.MeshFedConfig {
 	return &Passthrough{
 		cli,
 		istioCli,
+		recorder,
 	}
 }
 
 // NewPassthroughServiceExposer creates a "Passthrough" style implementation for handling ServiceExposure
-func NewPassthroughServiceExposer(cli client.Client, istioCli istioclient.Interface) style.ServiceExposer {
+func NewPassthroughServiceExposer(cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) style.ServiceExposer {
 	return &Passthrough{
 		cli,
 		istioCli,
+		recorder,
 	}
 }
 
 // NewPassthroughServiceBinder creates a "Passthrough" style implementation for handling ServiceBinding
-func NewPassthroughServiceBinder(cli client.Client, istioCli istioclient.Interface) style.ServiceBinder {
+func NewPassthroughServiceBinder(cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) style.ServiceBinder {
 	return &Passthrough{
 		cli,
 		istioCli,
+		recorder,
 	}
 }
 
@@ -87,9 +128,9 @@ func (pt *Passthrough) EffectMeshFedConfig(ctx context.Context, mfc *mmv1.MeshFe
 	return nil
 }
 
-// RemoveMeshFedConfig does not do anything for the passthrough mode
+// RemoveMeshFedConfig deletes anything generated for the MeshFedConfig
 func (pt *Passthrough) RemoveMeshFedConfig(ctx context.Context, mfc *mmv1.MeshFedConfig) error {
-	return nil
+	return style.RemoveGenerated(ctx, pt.Client, pt.Interface, style.KindMeshFedConfig, mfc)
 }
 
 // *****************************
@@ -99,42 +140,61 @@ func (pt *Passthrough) RemoveMeshFedConfig(ctx context.Context, mfc *mmv1.MeshFe
 // EffectServiceExposure ...
 func (pt *Passthrough) EffectServiceExposure(ctx context.Context, se *mmv1.ServiceExposition, mfc *mmv1.MeshFedConfig) error {
 
-	eps, err := GetIngressEndpointsNoPort(ctx, pt.Client, "istio-ingressgateway", "istio-system", defaultIngressPort)
+	eps, err := ingressEndpoints(ctx, pt.Client, mfc)
 	if err != nil {
 		log.Warnf("could not get endpoints %v %v", eps, err)
-		return err
+		style.EndpointsUnresolved(&se.Status.Conditions, err)
+		return style.Failed(&se.Status.Conditions, mmv1.ReasonEndpointsUnavailable, err)
 	}
 	se.Spec.Endpoints = eps
+	style.EndpointsResolved(&se.Status.Conditions, eps)
+	style.ExposedTopology(ctx, pt.Client, pt.recorder, se, mfc, "istio-ingressgateway", "istio-system")
 
 	dr := passthroughExposingDestinationRule(mfc, se)
+	if dr == nil {
+		return style.Failed(&se.Status.Conditions, mmv1.ReasonDestinationRuleFailed,
+			fmt.Errorf("passthrough requires Ingress Gateway"))
+	}
 	_, err = createDestinationRule(pt.Interface, se.GetNamespace(), dr)
 	if err != nil {
 		log.Warnf("Could not create the Destination Rule %v: %v", dr.GetName(), err)
+		return style.Failed(&se.Status.Conditions, mmv1.ReasonDestinationRuleFailed, err)
 	}
+	mmv1.AddGeneratedObject(&se.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "DestinationRule", dr))
 
-	vs, _ := passthroughExposingVirtualService(mfc, se)
-	_, err = createVirtualService(pt.Interface, se.GetNamespace(), vs)
+	vs, err := passthroughExposingVirtualService(mfc, se)
+	if err == nil {
+		_, err = createVirtualService(pt.Interface, se.GetNamespace(), vs)
+	}
 	if err != nil {
-		log.Warnf("Could not create the Virtual Service %v: %v", vs.GetName(), err)
+		log.Warnf("Could not create the Virtual Service for %v: %v", se.GetName(), err)
+		return style.Failed(&se.Status.Conditions, mmv1.ReasonVirtualServiceFailed, err)
 	}
+	mmv1.AddGeneratedObject(&se.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "VirtualService", vs))
 
-	gw, _ := passthroughExposingGateway(mfc, se)
-	_, err = createGateway(pt.Interface, se.GetNamespace(), gw)
+	gw, err := passthroughExposingGateway(mfc, se)
+	if err == nil {
+		_, err = createGateway(pt.Interface, se.GetNamespace(), gw)
+	}
 	if err != nil {
-		log.Warnf("Could not create the Gateway %v: %v", gw.GetName(), err)
+		log.Warnf("Could not create the Gateway for %v: %v", se.GetName(), err)
+		return style.Failed(&se.Status.Conditions, mmv1.ReasonGatewayFailed, err)
 	}
+	mmv1.AddGeneratedObject(&se.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "Gateway", gw))
 
-	se.Status.Ready = true
+	// Update() returns the stored status; keep the one we are building for the controller
+	status := se.Status.DeepCopy()
 	if err := pt.Client.Update(ctx, se); err != nil {
 		return err
 	}
+	se.Status = *status
 
 	return nil
 }
 
-// RemoveServiceExposure ...
+// RemoveServiceExposure deletes the Gateway, VirtualService and DestinationRule generated for the exposure
 func (pt *Passthrough) RemoveServiceExposure(ctx context.Context, se *mmv1.ServiceExposition, mfc *mmv1.MeshFedConfig) error {
-	return nil
+	return style.RemoveGenerated(ctx, pt.Client, pt.Interface, style.KindServiceExposition, se)
 }
 
 // ****************************
@@ -144,16 +204,24 @@ func (pt *Passthrough) RemoveServiceExposure(ctx context.Context, se *mmv1.Servi
 // EffectServiceBinding ...
 func (pt *Passthrough) EffectServiceBinding(ctx context.Context, sb *mmv1.ServiceBinding, mfc *mmv1.MeshFedConfig) error {
 
+	style.EndpointsResolved(&sb.Status.Conditions, sb.Spec.Endpoints)
 	serviceEntry := passthroughBindingServiceEntry(mfc, sb)
+	if serviceEntry == nil {
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonServiceEntryFailed,
+			fmt.Errorf("passthrough could not generate the Service Entry for %v (requires Ingress Gateway and ip:port endpoints)", sb.GetName()))
+	}
 	_, err := createServiceEntry(pt.Interface, sb.GetNamespace(), serviceEntry)
 	if err != nil {
 		log.Warnf("Could not create the Service Entry %v: %v", serviceEntry.GetName(), err)
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonServiceEntryFailed, err)
 	}
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "ServiceEntry", serviceEntry))
 
 	goalSvc := passthroughBindingService(sb, mfc)
 	if goalSvc == nil {
 		log.Infof("Could not generate Remote Cluster ingress Service")
-		return fmt.Errorf("passthrough controller could not generate Remote Cluster ingress Service")
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonServiceFailed,
+			fmt.Errorf("passthrough controller could not generate Remote Cluster ingress Service"))
 	}
 	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -171,14 +239,18 @@ func (pt *Passthrough) EffectServiceBinding(ctx context.Context, sb *mmv1.Servic
 		return nil
 	})
 	if err != nil {
-		return err
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonServiceFailed, err)
 	}
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated("v1", "Service", svc))
+	style.Recorded(pt.recorder, sb, or, "Service", svc)
 
 	dr := passthroughBindingDestinationRule(mfc, sb)
 	_, err = createDestinationRule(pt.Interface, sb.GetNamespace(), dr)
 	if err != nil {
 		log.Warnf("Could not create the Destination Rule %v: %v", dr.GetName(), err)
+		return style.Failed(&sb.Status.Conditions, mmv1.ReasonDestinationRuleFailed, err)
 	}
+	mmv1.AddGeneratedObject(&sb.Status.GeneratedObjects, style.Generated(v1alpha3.SchemeGroupVersion.String(), "DestinationRule", dr))
 
 	log.Infof("%s %s %s", or,
 		"Remote Cluster ingress Service",
@@ -187,9 +259,9 @@ func (pt *Passthrough) EffectServiceBinding(ctx context.Context, sb *mmv1.Servic
 	return nil
 }
 
-// RemoveServiceBinding ...
+// RemoveServiceBinding deletes the ServiceEntry, Service and DestinationRule generated for the binding
 func (pt *Passthrough) RemoveServiceBinding(ctx context.Context, sb *mmv1.ServiceBinding, mfc *mmv1.MeshFedConfig) error {
-	return nil
+	return style.RemoveGenerated(ctx, pt.Client, pt.Interface, style.KindServiceBinding, sb)
 }
 
 // *****************************
@@ -211,6 +283,14 @@ func passthroughExposingGateway(mfc *mmv1.MeshFedConfig, se *mmv1.ServiceExposit
 	if portToListen == 0 {
 		return nil, fmt.Errorf("passthrough requires a port number for Ingress Gateway")
 	}
+	hosts := []string{fmt.Sprintf("%s.%s.svc.cluster.local", se.Spec.Name, se.GetNamespace())} // TODO intermeshNamespace
+	ports := se.Spec.ServicePorts()
+	if len(ports) > 1 {
+		exposedHost := fmt.Sprintf("%s.%s.svc.cluster.local", exposedLocalName(se), se.GetNamespace())
+		for _, p := range ports {
+			hosts = append(hosts, sniHost(exposedHost, p, ports))
+		}
+	}
 	return &v1alpha3.Gateway{
 		TypeMeta: metav1.TypeMeta{
 			Kind: "Gateway",
@@ -218,15 +298,15 @@ func passthroughExposingGateway(mfc *mmv1.MeshFedConfig, se *mmv1.ServiceExposit
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceExposeName(mfc.GetName(), se.GetName()),
 			Namespace: se.GetNamespace(), // TODO
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceExposition, se, map[string]string{
 				"mesh": mfc.GetName(),
-			},
-			OwnerReferences: ownerReference(se.APIVersion, se.Kind, se.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceExposition, se, se.GetNamespace()),
 		},
 		Spec: istiov1alpha3.Gateway{
 			Servers: []*istiov1alpha3.Server{
 				&istiov1alpha3.Server{
-					Hosts: []string{fmt.Sprintf("%s.%s.svc.cluster.local", se.Spec.Name, se.GetNamespace())}, // TODO intermeshNamespace
+					Hosts: hosts,
 					// Hosts: []string{"*.svc.cluster.local"},
 					Port: &istiov1alpha3.Port{
 						Number:   portToListen,
@@ -238,7 +318,7 @@ func passthroughExposingGateway(mfc *mmv1.MeshFedConfig, se *mmv1.ServiceExposit
 					},
 				},
 			},
-			Selector: mfc.Spec.IngressGatewaySelector, // TODO: default to: map[string]string {"istio": "ingressgateway}",
+			Selector: mfc.Spec.IngressGatewaySelector, // defaulted by the webhook to {"istio": "ingressgateway"}
 		},
 	}, nil
 }
@@ -251,6 +331,35 @@ func passthroughExposingVirtualService(mfc *mmv1.MeshFedConfig, se *mmv1.Service
 	if portToListen == 0 {
 		return nil, fmt.Errorf("passthrough requires a port number for Ingress Gateway")
 	}
+	ports := se.Spec.ServicePorts()
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("passthrough requires the ports of %s", se.Spec.Name)
+	}
+
+	// Each port is routed on its own SNI; the ingress passes the TLS through
+	exposedHost := fmt.Sprintf("%s.%s.svc.cluster.local", exposedLocalName(se), se.GetNamespace())
+	var routes []*istiov1alpha3.TLSRoute
+	for _, p := range ports {
+		routes = append(routes, &istiov1alpha3.TLSRoute{
+			Match: []*istiov1alpha3.TLSMatchAttributes{
+				&istiov1alpha3.TLSMatchAttributes{
+					Port:     portToListen,
+					SniHosts: []string{sniHost(exposedHost, p, ports)},
+				},
+			},
+			Route: []*istiov1alpha3.RouteDestination{
+				{
+					Destination: &istiov1alpha3.Destination{
+						Host: fmt.Sprintf("%s.%s.svc.cluster.local", se.Spec.Name, se.GetNamespace()),
+						Port: &istiov1alpha3.PortSelector{
+							Number: p.Number,
+						},
+						Subset: "notls",
+					},
+				},
+			},
+		})
+	}
 
 	return &v1alpha3.VirtualService{
 		TypeMeta: metav1.TypeMeta{
@@ -259,36 +368,16 @@ func passthroughExposingVirtualService(mfc *mmv1.MeshFedConfig, se *mmv1.Service
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("intermesh-%s-%s", se.Spec.Name, se.GetNamespace()),
 			Namespace: se.GetNamespace(),
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceExposition, se, map[string]string{
 				"mesh": mfc.GetName(),
 				"role": "external",
-			},
-			OwnerReferences: ownerReference(se.APIVersion, se.Kind, se.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceExposition, se, se.GetNamespace()),
 		},
 		Spec: istiov1alpha3.VirtualService{
 			Hosts:    []string{"*"}, // fmt.Sprintf("%s.%s.svc.cluster.local", exposedLocalName(se), se.GetNamespace())}, // TODO Why need the "*"?
 			Gateways: []string{serviceExposeName(mfc.GetName(), se.GetName())},
-			Tls: []*istiov1alpha3.TLSRoute{
-				{
-					Match: []*istiov1alpha3.TLSMatchAttributes{
-						&istiov1alpha3.TLSMatchAttributes{
-							Port:     portToListen,
-							SniHosts: []string{fmt.Sprintf("%s.%s.svc.cluster.local", exposedLocalName(se), se.GetNamespace())},
-						},
-					},
-					Route: []*istiov1alpha3.RouteDestination{
-						{
-							Destination: &istiov1alpha3.Destination{
-								Host: fmt.Sprintf("%s.%s.svc.cluster.local", se.Spec.Name, se.GetNamespace()),
-								Port: &istiov1alpha3.PortSelector{
-									Number: se.Spec.Port,
-								},
-								Subset: "notls",
-							},
-						},
-					},
-				},
-			},
+			Tls:      routes,
 		},
 	}, nil
 }
@@ -309,10 +398,10 @@ func passthroughExposingDestinationRule(mfc *mmv1.MeshFedConfig, se *mmv1.Servic
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceExposeName(mfc.GetName(), se.GetName()),
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceExposition, se, map[string]string{
 				"mesh": mfc.GetName(),
-			},
-			OwnerReferences: ownerReference(se.APIVersion, se.Kind, se.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceExposition, se, namespace),
 		},
 		Spec: istiov1alpha3.DestinationRule{
 			Host: svcName,
@@ -337,14 +426,14 @@ func passthroughExposingDestinationRule(mfc *mmv1.MeshFedConfig, se *mmv1.Servic
 	}
 }
 
+// getPortfromIPPort returns the port of an ip:port or [ipv6]:port address, or 0 if it has none
 func getPortfromIPPort(ep string) uint32 {
-	parts := strings.Split(ep, ":")
-	numparts := len(parts)
-	if numparts != 2 {
+	_, p, err := net.SplitHostPort(ep)
+	if err != nil {
 		log.Warnf("Address %q not in form ip:port", ep)
 		return 0
 	}
-	port, err := strconv.Atoi(parts[1])
+	port, err := strconv.ParseUint(p, 10, 16)
 	if err != nil {
 		return 0
 	}
@@ -356,25 +445,30 @@ func passthroughBindingServiceEntry(mfc *mmv1.MeshFedConfig, sb *mmv1.ServiceBin
 		return nil
 	}
 	name := boundLocalName(sb)
-	namespace := sb.Spec.Namespace
-	port := boundLocalPort(sb)
+	namespace := sb.GetNamespace()
+	ports := boundLocalPorts(sb)
 
 	if len(sb.Spec.Endpoints) == 0 {
 		log.Warnf("no endpoints found for service binding: %v", sb.GetName())
 		return nil
 	}
-
-	parts := strings.Split(sb.Spec.Endpoints[0], ":")
-	numparts := len(parts)
-	if numparts != 2 {
-		log.Warnf("Address %q not in form ip:port", sb.Spec.Endpoints[0])
-		return nil
-	}
-	epPort, err := strconv.Atoi(parts[1])
+	eps, err := style.BindingEndpoints(sb)
 	if err != nil {
+		log.Warnf("%v", err)
 		return nil
 	}
-	epAddress := parts[0]
+
+	// Every port reaches each remote ingress on the endpoint's port
+	var sePorts []*istiov1alpha3.Port
+	var portNames []string
+	for _, p := range ports {
+		sePorts = append(sePorts, &istiov1alpha3.Port{
+			Name:     p.Name,
+			Number:   p.Number,
+			Protocol: string(p.Protocol),
+		})
+		portNames = append(portNames, p.Name)
+	}
 
 	return &v1alpha3.ServiceEntry{
 		TypeMeta: metav1.TypeMeta{
@@ -383,35 +477,20 @@ func passthroughBindingServiceEntry(mfc *mmv1.MeshFedConfig, sb *mmv1.ServiceBin
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceRemoteName(mfc.GetName(), name),
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
 				"mesh": mfc.GetName(),
-			},
-			OwnerReferences: ownerReference(sb.APIVersion, sb.Kind, sb.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, namespace),
 		},
 		Spec: istiov1alpha3.ServiceEntry{
 			Hosts: []string{
 				// Note that this must be the local name, even if a DestinationRule has altered the SNI (tested in Istio 1.4.0)
 				fmt.Sprintf("%s.%s.svc.cluster.local", name, namespace), // TODO intermeshNamespace
 			},
-			Ports: []*istiov1alpha3.Port{
-				&istiov1alpha3.Port{
-					Name:     "http",
-					Number:   port,
-					Protocol: "HTTP",
-				},
-			},
-			Resolution: istiov1alpha3.ServiceEntry_STATIC,
+			Ports:      sePorts,
+			Resolution: style.Resolution(eps),
 			Location:   istiov1alpha3.ServiceEntry_MESH_INTERNAL,
-			Endpoints: []*istiov1alpha3.WorkloadEntry{
-				&istiov1alpha3.WorkloadEntry{
-					Address: epAddress,
-					Ports: map[string]uint32{
-						"http": uint32(epPort),
-					},
-					Locality: "us-north/007", // TODO use locality provided in discovery
-					Network:  "NorthStar",
-				},
-			},
+			Endpoints:  style.WorkloadEntries(eps, portNames...),
 		},
 	}
 }
@@ -422,10 +501,35 @@ func passthroughBindingDestinationRule(mfc *mmv1.MeshFedConfig, sb *mmv1.Service
 	}
 
 	name := sb.Spec.Name
-	namespace := sb.Spec.Namespace
-	svcName := fmt.Sprintf("%s.%s.svc.cluster.local", name, namespace)
+	namespace := sb.GetNamespace()
+	svcName := fmt.Sprintf("%s.%s.svc.cluster.local", name, boundNamespace(sb))
 	svcLocalName := fmt.Sprintf("%s.%s.svc.cluster.local", boundLocalName(sb), namespace) // TODO intermeshNamespace
 
+	// The SNI selects the port at the remote ingress, see passthroughExposingVirtualService
+	ports := boundLocalPorts(sb)
+	var portSettings []*istiov1alpha3.TrafficPolicy_PortTrafficPolicy
+	for _, p := range ports {
+		portSettings = append(portSettings, &istiov1alpha3.TrafficPolicy_PortTrafficPolicy{
+			Port: &istiov1alpha3.PortSelector{
+				Number: p.Number,
+			},
+			ConnectionPool: &istiov1alpha3.ConnectionPoolSettings{
+				Http: &istiov1alpha3.ConnectionPoolSettings_HTTPSettings{
+					Http2MaxRequests:         1000,
+					MaxRequestsPerConnection: 10,
+				},
+				Tcp: &istiov1alpha3.ConnectionPoolSettings_TCPSettings{
+					MaxConnections: 100,
+				},
+			},
+			OutlierDetection: style.FailoverOutlierDetection(),
+			Tls: &istiov1alpha3.ClientTLSSettings{
+				Mode: istiov1alpha3.ClientTLSSettings_ISTIO_MUTUAL,
+				Sni:  sniHost(svcName, p, ports),
+			},
+		})
+	}
+
 	return &v1alpha3.DestinationRule{
 		TypeMeta: metav1.TypeMeta{
 			Kind: "DestinationRule",
@@ -433,44 +537,18 @@ func passthroughBindingDestinationRule(mfc *mmv1.MeshFedConfig, sb *mmv1.Service
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceRemoteName(mfc.GetName(), sb.GetName()),
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
 				"mesh": mfc.GetName(),
-			},
-			OwnerReferences: ownerReference(sb.APIVersion, sb.Kind, sb.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, namespace),
 		},
 		Spec: istiov1alpha3.DestinationRule{
 			Host: svcLocalName,
+			// Spread across the meshes exporting the service, failing over between them
 			TrafficPolicy: &istiov1alpha3.TrafficPolicy{
-				PortLevelSettings: []*istiov1alpha3.TrafficPolicy_PortTrafficPolicy{
-					&istiov1alpha3.TrafficPolicy_PortTrafficPolicy{
-						Port: &istiov1alpha3.PortSelector{
-							Number: boundLocalPort(sb),
-						},
-						ConnectionPool: &istiov1alpha3.ConnectionPoolSettings{
-							Http: &istiov1alpha3.ConnectionPoolSettings_HTTPSettings{
-								Http2MaxRequests:         1000,
-								MaxRequestsPerConnection: 10,
-							},
-							Tcp: &istiov1alpha3.ConnectionPoolSettings_TCPSettings{
-								MaxConnections: 100,
-							},
-						},
-						OutlierDetection: &istiov1alpha3.OutlierDetection{
-							BaseEjectionTime: &types.Duration{
-								Seconds: 20,
-							},
-							ConsecutiveErrors: 2,
-							Interval: &types.Duration{
-								Seconds: 5,
-							},
-							MaxEjectionPercent: 75,
-						},
-						Tls: &istiov1alpha3.ClientTLSSettings{
-							Mode: istiov1alpha3.ClientTLSSettings_ISTIO_MUTUAL,
-							Sni:  svcName,
-						},
-					},
-				},
+				LoadBalancer:      style.FailoverLoadBalancer(),
+				OutlierDetection:  style.FailoverOutlierDetection(),
+				PortLevelSettings: portSettings,
 			},
 		},
 	}
@@ -481,7 +559,14 @@ func passthroughBindingService(sb *mmv1.ServiceBinding, mfc *mmv1.MeshFedConfig)
 		return nil
 	}
 	name := boundLocalName(sb) // TODO need this? serviceIntermeshName(sb.Spec.Name)
-	port := boundLocalPort(sb)
+	var svcPorts []corev1.ServicePort
+	for _, p := range boundLocalPorts(sb) {
+		svcPorts = append(svcPorts, corev1.ServicePort{
+			Name: p.Name,
+			Port: int32(p.Number),
+			// TargetPort: intstr.FromInt(0),
+		})
+	}
 	return &corev1.Service{
 		TypeMeta: metav1.TypeMeta{
 			Kind: "Service",
@@ -489,20 +574,14 @@ func passthroughBindingService(sb *mmv1.ServiceBinding, mfc *mmv1.MeshFedConfig)
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: sb.GetNamespace(),
-			Labels: map[string]string{
+			Labels: style.OwnerLabels(style.KindServiceBinding, sb, map[string]string{
 				"mesh": sb.Spec.Name,
 				"role": "ingress-svc",
-			},
-			OwnerReferences: ownerReference(sb.APIVersion, sb.Kind, sb.ObjectMeta),
+			}),
+			OwnerReferences: style.OwnerReferences(style.KindServiceBinding, sb, sb.GetNamespace()),
 		},
 		Spec: corev1.ServiceSpec{
-			Ports: []corev1.ServicePort{
-				{
-					Name: "http",
-					Port: int32(port),
-					// TargetPort: intstr.FromInt(0),
-				},
-			},
+			Ports: svcPorts,
 		},
 	}
 }
@@ -519,17 +598,34 @@ func serviceExposeName(mfcName, svcName string) string {
 //	return fmt.Sprintf("%s-intermesh", name)
 //}
 
-func boundLocalPort(sb *mmv1.ServiceBinding) uint32 {
-	if sb.Spec.Port != 0 {
-		return sb.Spec.Port
+func boundLocalPorts(sb *mmv1.ServiceBinding) []mmv1.ServicePort {
+	if ports := sb.Spec.ServicePorts(); len(ports) > 0 {
+		return ports
+	}
+	return mmv1.EffectivePorts(80, nil)
+}
+
+// sniHost is the SNI for port p of host.  A service with a single port keeps the
+// plain host name, as before ports were introduced.
+func sniHost(host string, p mmv1.ServicePort, ports []mmv1.ServicePort) string {
+	if len(ports) <= 1 {
+		return host
 	}
-	return 80
+	return fmt.Sprintf("outbound_.%d_._.%s", p.Number, host)
 }
 
 func renderName(om *metav1.ObjectMeta) string {
 	return fmt.Sprintf("%s.%s", om.GetName(), om.GetNamespace())
 }
 
+// boundNamespace is the namespace of the bound service in the exposing mesh
+func boundNamespace(sb *mmv1.ServiceBinding) string {
+	if sb.Spec.Namespace != "" {
+		return sb.Spec.Namespace
+	}
+	return sb.GetNamespace()
+}
+
 func boundLocalName(sb *mmv1.ServiceBinding) string {
 	if sb.Spec.Alias != "" {
 		return sb.Spec.Alias
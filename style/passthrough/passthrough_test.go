@@ -0,0 +1,76 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package passthrough
+
+import (
+	"testing"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetPortfromIPPort(t *testing.T) {
+	cases := []struct {
+		ep   string
+		port uint32
+	}{
+		{ep: "192.0.2.1:15443", port: 15443},
+		{ep: "[2001:db8::1]:15443", port: 15443},
+		{ep: "ingress.example.com:443", port: 443},
+		{ep: "192.0.2.1"},
+		{ep: "2001:db8::1:15443"},
+		{ep: "192.0.2.1:http"},
+		{ep: "192.0.2.1:70000"},
+	}
+	for _, c := range cases {
+		if actual := getPortfromIPPort(c.ep); actual != c.port {
+			t.Errorf("port of %q: %d, expected %d", c.ep, actual, c.port)
+		}
+	}
+}
+
+func TestExposingIPv6Ingress(t *testing.T) {
+	mfc := &mmv1.MeshFedConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "passthrough", Namespace: "mesh-system"},
+		Spec:       mmv1.MeshFedConfigSpec{Mode: Mode, UseIngressGateway: true},
+	}
+	se := &mmv1.ServiceExposition{
+		ObjectMeta: metav1.ObjectMeta{Name: "helloworld", Namespace: "shop"},
+		Spec: mmv1.ServiceExpositionSpec{
+			Name:      "helloworld",
+			Port:      5000,
+			Endpoints: []string{"[2001:db8::20]:15443"},
+		},
+	}
+
+	gw, err := passthroughExposingGateway(mfc, se)
+	if err != nil {
+		t.Fatalf("Gateway not created: %v", err)
+	}
+	if port := gw.Spec.Servers[0].Port.Number; port != 15443 {
+		t.Errorf("Gateway listens on %d, expected 15443", port)
+	}
+
+	vs, err := passthroughExposingVirtualService(mfc, se)
+	if err != nil {
+		t.Fatalf("VirtualService not created: %v", err)
+	}
+	for _, route := range vs.Spec.Tls {
+		if port := route.Match[0].Port; port != 15443 {
+			t.Errorf("VirtualService matches port %d, expected 15443", port)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
 
+	"github.com/istio-ecosystem/emcee/pkg/metrics"
 	mfutil "github.com/istio-ecosystem/emcee/util"
 	"istio.io/pkg/log"
 	corev1 "k8s.io/api/core/v1"
@@ -49,13 +50,18 @@ func createGateway(r istioclient.Interface, namespace string, gateway *v1alpha3.
 	if mfutil.ErrorAlreadyExists(err) {
 		updatedGateway, err := r.NetworkingV1alpha3().Gateways(namespace).Get(context.TODO(), gateway.GetName(), metav1.GetOptions{})
 		if err != nil {
+			metrics.IstioWrite("Gateway", metrics.OperationUpdate, err)
 			log.Warnf("Failed updating Istio gateway %v: %v", gateway.GetName(), err)
 			return updatedGateway, err
 		}
 		updatedGateway.Spec = gateway.Spec
+		updatedGateway.Labels = gateway.Labels
+		updatedGateway.OwnerReferences = gateway.OwnerReferences
 		updatedGateway, err = r.NetworkingV1alpha3().Gateways(namespace).Update(context.TODO(), updatedGateway, metav1.UpdateOptions{})
+		metrics.IstioWrite("Gateway", metrics.OperationUpdate, err)
 		return updatedGateway, err
 	}
+	metrics.IstioWrite("Gateway", metrics.OperationCreate, err)
 	return createdGateway, err
 }
 
@@ -65,13 +71,18 @@ func createVirtualService(r istioclient.Interface, namespace string, vs *v1alpha
 	if mfutil.ErrorAlreadyExists(err) {
 		updatedVirtualService, err := r.NetworkingV1alpha3().VirtualServices(namespace).Get(context.TODO(), vs.GetName(), metav1.GetOptions{})
 		if err != nil {
+			metrics.IstioWrite("VirtualService", metrics.OperationUpdate, err)
 			log.Warnf("Failed updating Istio virtual service %v: %v", vs.GetName(), err)
 			return updatedVirtualService, err
 		}
 		updatedVirtualService.Spec = vs.Spec
+		updatedVirtualService.Labels = vs.Labels
+		updatedVirtualService.OwnerReferences = vs.OwnerReferences
 		updatedVirtualService, err = r.NetworkingV1alpha3().VirtualServices(namespace).Update(context.TODO(), updatedVirtualService, metav1.UpdateOptions{})
+		metrics.IstioWrite("VirtualService", metrics.OperationUpdate, err)
 		return updatedVirtualService, err
 	}
+	metrics.IstioWrite("VirtualService", metrics.OperationCreate, err)
 	return createdVirtualService, err
 }
 
@@ -81,13 +92,18 @@ func createDestinationRule(r istioclient.Interface, namespace string, dr *v1alph
 	if mfutil.ErrorAlreadyExists(err) {
 		updatedDestinationRule, err := r.NetworkingV1alpha3().DestinationRules(namespace).Get(context.TODO(), dr.GetName(), metav1.GetOptions{})
 		if err != nil {
+			metrics.IstioWrite("DestinationRule", metrics.OperationUpdate, err)
 			log.Warnf("Failed updating Istio gateway %v: %v", dr.GetName(), err)
 			return updatedDestinationRule, err
 		}
 		updatedDestinationRule.Spec = dr.Spec
+		updatedDestinationRule.Labels = dr.Labels
+		updatedDestinationRule.OwnerReferences = dr.OwnerReferences
 		updatedDestinationRule, err = r.NetworkingV1alpha3().DestinationRules(namespace).Update(context.TODO(), updatedDestinationRule, metav1.UpdateOptions{})
+		metrics.IstioWrite("DestinationRule", metrics.OperationUpdate, err)
 		return updatedDestinationRule, err
 	}
+	metrics.IstioWrite("DestinationRule", metrics.OperationCreate, err)
 	return createdDestinationRule, err
 }
 
@@ -97,27 +113,21 @@ func createServiceEntry(r istioclient.Interface, namespace string, dr *v1alpha3.
 	if mfutil.ErrorAlreadyExists(err) {
 		updatedServiceEntry, err := r.NetworkingV1alpha3().ServiceEntries(namespace).Get(context.TODO(), dr.GetName(), metav1.GetOptions{})
 		if err != nil {
+			metrics.IstioWrite("ServiceEntry", metrics.OperationUpdate, err)
 			log.Warnf("Failed updating Istio gateway %v: %v", dr.GetName(), err)
 			return updatedServiceEntry, err
 		}
 		updatedServiceEntry.Spec = dr.Spec
+		updatedServiceEntry.Labels = dr.Labels
+		updatedServiceEntry.OwnerReferences = dr.OwnerReferences
 		updatedServiceEntry, err = r.NetworkingV1alpha3().ServiceEntries(namespace).Update(context.TODO(), updatedServiceEntry, metav1.UpdateOptions{})
+		metrics.IstioWrite("ServiceEntry", metrics.OperationUpdate, err)
 		return updatedServiceEntry, err
 	}
+	metrics.IstioWrite("ServiceEntry", metrics.OperationCreate, err)
 	return createdServiceEntry, err
 }
 
-func ownerReference(apiVersion, kind string, owner metav1.ObjectMeta) []metav1.OwnerReference {
-	return []metav1.OwnerReference{
-		{
-			APIVersion: apiVersion,
-			Kind:       kind,
-			Name:       owner.GetName(),
-			UID:        owner.GetUID(),
-		},
-	}
-}
-
 // GetIngressEndpointsNoPort find the ingress endpoint
 func GetIngressEndpointsNoPort(ctx context.Context, c client.Client, name string, namespace string, port uint32) ([]string, error) {
 	var ingressService corev1.Service
@@ -131,11 +141,7 @@ func GetIngressEndpointsNoPort(ctx context.Context, c client.Client, name string
 		log.Warnf("ingress service %v not found with err: %v ", nsn, ingressService)
 		return nil, err
 	}
-	if len(ingressService.Status.LoadBalancer.Ingress) > 0 {
-		var s []string
-		for _, ingress := range ingressService.Status.LoadBalancer.Ingress {
-			s = append(s, fmt.Sprintf("%s:%d", ingress.IP, port))
-		}
+	if s := mfutil.LoadBalancerEndpoints(ingressService.Status.LoadBalancer, port); len(s) > 0 {
 		return s, nil
 	}
 	return nil, fmt.Errorf("Did not find a host IP")
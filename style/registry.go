@@ -0,0 +1,94 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package style
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+
+	istioclient "istio.io/client-go/pkg/clientset/versioned"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Style is a data plane style, selected by the mode of a MeshFedConfig.  A style registers
+// itself with Register, usually from the init function of its package, so that importing the
+// package makes it available to the controllers and validators.
+type Style struct {
+	// Mode is the MeshFedConfig mode selecting the style.  It is matched case insensitively.
+	Mode string
+	// NewMeshFedConfig, NewServiceExposer and NewServiceBinder create the implementations of
+	// the style
+	NewMeshFedConfig  func(cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) MeshFedConfig
+	NewServiceExposer func(cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) ServiceExposer
+	NewServiceBinder  func(cli client.Client, istioCli istioclient.Interface, recorder record.EventRecorder) ServiceBinder
+	// Validate checks the spec of the MeshFedConfig namespace/name.  It may be nil.
+	Validate func(name, namespace string, mfc mmv1.MeshFedConfigSpec) error
+	// Default fills in the gateway selectors and ports a MeshFedConfig leaves to the style.
+	// It is called by the defaulting webhook before Validate, and may be nil.
+	Default func(mfc *mmv1.MeshFedConfigSpec)
+	// IngressAddresses returns the host:port addresses clients of exposed services reach the
+	// ingress of a MeshFedConfig at.  Unlike the endpoints of expositions, which remote meshes
+	// bind to, they may be host names.  It may be nil.
+	IngressAddresses func(ctx context.Context, cli client.Client, mfc *mmv1.MeshFedConfig) ([]string, error)
+	// IngressScheme is the URL scheme clients of exposed services use at the ingress
+	IngressScheme string
+}
+
+var (
+	stylesMutex sync.RWMutex
+	styles      = map[string]Style{}
+)
+
+// Register makes a style available under its mode.  It panics if the style is incomplete or
+// its mode is already registered.
+func Register(s Style) {
+	if s.Mode == "" || s.NewMeshFedConfig == nil || s.NewServiceExposer == nil || s.NewServiceBinder == nil {
+		panic(fmt.Sprintf("style %q must have a mode and all its constructors", s.Mode))
+	}
+	stylesMutex.Lock()
+	defer stylesMutex.Unlock()
+	mode := strings.ToUpper(s.Mode)
+	if _, ok := styles[mode]; ok {
+		panic(fmt.Sprintf("style %q registered twice", mode))
+	}
+	styles[mode] = s
+}
+
+// Lookup returns the style registered for mode
+func Lookup(mode string) (Style, bool) {
+	stylesMutex.RLock()
+	defer stylesMutex.RUnlock()
+	s, ok := styles[strings.ToUpper(mode)]
+	return s, ok
+}
+
+// Modes returns the registered modes, sorted
+func Modes() []string {
+	stylesMutex.RLock()
+	defer stylesMutex.RUnlock()
+	modes := make([]string, 0, len(styles))
+	for mode := range styles {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	return modes
+}
@@ -0,0 +1,59 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package style
+
+import (
+	"strings"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Failed marks the Reconciled condition False with reason and the error message, and returns err.
+// The controllers keep a style's reason as long as the returned error is unchanged.
+func Failed(conditions *[]mmv1.Condition, reason string, err error) error {
+	mmv1.SetCondition(conditions, mmv1.ConditionReconciled, corev1.ConditionFalse, reason, err.Error())
+	return err
+}
+
+// EndpointsResolved sets the EndpointsResolved condition from a list of endpoints
+func EndpointsResolved(conditions *[]mmv1.Condition, endpoints []string) {
+	if len(endpoints) == 0 {
+		mmv1.SetCondition(conditions, mmv1.ConditionEndpointsResolved, corev1.ConditionFalse,
+			mmv1.ReasonEndpointsUnavailable, "no endpoints")
+		return
+	}
+	mmv1.SetCondition(conditions, mmv1.ConditionEndpointsResolved, corev1.ConditionTrue,
+		mmv1.ReasonEndpointsResolved, strings.Join(endpoints, ","))
+}
+
+// EndpointsUnresolved marks the EndpointsResolved condition False with the error message
+func EndpointsUnresolved(conditions *[]mmv1.Condition, err error) {
+	mmv1.SetCondition(conditions, mmv1.ConditionEndpointsResolved, corev1.ConditionFalse,
+		mmv1.ReasonEndpointsUnavailable, err.Error())
+}
+
+// Generated describes an object created by a style, for the owner's status
+func Generated(apiVersion, kind string, om metav1.Object) mmv1.GeneratedObject {
+	return mmv1.GeneratedObject{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  om.GetNamespace(),
+		Name:       om.GetName(),
+	}
+}
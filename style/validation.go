@@ -0,0 +1,49 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package style
+
+import (
+	"fmt"
+
+	multierror "github.com/hashicorp/go-multierror"
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+)
+
+// ValidateGateways checks that a MeshFedConfig only selects and sets the port of the egress
+// and ingress gateways it uses
+func ValidateGateways(name, namespace string, mfc mmv1.MeshFedConfigSpec) error {
+	var retval error
+	if !mfc.UseEgressGateway {
+		if len(mfc.EgressGatewaySelector) != 0 {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify egress, but selects one", namespace, name))
+		}
+
+		if mfc.EgressGatewayPort != 0 {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify egress, but specifies port %v", namespace, name, mfc.EgressGatewayPort))
+		}
+	}
+
+	if !mfc.UseIngressGateway {
+		if len(mfc.IngressGatewaySelector) != 0 {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify ingress, but selects one", namespace, name))
+		}
+
+		if mfc.IngressGatewayPort != 0 {
+			retval = multierror.Append(retval, fmt.Errorf("%s/%s: does not specify ingress, but specifies port %v", namespace, name, mfc.IngressGatewayPort))
+		}
+	}
+	return retval
+}
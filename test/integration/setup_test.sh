@@ -366,7 +366,7 @@ main() {
        # Bind helloworld to the actual dynamic exposed public IP
        BINDING="cat ./samples/$MODE/helloworld-binding.yaml | sed s/9.1.2.3:5000/$CLUSTER2_INGRESS:15443/ | kubectl --context $CLUSTER1 apply -f -"
     else
-       BINDING="kubectl --context $CLUSTER3  apply -f ./samples/service_discovery.yaml"
+       BINDING="kubectl --context $CLUSTER3  apply -f ./samples/discovery_peer.yaml"
     fi
 
     # Wait for the exposure to be affected
@@ -338,7 +338,7 @@ main() {
        # Bind helloworld to the actual dynamic exposed public IP
        cat $BASEDIR/samples/$MODE/helloworld-binding.yaml | sed s/9.1.2.3:5000/$CLUSTER2_INGRESS:15443/ | kubectl --context $CLUSTER1 apply -f -
     else
-       kubectl --context $CLUSTER3  apply -f $BASEDIR/samples/service_discovery.yaml
+       kubectl --context $CLUSTER3  apply -f $BASEDIR/samples/discovery_peer.yaml
     fi
 
     # Wait for the exposure to be affected
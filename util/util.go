@@ -18,6 +18,8 @@ package util
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
 
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
@@ -160,17 +162,113 @@ func GetIngressEndpoints(ctx context.Context, c client.Client, name string, name
 		log.Warnf("ingress service %v not found with err: %v ", nsn, ingressService)
 		return nil, err
 	}
-	if len(ingressService.Status.LoadBalancer.Ingress) > 0 {
-		var s []string
-		for _, ingress := range ingressService.Status.LoadBalancer.Ingress {
-			s = append(s, fmt.Sprintf("%s:%d", ingress.IP, port))
-		}
+	if s := LoadBalancerEndpoints(ingressService.Status.LoadBalancer, port); len(s) > 0 {
 		return s, nil
 	} else {
 		return nil, fmt.Errorf("Did not find a host IP")
 	}
 }
 
+// GetIngressAddresses returns the host:port addresses of the load balancer of the Service
+// name, by hostname for load balancers known by DNS name.  They are for clients that resolve
+// names, such as those of an OpenAPI catalogue; remote meshes bind to GetIngressEndpoints.
+func GetIngressAddresses(ctx context.Context, c client.Client, name string, namespace string, port uint32) ([]string, error) {
+	var ingressService corev1.Service
+	nsn := types.NamespacedName{Name: name, Namespace: namespace}
+	if err := c.Get(ctx, nsn, &ingressService); err != nil {
+		return nil, err
+	}
+	if s := loadBalancerAddresses(ingressService.Status.LoadBalancer, port, true); len(s) > 0 {
+		return s, nil
+	}
+	return nil, fmt.Errorf("Did not find an address of %v", nsn)
+}
+
+// LoadBalancerEndpoints returns the ip:port of each ingress of a load balancer.  Remote meshes
+// bind to IP addresses, so ingresses known only by hostname are skipped.
+func LoadBalancerEndpoints(lb corev1.LoadBalancerStatus, port uint32) []string {
+	return loadBalancerAddresses(lb, port, false)
+}
+
+func loadBalancerAddresses(lb corev1.LoadBalancerStatus, port uint32, hostnames bool) []string {
+	var s []string
+	for _, ingress := range lb.Ingress {
+		host := ingress.IP
+		if host == "" && hostnames {
+			host = ingress.Hostname
+		}
+		if host == "" {
+			continue
+		}
+		s = append(s, net.JoinHostPort(host, strconv.Itoa(int(port))))
+	}
+	return s
+}
+
+// Node and pod labels placing the ingress gateway in the topology
+const (
+	regionLabel     = "topology.kubernetes.io/region"
+	zoneLabel       = "topology.kubernetes.io/zone"
+	betaRegionLabel = "failure-domain.beta.kubernetes.io/region"
+	betaZoneLabel   = "failure-domain.beta.kubernetes.io/zone"
+	subzoneLabel    = "topology.istio.io/subzone"
+	networkLabel    = "topology.istio.io/network"
+)
+
+// GetIngressTopology returns the locality (region/zone/subzone) and Istio network of the node of
+// a running pod of the named ingress service.  The network may also be a label of the pod.
+func GetIngressTopology(ctx context.Context, c client.Client, name string, namespace string) (string, string, error) {
+	var ingressService corev1.Service
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &ingressService); err != nil {
+		return "", "", err
+	}
+	if len(ingressService.Spec.Selector) == 0 {
+		return "", "", fmt.Errorf("ingress service %s.%s has no selector", name, namespace)
+	}
+	var pods corev1.PodList
+	if err := c.List(ctx, &pods, client.InNamespace(namespace), client.MatchingLabels(ingressService.Spec.Selector)); err != nil {
+		return "", "", err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Spec.NodeName == "" {
+			continue
+		}
+		var node corev1.Node
+		if err := c.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, &node); err != nil {
+			return "", "", err
+		}
+		network := node.Labels[networkLabel]
+		if network == "" {
+			network = pod.Labels[networkLabel]
+		}
+		return nodeLocality(node.Labels), network, nil
+	}
+	return "", "", fmt.Errorf("no running pod of ingress service %s.%s", name, namespace)
+}
+
+// nodeLocality is the region/zone/subzone of a node, empty if its region is unknown
+func nodeLocality(lbls map[string]string) string {
+	region := lbls[regionLabel]
+	if region == "" {
+		region = lbls[betaRegionLabel]
+	}
+	zone := lbls[zoneLabel]
+	if zone == "" {
+		zone = lbls[betaZoneLabel]
+	}
+	if region == "" {
+		return ""
+	}
+	locality := region
+	if zone != "" {
+		locality += "/" + zone
+		if subzone := lbls[subzoneLabel]; subzone != "" {
+			locality += "/" + subzone
+		}
+	}
+	return locality
+}
+
 func GetTlsSecret(ctx context.Context, c client.Client, tlsSelector client.MatchingLabels) (corev1.Secret, error) {
 	var tlsSecretList corev1.SecretList
 	var tlsSecret corev1.Secret
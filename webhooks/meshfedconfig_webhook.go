@@ -0,0 +1,71 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/pkg/validate"
+	"github.com/istio-ecosystem/emcee/style"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/mutate-mm-ibm-istio-io-v1-meshfedconfig,mutating=true,failurePolicy=fail,groups=mm.ibm.istio.io,resources=meshfedconfigs,verbs=create;update,versions=v1,name=mmeshfedconfig.kb.io
+// +kubebuilder:webhook:path=/validate-mm-ibm-istio-io-v1-meshfedconfig,mutating=false,failurePolicy=fail,groups=mm.ibm.istio.io,resources=meshfedconfigs,verbs=create;update,versions=v1,name=vmeshfedconfig.kb.io
+
+// MeshFedConfigDefaulter sets the gateway selectors and ports the style of a MeshFedConfig
+// would otherwise default each time it is reconciled
+type MeshFedConfigDefaulter struct {
+	decoding
+}
+
+// Handle implements admission.Handler
+func (d *MeshFedConfigDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var mfc mmv1.MeshFedConfig
+	if err := d.decoder.Decode(req, &mfc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// An unknown mode is left to the validating webhook
+	s, ok := style.Lookup(mfc.Spec.Mode)
+	if !ok || s.Default == nil {
+		return admission.Allowed("")
+	}
+	s.Default(&mfc.Spec)
+
+	marshaled, err := json.Marshal(&mfc)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// MeshFedConfigValidator rejects MeshFedConfigs validate.MeshConfig finds invalid
+type MeshFedConfigValidator struct {
+	decoding
+}
+
+// Handle implements admission.Handler
+func (v *MeshFedConfigValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var mfc mmv1.MeshFedConfig
+	return v.validateRequest(req, &mfc, func() error {
+		return validate.MeshConfig(mfc.GetName(), mfc.GetNamespace(), mfc.Spec)
+	})
+}
@@ -0,0 +1,40 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"context"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"github.com/istio-ecosystem/emcee/pkg/validate"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/validate-mm-ibm-istio-io-v1-servicebinding,mutating=false,failurePolicy=fail,groups=mm.ibm.istio.io,resources=servicebindings,verbs=create;update,versions=v1,name=vservicebinding.kb.io
+
+// ServiceBindingValidator rejects ServiceBindings validate.ServiceBinding finds invalid
+type ServiceBindingValidator struct {
+	decoding
+}
+
+// Handle implements admission.Handler
+func (v *ServiceBindingValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var sb mmv1.ServiceBinding
+	return v.validateRequest(req, &sb, func() error {
+		return validate.ServiceBinding(sb.GetName(), sb.GetNamespace(), sb.Spec)
+	})
+}
@@ -0,0 +1,81 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhooks admits emcee's custom resources.  The validating webhooks reject the specs
+// pkg/validate finds invalid, and the defaulting webhook persists the gateway defaults of the
+// MeshFedConfig's style.
+package webhooks
+
+import (
+	"net/http"
+
+	"istio.io/pkg/log"
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// The paths of the webhooks, as in config/webhook/manifests.yaml
+const (
+	mutateMeshFedConfigPath       = "/mutate-mm-ibm-istio-io-v1-meshfedconfig"
+	validateMeshFedConfigPath     = "/validate-mm-ibm-istio-io-v1-meshfedconfig"
+	validateServiceExpositionPath = "/validate-mm-ibm-istio-io-v1-serviceexposition"
+	validateServiceBindingPath    = "/validate-mm-ibm-istio-io-v1-servicebinding"
+)
+
+// SetupWithManager registers the webhooks with the manager's webhook server
+func SetupWithManager(mgr ctrl.Manager) {
+	server := mgr.GetWebhookServer()
+	server.Register(mutateMeshFedConfigPath, &webhook.Admission{Handler: &MeshFedConfigDefaulter{}})
+	server.Register(validateMeshFedConfigPath, &webhook.Admission{Handler: &MeshFedConfigValidator{}})
+	server.Register(validateServiceExpositionPath, &webhook.Admission{Handler: &ServiceExpositionValidator{}})
+	server.Register(validateServiceBindingPath, &webhook.Admission{Handler: &ServiceBindingValidator{}})
+}
+
+// decoding is embedded by the handlers to have the webhook server inject a decoder
+type decoding struct {
+	decoder *admission.Decoder
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (d *decoding) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// validateRequest decodes the object of a create or update into obj and admits it if validate
+// accepts it.  Objects being deleted are admitted, so that removing their finalizers cannot fail.
+func (d *decoding) validateRequest(req admission.Request, obj interface {
+	runtime.Object
+	metav1.Object
+}, validate func() error) admission.Response {
+	if req.Operation != v1beta1.Create && req.Operation != v1beta1.Update {
+		return admission.Allowed("")
+	}
+	if err := d.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if obj.GetDeletionTimestamp() != nil {
+		return admission.Allowed("")
+	}
+	if err := validate(); err != nil {
+		log.Infof("Denied %s %s %s/%s: %v", req.Operation, req.Kind.Kind, req.Namespace, req.Name, err)
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("")
+}
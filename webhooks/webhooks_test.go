@@ -0,0 +1,234 @@
+// Licensed Materials - Property of IBM
+// (C) Copyright IBM Corp. 2019. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	mmv1 "github.com/istio-ecosystem/emcee/api/v1"
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	// The styles whose validators the webhooks run
+	_ "github.com/istio-ecosystem/emcee/style/builtin"
+)
+
+func newTestDecoding(t *testing.T) decoding {
+	scheme := runtime.NewScheme()
+	if err := mmv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Could not create scheme: %v", err)
+	}
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatalf("Could not create decoder: %v", err)
+	}
+	return decoding{decoder: decoder}
+}
+
+func newRequest(t *testing.T, op v1beta1.Operation, obj runtime.Object) admission.Request {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("Could not marshal %v: %v", obj, err)
+	}
+	return admission.Request{AdmissionRequest: v1beta1.AdmissionRequest{
+		Operation: op,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+}
+
+func meshFedConfig(spec mmv1.MeshFedConfigSpec) *mmv1.MeshFedConfig {
+	return &mmv1.MeshFedConfig{
+		TypeMeta:   metav1.TypeMeta{APIVersion: mmv1.GroupVersion.String(), Kind: "MeshFedConfig"},
+		ObjectMeta: metav1.ObjectMeta{Name: "fed", Namespace: "mesh-system"},
+		Spec:       spec,
+	}
+}
+
+func TestMeshFedConfigDefaulter(t *testing.T) {
+	cases := []struct {
+		name string
+		spec mmv1.MeshFedConfigSpec
+		// paths are the fields the patch sets
+		paths []string
+	}{
+		{
+			name:  "passthrough ingress",
+			spec:  mmv1.MeshFedConfigSpec{Mode: "PASSTHROUGH", UseIngressGateway: true},
+			paths: []string{"/spec/ingress_gateway_selector"},
+		},
+		{
+			name: "passthrough selecting its ingress",
+			spec: mmv1.MeshFedConfigSpec{Mode: "PASSTHROUGH", UseIngressGateway: true,
+				IngressGatewaySelector: map[string]string{"app": "ingress"}},
+		},
+		{
+			name: "boundary",
+			spec: mmv1.MeshFedConfigSpec{Mode: "BOUNDARY", UseIngressGateway: true, UseEgressGateway: true},
+			paths: []string{"/spec/egress_gateway_port", "/spec/egress_gateway_selector",
+				"/spec/ingress_gateway_port", "/spec/ingress_gateway_selector"},
+		},
+		{
+			name:  "gateway api, mode matched case insensitively",
+			spec:  mmv1.MeshFedConfigSpec{Mode: "gateway_api", UseIngressGateway: true},
+			paths: []string{"/spec/ingress_gateway_port"},
+		},
+		{
+			name: "without gateways",
+			spec: mmv1.MeshFedConfigSpec{Mode: "PASSTHROUGH"},
+		},
+		{
+			name: "unknown mode",
+			spec: mmv1.MeshFedConfigSpec{Mode: "UNKNOWN", UseIngressGateway: true},
+		},
+	}
+	d := &MeshFedConfigDefaulter{newTestDecoding(t)}
+	for _, c := range cases {
+		resp := d.Handle(context.Background(), newRequest(t, v1beta1.Create, meshFedConfig(c.spec)))
+		if !resp.Allowed {
+			t.Errorf("%s: denied: %v", c.name, resp.Result)
+			continue
+		}
+		var paths []string
+		for _, p := range resp.Patches {
+			paths = append(paths, p.Path)
+		}
+		sort.Strings(paths)
+		if strings.Join(paths, ",") != strings.Join(c.paths, ",") {
+			t.Errorf("%s: patched %v, expected %v", c.name, paths, c.paths)
+		}
+	}
+}
+
+func TestMeshFedConfigValidator(t *testing.T) {
+	cases := []struct {
+		name    string
+		spec    mmv1.MeshFedConfigSpec
+		allowed bool
+	}{
+		{
+			name:    "boundary",
+			spec:    mmv1.MeshFedConfigSpec{Mode: "BOUNDARY", UseIngressGateway: true, TlsContextSelector: map[string]string{"mesh": "fed"}},
+			allowed: true,
+		},
+		{
+			name: "boundary without TLS context",
+			spec: mmv1.MeshFedConfigSpec{Mode: "BOUNDARY", UseIngressGateway: true},
+		},
+		{
+			name:    "passthrough",
+			spec:    mmv1.MeshFedConfigSpec{Mode: "PASSTHROUGH", UseIngressGateway: true, IngressGatewayPort: 443},
+			allowed: true,
+		},
+		{
+			name: "passthrough with port of unused egress",
+			spec: mmv1.MeshFedConfigSpec{Mode: "PASSTHROUGH", EgressGatewayPort: 443},
+		},
+		{
+			name:    "gateway api",
+			spec:    mmv1.MeshFedConfigSpec{Mode: "GATEWAY_API", UseIngressGateway: true},
+			allowed: true,
+		},
+		{
+			name: "gateway api with egress",
+			spec: mmv1.MeshFedConfigSpec{Mode: "GATEWAY_API", UseIngressGateway: true, UseEgressGateway: true},
+		},
+		{
+			name: "unknown mode",
+			spec: mmv1.MeshFedConfigSpec{Mode: "UNKNOWN"},
+		},
+	}
+	v := &MeshFedConfigValidator{newTestDecoding(t)}
+	for _, c := range cases {
+		resp := v.Handle(context.Background(), newRequest(t, v1beta1.Create, meshFedConfig(c.spec)))
+		if resp.Allowed != c.allowed {
+			t.Errorf("%s: allowed is %v, expected %v: %v", c.name, resp.Allowed, c.allowed, resp.Result)
+		}
+	}
+
+	// Deletions, and objects being deleted, are always admitted
+	invalid := meshFedConfig(mmv1.MeshFedConfigSpec{Mode: "UNKNOWN"})
+	if resp := v.Handle(context.Background(), newRequest(t, v1beta1.Delete, invalid)); !resp.Allowed {
+		t.Errorf("deletion denied: %v", resp.Result)
+	}
+	now := metav1.Now()
+	invalid.DeletionTimestamp = &now
+	if resp := v.Handle(context.Background(), newRequest(t, v1beta1.Update, invalid)); !resp.Allowed {
+		t.Errorf("update of an object being deleted denied: %v", resp.Result)
+	}
+}
+
+func TestServiceExpositionValidator(t *testing.T) {
+	selector := map[string]string{"mesh": "fed"}
+	cases := []struct {
+		name    string
+		spec    mmv1.ServiceExpositionSpec
+		allowed bool
+	}{
+		{name: "valid", spec: mmv1.ServiceExpositionSpec{Name: "helloworld", Port: 5000, MeshFedConfigSelector: selector}, allowed: true},
+		{name: "without selector", spec: mmv1.ServiceExpositionSpec{Name: "helloworld", Port: 5000}},
+		{name: "without ports", spec: mmv1.ServiceExpositionSpec{Name: "helloworld", MeshFedConfigSelector: selector}},
+		{name: "invalid name", spec: mmv1.ServiceExpositionSpec{Name: "Hello_World", Port: 5000, MeshFedConfigSelector: selector}},
+	}
+	v := &ServiceExpositionValidator{newTestDecoding(t)}
+	for _, c := range cases {
+		se := &mmv1.ServiceExposition{
+			TypeMeta:   metav1.TypeMeta{APIVersion: mmv1.GroupVersion.String(), Kind: "ServiceExposition"},
+			ObjectMeta: metav1.ObjectMeta{Name: "helloworld", Namespace: "default"},
+			Spec:       c.spec,
+		}
+		resp := v.Handle(context.Background(), newRequest(t, v1beta1.Create, se))
+		if resp.Allowed != c.allowed {
+			t.Errorf("%s: allowed is %v, expected %v: %v", c.name, resp.Allowed, c.allowed, resp.Result)
+		}
+	}
+}
+
+func TestServiceBindingValidator(t *testing.T) {
+	selector := map[string]string{"mesh": "fed"}
+	cases := []struct {
+		name    string
+		spec    mmv1.ServiceBindingSpec
+		allowed bool
+	}{
+		// Discovery fills in the endpoints later
+		{name: "without endpoints", spec: mmv1.ServiceBindingSpec{Name: "helloworld", Port: 5000, MeshFedConfigSelector: selector}, allowed: true},
+		{name: "without selector", spec: mmv1.ServiceBindingSpec{Name: "helloworld", Port: 5000}},
+		{name: "invalid alias", spec: mmv1.ServiceBindingSpec{Name: "helloworld", Alias: "Hello.World", MeshFedConfigSelector: selector}},
+		{
+			name: "port and ports",
+			spec: mmv1.ServiceBindingSpec{Name: "helloworld", Port: 5000, MeshFedConfigSelector: selector,
+				Ports: []mmv1.ServicePort{{Name: "http", Number: 5000}}},
+		},
+	}
+	v := &ServiceBindingValidator{newTestDecoding(t)}
+	for _, c := range cases {
+		sb := &mmv1.ServiceBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: mmv1.GroupVersion.String(), Kind: "ServiceBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: "helloworld", Namespace: "default"},
+			Spec:       c.spec,
+		}
+		resp := v.Handle(context.Background(), newRequest(t, v1beta1.Create, sb))
+		if resp.Allowed != c.allowed {
+			t.Errorf("%s: allowed is %v, expected %v: %v", c.name, resp.Allowed, c.allowed, resp.Result)
+		}
+	}
+}